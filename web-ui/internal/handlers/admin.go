@@ -0,0 +1,520 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+	"web-ui/internal/utils"
+)
+
+// requireAdmin checks the request carries the ADMIN_TOKEN and writes a 401/403 if not.
+// The admin API is disabled entirely when ADMIN_TOKEN is unset.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		http.Error(w, "Admin API is disabled. Set ADMIN_TOKEN to enable it", http.StatusForbidden)
+		return false
+	}
+
+	provided := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if provided == "" {
+		provided = r.Header.Get("X-Admin-Token")
+	}
+
+	if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// AdminTeams creates or replaces a team (POST) or deletes one (DELETE /api/admin/teams/{id})
+func (h *APIHandler) AdminTeams(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case "POST", "PUT":
+		var team models.Team
+		if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
+			http.Error(w, "Invalid request data", http.StatusBadRequest)
+			return
+		}
+		if err := h.teamService.SaveTeam(&team); err != nil {
+			http.Error(w, "Failed to save team: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"team":    team,
+		})
+	case "DELETE":
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/teams"), "/")
+		if id == "" {
+			http.Error(w, "Team ID is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.teamService.DeleteTeam(id); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrTeamNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// AdminCohorts creates or replaces a cohort (POST) or deletes one (DELETE /api/admin/cohorts/{id})
+func (h *APIHandler) AdminCohorts(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case "POST", "PUT":
+		var cohort models.Cohort
+		if err := json.NewDecoder(r.Body).Decode(&cohort); err != nil {
+			http.Error(w, "Invalid request data", http.StatusBadRequest)
+			return
+		}
+		if err := h.cohortService.SaveCohort(&cohort); err != nil {
+			http.Error(w, "Failed to save cohort: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"cohort":  cohort,
+		})
+	case "DELETE":
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/cohorts"), "/")
+		if id == "" {
+			http.Error(w, "Cohort ID is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.cohortService.DeleteCohort(id); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrCohortNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// AdminStatus reports executor, content and cache status
+func (h *APIHandler) AdminStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sponsorCount, sponsorsUpdated := sponsorCacheInfo()
+	response := map[string]interface{}{
+		"success":  true,
+		"executor": h.executionService.Status(),
+		"content": map[string]int{
+			"challenges": len(h.challengeService.GetChallenges()),
+			"packages":   len(h.packageService.GetPackages()),
+			"teams":      len(h.teamService.GetTeams()),
+			"cohorts":    len(h.cohortService.GetCohorts()),
+			"tracks":     len(h.trackService.GetTracks()),
+		},
+		"caches": map[string]interface{}{
+			"sponsors": map[string]interface{}{
+				"count":       sponsorCount,
+				"lastUpdated": sponsorsUpdated,
+			},
+			"ai": h.aiService.Usage().Report().Cache,
+		},
+		"workspace": map[string]string{
+			"root":  h.workspaceService.Root(),
+			"owner": h.workspaceService.Owner(),
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AdminExecutions lists recent executions, newest first (?limit=N&failures=true)
+func (h *APIHandler) AdminExecutions(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	failuresOnly := r.URL.Query().Get("failures") == "true"
+
+	response := map[string]interface{}{
+		"success":    true,
+		"executions": h.executionService.RecentExecutions(limit, failuresOnly),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AdminReload reloads challenges, scoreboards, packages, teams, cohorts and learning tracks from disk
+func (h *APIHandler) AdminReload(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.challengeService.LoadChallenges(); err != nil {
+		http.Error(w, "Failed to reload challenges: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	challenges := h.challengeService.GetChallenges()
+	if err := h.scoreboardService.LoadScoreboards(challenges); err != nil {
+		http.Error(w, "Failed to reload scoreboards: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	packages := h.packageService.RefreshPackages()
+	// Teams and cohorts are validated against the freshly loaded challenges
+	if err := h.teamService.LoadTeams(); err != nil {
+		http.Error(w, "Failed to reload teams: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.cohortService.LoadCohorts(); err != nil {
+		http.Error(w, "Failed to reload cohorts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.trackService.LoadTracks(); err != nil {
+		http.Error(w, "Failed to reload learning tracks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.userService.ClearAttempts()
+	h.searchService.Rebuild()
+
+	// A broken prompt override only disables that override, so it does not fail the reload
+	promptErr := h.aiService.Prompts().Load()
+
+	log.Printf("Admin reload: %d challenges, %d packages", len(challenges), len(packages))
+
+	response := map[string]interface{}{
+		"success":    true,
+		"challenges": len(challenges),
+		"packages":   len(packages),
+		"teams":      len(h.teamService.GetTeams()),
+		"cohorts":    len(h.cohortService.GetCohorts()),
+		"prompts":    len(h.aiService.Prompts().Prompts()),
+	}
+	if promptErr != nil {
+		response["promptErrors"] = promptErr.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AdminInvalidateCache clears the named caches: sponsors, stars, attempts, ai. No names clears all of them.
+func (h *APIHandler) AdminInvalidateCache(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Caches []string `json:"caches"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request data", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(request.Caches) == 0 {
+		request.Caches = []string{"sponsors", "stars", "attempts", "ai"}
+	}
+
+	cleared := make(map[string]interface{})
+	for _, name := range request.Caches {
+		switch name {
+		case "sponsors":
+			invalidateSponsorCache()
+			cleared[name] = true
+		case "stars":
+			// Stars are fetched while loading package metadata
+			cleared[name] = len(h.packageService.RefreshPackages())
+		case "attempts":
+			cleared[name] = h.userService.ClearAttempts()
+		case "ai":
+			cleared[name] = h.aiService.Usage().ClearCache()
+		default:
+			http.Error(w, fmt.Sprintf("Unknown cache %q. Use sponsors, stars, attempts or ai", name), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"cleared": cleared,
+	})
+}
+
+// AdminUser is a known user as listed in the admin console
+type AdminUser struct {
+	Username       string   `json:"username"`
+	CompletedCount int      `json:"completedCount"`
+	Rank           int      `json:"rank"`
+	IsSponsor      bool     `json:"isSponsor"`
+	Teams          []string `json:"teams"`
+	Cohorts        []string `json:"cohorts"`
+}
+
+// AdminUsers lists users on the main leaderboard or in a team or cohort (GET),
+// or refreshes one user's cached attempts (POST /api/admin/users/{username}/refresh)
+func (h *APIHandler) AdminUsers(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	if r.Method == "POST" {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/users"), "/"), "/")
+		if len(parts) != 2 || parts[1] != "refresh" || !services.ValidGitHubUsername(parts[0]) {
+			http.Error(w, "Expected /api/admin/users/{username}/refresh", http.StatusBadRequest)
+			return
+		}
+		attempts := h.userService.RefreshUserAttempts(parts[0], h.challengeService.GetChallenges())
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  true,
+			"attempts": attempts,
+		})
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	users := make(map[string]*AdminUser)
+	user := func(username string) *AdminUser {
+		key := strings.ToLower(username)
+		if users[key] == nil {
+			users[key] = &AdminUser{Username: username, Teams: []string{}, Cohorts: []string{}}
+		}
+		return users[key]
+	}
+
+	for _, entry := range h.leaderboardService.MainLeaderboard(h.LoadSponsors()) {
+		u := user(entry.Username)
+		u.CompletedCount = entry.CompletedCount
+		u.Rank = entry.Rank
+		u.IsSponsor = entry.IsSponsor
+	}
+	for _, team := range h.teamService.GetTeams() {
+		for _, member := range team.Members {
+			u := user(member)
+			u.Teams = append(u.Teams, team.ID)
+		}
+	}
+	for _, cohort := range h.cohortService.GetCohorts() {
+		for _, member := range cohort.Members {
+			u := user(member)
+			u.Cohorts = append(u.Cohorts, cohort.ID)
+		}
+	}
+
+	result := make([]*AdminUser, 0, len(users))
+	for _, u := range users {
+		result = append(result, u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Username) < strings.ToLower(result[j].Username)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"users":   result,
+	})
+}
+
+// AdminSponsorsDebug returns the cached sponsor list
+func (h *APIHandler) AdminSponsorsDebug(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	h.GetSponsorsDebug(w, r)
+}
+
+// AdminAIDebug returns the raw AI response and prompt for a code review
+func (h *APIHandler) AdminAIDebug(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	h.AIDebugResponse(w, r)
+}
+
+// AdminAIUsage reports today's AI requests, tokens and cache hits per user and per feature
+func (h *APIHandler) AdminAIUsage(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Success bool `json:"success"`
+		services.AIUsageReport
+	}{
+		Success:       true,
+		AIUsageReport: h.aiService.Usage().Report(),
+	})
+}
+
+// AdminAIPrompts lists the prompt templates in use (GET) or reloads them from disk (POST)
+func (h *APIHandler) AdminAIPrompts(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" && r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	prompts := h.aiService.Prompts()
+	response := map[string]interface{}{"success": true}
+	if r.Method == "POST" {
+		if err := prompts.Load(); err != nil {
+			response["success"] = false
+			response["error"] = err.Error()
+		}
+		log.Printf("Admin reloaded AI prompts from %s", prompts.Dir())
+	}
+	response["dir"] = prompts.Dir()
+	response["prompts"] = prompts.Prompts()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AdminAIAuthor drafts a new challenge with the AI, verifies it and, unless it is a dry
+// run, writes it to the repository and reloads the challenges
+func (h *APIHandler) AdminAIAuthor(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request services.AuthoringRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(request.Topic) == "" {
+		http.Error(w, "topic is required", http.StatusBadRequest)
+		return
+	}
+	if request.Difficulty != "" && !services.IsDifficulty(request.Difficulty) {
+		http.Error(w, "difficulty must be one of "+strings.Join(services.Difficulties, ", "), http.StatusBadRequest)
+		return
+	}
+	if request.Package != "" {
+		if _, err := h.packageService.GetPackage(request.Package); err != nil {
+			http.Error(w, "Package not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	report, err := h.authoringService.Author(h.aiCaller(w, r), request)
+	if err != nil {
+		writeAIError(w, err, "Authoring failed")
+		return
+	}
+
+	if report.Directory != "" {
+		log.Printf("Admin authored %s (%s)", report.Directory, report.Draft.Metadata.Title)
+		if err := h.challengeService.LoadChallenges(); err != nil {
+			log.Printf("Warning: could not reload challenges: %v", err)
+		} else if err := h.scoreboardService.LoadScoreboards(h.challengeService.GetChallenges()); err != nil {
+			log.Printf("Warning: could not reload scoreboards: %v", err)
+		}
+		h.packageService.RefreshPackages()
+		h.searchService.Rebuild()
+		if err := h.trackService.LoadTracks(); err != nil {
+			log.Printf("Warning: could not reload learning tracks: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// AdminPage renders the admin console. The page itself holds no data; it calls
+// the admin API with the token the operator enters.
+func (h *WebHandler) AdminPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/admin.html")
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Enabled bool
+	}{
+		Enabled: os.Getenv("ADMIN_TOKEN") != "",
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		log.Printf("Template execution error: %v", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
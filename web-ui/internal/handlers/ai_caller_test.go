@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientNetwork(t *testing.T) {
+	loadTrustedProxies() // mark as loaded so the environment does not override the list below
+	_, proxy, _ := net.ParseCIDR("10.0.0.0/8")
+	trustedProxies = []*net.IPNet{proxy}
+	defer func() { trustedProxies = nil }()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"direct", "203.0.113.7:5000", "", "ip:203.0.113.7"},
+		{"forwarded from untrusted peer", "203.0.113.7:5000", "198.51.100.1", "ip:203.0.113.7"},
+		{"forwarded from trusted proxy", "10.1.2.3:5000", "198.51.100.1", "ip:198.51.100.1"},
+		{"spoofed entry left of the client", "10.1.2.3:5000", "1.2.3.4, 198.51.100.1", "ip:198.51.100.1"},
+		{"proxy chain", "10.1.2.3:5000", "198.51.100.1, 10.9.9.9", "ip:198.51.100.1"},
+		{"malformed entry", "10.1.2.3:5000", "198.51.100.1, junk", "ip:10.1.2.3"},
+		{"ipv6 grouped by /64", "[2001:db8:1:2:aaaa::1]:5000", "", "ip:2001:db8:1:2::/64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/ai/hint", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := clientNetwork(r); got != tt.want {
+				t.Fatalf("clientNetwork() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAICaller(t *testing.T) {
+	h := &APIHandler{}
+	request := func(remoteAddr string, cookies ...*http.Cookie) *http.Request {
+		r := httptest.NewRequest("POST", "/api/ai/hint", nil)
+		r.RemoteAddr = remoteAddr
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	alice := h.aiCaller(w, request("203.0.113.7:5000"))
+	session := w.Result().Cookies()
+	if len(session) != 1 || session[0].Name != sessionCookie {
+		t.Fatalf("no session cookie issued: %v", session)
+	}
+
+	bob := h.aiCaller(httptest.NewRecorder(), request("203.0.113.7:5001"))
+	if alice == bob {
+		t.Fatal("two browsers on one address share a caller")
+	}
+
+	if again := h.aiCaller(httptest.NewRecorder(), request("198.51.100.1:5000", session...)); again != alice {
+		t.Fatalf("the session from another network is %q, want %q", again, alice)
+	}
+
+	username := &http.Cookie{Name: "username", Value: "alice"}
+	if named := h.aiCaller(httptest.NewRecorder(), request("203.0.113.7:5000", username)); named == alice || named == "alice" {
+		t.Fatal("the username cookie chose the caller")
+	}
+
+	if alice == "session:"+session[0].Value || len(alice) != len("session:")+16 {
+		t.Fatalf("caller %q is not a hash of the session", alice)
+	}
+}
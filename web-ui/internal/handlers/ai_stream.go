@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/services"
+)
+
+// aiCaller identifies who an AI request is made for, for quotas and interview sessions:
+// the browser session the server issued (see ensureSession), by a hash of its ID so the
+// ID itself is never stored or shown. The username cookie is set freely, so it identifies
+// nobody. A session is issued when the request has none, so aiCaller must be called
+// before the response is written.
+//
+// A client can always start a new session by dropping the cookie, so the session's network
+// is recorded too: everyone on it shares a larger daily quota.
+func (h *APIHandler) aiCaller(w http.ResponseWriter, r *http.Request) string {
+	network := clientNetwork(r)
+	caller := network
+	if session := ensureSession(w, r); session != "" {
+		sum := sha256.Sum256([]byte(session))
+		caller = "session:" + hex.EncodeToString(sum[:8])
+	}
+	if h.aiService != nil {
+		h.aiService.Usage().SetNetwork(caller, network)
+	}
+	return caller
+}
+
+// clientNetwork identifies the network a request comes from by the client address, which
+// the client cannot choose (X-Forwarded-For only counts from TRUSTED_PROXIES). IPv6
+// clients are grouped by /64, the block a single host is handed.
+func clientNetwork(r *http.Request) string {
+	ip := clientIP(r)
+	if ip == nil {
+		return "ip:" + r.RemoteAddr
+	}
+	if ip.To4() == nil {
+		return "ip:" + ip.Mask(net.CIDRMask(64, 128)).String() + "/64"
+	}
+	return "ip:" + ip.String()
+}
+
+var (
+	trustedProxiesOnce sync.Once
+	trustedProxies     []*net.IPNet
+)
+
+// loadTrustedProxies parses TRUSTED_PROXIES, a comma-separated list of addresses or CIDR
+// ranges of the reverse proxies in front of the server (e.g. "10.0.0.0/8, 127.0.0.1")
+func loadTrustedProxies() []*net.IPNet {
+	trustedProxiesOnce.Do(func() {
+		for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			if !strings.Contains(entry, "/") {
+				if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+					entry += "/32"
+				} else {
+					entry += "/128"
+				}
+			}
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				fmt.Printf("Ignoring invalid TRUSTED_PROXIES entry %q\n", entry)
+				continue
+			}
+			trustedProxies = append(trustedProxies, network)
+		}
+	})
+	return trustedProxies
+}
+
+// isTrustedProxy reports whether ip is listed in TRUSTED_PROXIES
+func isTrustedProxy(ip net.IP) bool {
+	for _, network := range loadTrustedProxies() {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only read when the
+// connection comes from a trusted proxy, and then from the right: the nearest entry that
+// is not itself a trusted proxy was added by one, while entries to its left came from the
+// client.
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isTrustedProxy(ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break // A malformed entry: nothing left of it can be trusted
+		}
+		ip = hop
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// quotaErrorBody is the JSON returned when an AI quota is exhausted
+type quotaErrorBody struct {
+	Error         string `json:"error"`
+	QuotaExceeded bool   `json:"quotaExceeded"`
+	*services.QuotaError
+}
+
+func newQuotaErrorBody(err error) (quotaErrorBody, bool) {
+	var quotaErr *services.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return quotaErrorBody{}, false
+	}
+	return quotaErrorBody{Error: quotaErr.Error(), QuotaExceeded: true, QuotaError: quotaErr}, true
+}
+
+// writeAIError responds 429 with a Retry-After header for quota errors and 500 otherwise
+func writeAIError(w http.ResponseWriter, err error, prefix string) {
+	body, ok := newQuotaErrorBody(err)
+	if !ok {
+		http.Error(w, fmt.Sprintf("%s: %v", prefix, err), http.StatusInternalServerError)
+		return
+	}
+	retryAfter := int(time.Until(body.ResetsAt).Seconds()) + 1
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(body)
+}
+
+// sseWriter writes server-sent events and flushes after each one. The stream
+// starts with the first event, so errors raised before any output can still be
+// sent as a regular HTTP error.
+type sseWriter struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	flusher http.Flusher
+	started bool
+}
+
+// newSSEWriter wraps w for an event stream answering r. It returns false if the
+// connection cannot be flushed incrementally.
+func newSSEWriter(w http.ResponseWriter, r *http.Request) (*sseWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	return &sseWriter{w: w, r: r, flusher: flusher}, true
+}
+
+func (s *sseWriter) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", "text/event-stream")
+	s.w.Header().Set("Cache-Control", "no-cache")
+	s.w.Header().Set("Connection", "keep-alive")
+	s.w.Header().Set("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// send writes one event with a JSON-encoded payload
+func (s *sseWriter) send(event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	s.start()
+	if _, err := s.w.Write([]byte("event: " + event + "\ndata: " + string(data) + "\n\n")); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// finish sends the final event, and logs instead when the client has gone away or
+// the event cannot be written
+func (s *sseWriter) finish(event string, payload interface{}) {
+	if err := s.r.Context().Err(); err != nil {
+		log.Printf("Stream %s closed before its %s event: %v", s.r.URL.Path, event, err)
+		return
+	}
+	if err := s.send(event, payload); err != nil {
+		log.Printf("Error sending %s event on %s: %v", event, s.r.URL.Path, err)
+	}
+}
+
+// fail reports err as an HTTP error (429 for quota errors) if nothing has been streamed yet,
+// otherwise as an "error" event carrying the same fields
+func (s *sseWriter) fail(err error) {
+	if s.r.Context().Err() != nil {
+		log.Printf("Stream %s closed before it finished: %v", s.r.URL.Path, err)
+		return
+	}
+	if !s.started {
+		writeAIError(s.w, err, "AI request failed")
+		return
+	}
+	if body, ok := newQuotaErrorBody(err); ok {
+		s.finish("error", body)
+		return
+	}
+	s.finish("error", map[string]string{"error": err.Error()})
+}
+
+// done sends the final "done" event carrying the result
+func (s *sseWriter) done(payload interface{}) {
+	s.finish("done", payload)
+}
+
+// token forwards a chunk of model output to the browser
+func (s *sseWriter) token(text string) error {
+	return s.send("token", map[string]string{"text": text})
+}
+
+// AICodeReviewStream streams a code review as server-sent events.
+// "token" events carry raw model output; the final "done" event carries the parsed review.
+func (h *APIHandler) AICodeReviewStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Package     string       `json:"package"`
+		ChallengeID challengeRef `json:"challengeId"`
+		Code        string       `json:"code"`
+		Context     string       `json:"context"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	stream, ok := newSSEWriter(w, r)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	review, err := h.aiService.StreamReview(r.Context(), h.aiCaller(w, r), request.Code, challenge, request.Context, stream.token)
+	if err != nil {
+		stream.fail(err)
+		return
+	}
+	stream.done(review)
+}
+
+// AIInterviewerQuestionsStream streams interviewer questions as server-sent events
+func (h *APIHandler) AIInterviewerQuestionsStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Package      string       `json:"package"`
+		ChallengeID  challengeRef `json:"challengeId"`
+		Code         string       `json:"code"`
+		UserProgress string       `json:"userProgress"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	stream, ok := newSSEWriter(w, r)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	questions, promptVersion, err := h.aiService.StreamInterviewerQuestions(r.Context(), h.aiCaller(w, r), request.Code, challenge, request.UserProgress, stream.token)
+	if err != nil {
+		stream.fail(err)
+		return
+	}
+	stream.done(struct {
+		Questions     []string `json:"questions"`
+		Success       bool     `json:"success"`
+		PromptVersion string   `json:"promptVersion,omitempty"`
+	}{
+		Questions:     questions,
+		Success:       true,
+		PromptVersion: promptVersion,
+	})
+}
+
+// AICodeHintStream streams a hint as server-sent events
+func (h *APIHandler) AICodeHintStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Package     string       `json:"package"`
+		ChallengeID challengeRef `json:"challengeId"`
+		Code        string       `json:"code"`
+		HintLevel   int          `json:"hintLevel"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	// Validate hint level
+	if request.HintLevel < 1 || request.HintLevel > 4 {
+		request.HintLevel = 1
+	}
+
+	stream, ok := newSSEWriter(w, r)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	hint, promptVersion, err := h.aiService.StreamCodeHint(r.Context(), h.aiCaller(w, r), request.Code, challenge, request.HintLevel, stream.token)
+	if err != nil {
+		stream.fail(err)
+		return
+	}
+	stream.done(struct {
+		Hint          string `json:"hint"`
+		HintLevel     int    `json:"hintLevel"`
+		Success       bool   `json:"success"`
+		PromptVersion string `json:"promptVersion,omitempty"`
+	}{
+		Hint:          hint,
+		HintLevel:     request.HintLevel,
+		Success:       true,
+		PromptVersion: promptVersion,
+	})
+}
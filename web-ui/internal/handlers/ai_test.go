@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-ui/internal/services"
+)
+
+const testSolution = `package main
+
+func Sum(a, b int) int {
+	return a + b
+}
+`
+
+// newAITestHandler serves the AI endpoints from a checkout with a single challenge, using
+// the mock provider. env is applied before the services read their configuration.
+func newAITestHandler(t *testing.T, env map[string]string) *APIHandler {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"challenge-1/README.md":                 "# Challenge 1: Sum\n\nAdd two numbers.\n",
+		"challenge-1/solution-template.go":      "package main\n\nfunc Sum(a, b int) int {\n\treturn 0\n}\n",
+		"challenge-1/solution-template_test.go": "package main\n\nimport \"testing\"\n\nfunc TestSum(t *testing.T) {\n\tif Sum(1, 2) != 3 {\n\t\tt.Fatal(\"wrong sum\")\n\t}\n}\n",
+		"packages/.keep":                        "",
+		"web-ui/.keep":                          "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The services read the checkout relative to web-ui, like the server does
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(root, "web-ui")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("AI_PROVIDER", "mock")
+	t.Setenv("AI_FALLBACK_PROVIDERS", "")
+	t.Setenv("AI_CACHE_TTL", "0")
+	t.Setenv("AI_PROMPTS_DIR", filepath.Join(root, "prompts"))
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+
+	challengeService := services.NewChallengeService()
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := challengeService.GetChallenge(1); !ok {
+		t.Fatal("challenge 1 was not loaded")
+	}
+	aiService := services.NewAIService(services.NewAIUsageService(), nil, nil)
+
+	return NewAPIHandler(Services{ChallengeService: challengeService, AIService: aiService})
+}
+
+// postJSON calls handler with body encoded as JSON
+func postJSON(t *testing.T, handler http.HandlerFunc, path string, body interface{}, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", path, strings.NewReader(string(data)))
+	r.Header.Set("Content-Type", "application/json")
+	r.RemoteAddr = "203.0.113.7:5000"
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// sseEvent is one server-sent event
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readEvents splits an event stream into its events
+func readEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		case line == "" && current.name != "":
+			events = append(events, current)
+			current = sseEvent{}
+		}
+	}
+	return events
+}
+
+// streamed returns the concatenated token text and the data of the final "done" event
+func streamed(t *testing.T, w *httptest.ResponseRecorder) (string, string) {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	var text strings.Builder
+	done := ""
+	for _, event := range readEvents(t, w.Body.String()) {
+		switch event.name {
+		case "token":
+			var token struct{ Text string }
+			if err := json.Unmarshal([]byte(event.data), &token); err != nil {
+				t.Fatalf("bad token event %q: %v", event.data, err)
+			}
+			text.WriteString(token.Text)
+		case "done":
+			done = event.data
+		case "error":
+			t.Fatalf("error event: %s", event.data)
+		}
+	}
+	if text.Len() == 0 {
+		t.Fatal("no token events")
+	}
+	if done == "" {
+		t.Fatal("no done event")
+	}
+	return text.String(), done
+}
+
+func TestAICodeReview(t *testing.T) {
+	h := newAITestHandler(t, nil)
+
+	w := postJSON(t, h.AICodeReview, "/api/ai/code-review", map[string]interface{}{"challengeId": 1, "code": testSolution})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var review services.AICodeReview
+	if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+		t.Fatal(err)
+	}
+	if review.OverallScore <= 0 || review.OverallScore > 100 {
+		t.Fatalf("overall_score = %v", review.OverallScore)
+	}
+	if review.InterviewerFeedback == "" || review.PromptVersion == "" {
+		t.Fatalf("incomplete review: %+v", review)
+	}
+}
+
+func TestAICodeHint(t *testing.T) {
+	h := newAITestHandler(t, nil)
+
+	w := postJSON(t, h.AICodeHint, "/api/ai/code-hint", map[string]interface{}{"challengeId": 1, "code": testSolution, "hintLevel": 9})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Hint      string `json:"hint"`
+		HintLevel int    `json:"hintLevel"`
+		Success   bool   `json:"success"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if !response.Success || response.Hint == "" {
+		t.Fatalf("response = %+v", response)
+	}
+	if response.HintLevel != 1 {
+		t.Fatalf("hintLevel = %d, want out-of-range levels reset to 1", response.HintLevel)
+	}
+}
+
+func TestAICodeHintReplay(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hint.txt"), []byte("Recorded hint: check the return value.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := newAITestHandler(t, map[string]string{"AI_MOCK_DIR": dir})
+
+	w := postJSON(t, h.AICodeHint, "/api/ai/code-hint", map[string]interface{}{"challengeId": 1, "code": testSolution, "hintLevel": 2})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Recorded hint: check the return value.") {
+		t.Fatalf("hint not replayed: %s", w.Body.String())
+	}
+}
+
+func TestAIInterviewerQuestions(t *testing.T) {
+	h := newAITestHandler(t, nil)
+
+	w := postJSON(t, h.AIInterviewerQuestions, "/api/ai/interviewer-questions", map[string]interface{}{"challengeId": "1", "code": testSolution})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Questions []string `json:"questions"`
+		Success   bool     `json:"success"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if !response.Success || len(response.Questions) == 0 {
+		t.Fatalf("response = %+v", response)
+	}
+}
+
+func TestAIUnknownChallenge(t *testing.T) {
+	h := newAITestHandler(t, nil)
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"review":           h.AICodeReview,
+		"hint":             h.AICodeHint,
+		"questions":        h.AIInterviewerQuestions,
+		"review stream":    h.AICodeReviewStream,
+		"hint stream":      h.AICodeHintStream,
+		"questions stream": h.AIInterviewerQuestionsStream,
+	} {
+		w := postJSON(t, handler, "/api/ai", map[string]interface{}{"challengeId": 99, "code": testSolution})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want %d", name, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestAIStreams(t *testing.T) {
+	h := newAITestHandler(t, nil)
+	body := map[string]interface{}{"challengeId": 1, "code": testSolution, "hintLevel": 1}
+
+	t.Run("review", func(t *testing.T) {
+		text, done := streamed(t, postJSON(t, h.AICodeReviewStream, "/api/ai/code-review/stream", body))
+		var raw, review map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			t.Fatalf("streamed tokens are not the model's JSON: %v", err)
+		}
+		if err := json.Unmarshal([]byte(done), &review); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := review["overall_score"]; !ok {
+			t.Fatalf("done event without a review: %s", done)
+		}
+	})
+
+	t.Run("hint", func(t *testing.T) {
+		text, done := streamed(t, postJSON(t, h.AICodeHintStream, "/api/ai/code-hint/stream", body))
+		var response struct {
+			Hint    string `json:"hint"`
+			Success bool   `json:"success"`
+		}
+		if err := json.Unmarshal([]byte(done), &response); err != nil {
+			t.Fatal(err)
+		}
+		if !response.Success || strings.TrimSpace(response.Hint) != strings.TrimSpace(text) {
+			t.Fatalf("hint %q does not match the streamed text %q", response.Hint, text)
+		}
+	})
+
+	t.Run("questions", func(t *testing.T) {
+		_, done := streamed(t, postJSON(t, h.AIInterviewerQuestionsStream, "/api/ai/interviewer-questions/stream", body))
+		var response struct {
+			Questions []string `json:"questions"`
+		}
+		if err := json.Unmarshal([]byte(done), &response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Questions) == 0 {
+			t.Fatalf("no questions: %s", done)
+		}
+	})
+}
+
+func TestAIQuotaExceeded(t *testing.T) {
+	h := newAITestHandler(t, map[string]string{"AI_USER_DAILY_REQUESTS": "1"})
+	body := map[string]interface{}{"challengeId": 1, "code": testSolution, "hintLevel": 1}
+
+	first := postJSON(t, h.AICodeHint, "/api/ai/code-hint", body)
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d, body %s", first.Code, first.Body.String())
+	}
+	session := first.Result().Cookies()
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"hint":             h.AICodeHint,
+		"review":           h.AICodeReview,
+		"questions":        h.AIInterviewerQuestions,
+		"hint stream":      h.AICodeHintStream,
+		"review stream":    h.AICodeReviewStream,
+		"questions stream": h.AIInterviewerQuestionsStream,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := postJSON(t, handler, "/api/ai", body, session...)
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusTooManyRequests, w.Body.String())
+			}
+			if w.Header().Get("Retry-After") == "" {
+				t.Fatal("no Retry-After header")
+			}
+			var response struct {
+				QuotaExceeded bool   `json:"quotaExceeded"`
+				Error         string `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if !response.QuotaExceeded || response.Error == "" {
+				t.Fatalf("response = %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAINetworkQuota(t *testing.T) {
+	h := newAITestHandler(t, map[string]string{"AI_USER_DAILY_REQUESTS": "0", "AI_NETWORK_DAILY_REQUESTS": "1"})
+	body := map[string]interface{}{"challengeId": 1, "code": testSolution, "hintLevel": 1}
+
+	if w := postJSON(t, h.AICodeHint, "/api/ai/code-hint", body); w.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	// Without its cookie the client gets a new session, but not a new allowance
+	w := postJSON(t, h.AICodeHint, "/api/ai/code-hint", body)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusTooManyRequests, w.Body.String())
+	}
+	var response struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Scope != "network" {
+		t.Fatalf("response = %s", w.Body.String())
+	}
+}
+
+func TestAIMissingKey(t *testing.T) {
+	h := newAITestHandler(t, map[string]string{
+		"AI_PROVIDER":     "claude",
+		"CLAUDE_API_KEY":  "",
+		"AI_API_KEY":      "",
+		"AI_HINT_API_KEY": "",
+	})
+
+	w := postJSON(t, h.AICodeHint, "/api/ai/code-hint", map[string]interface{}{"challengeId": 1, "code": testSolution})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "CLAUDE_API_KEY") || strings.Contains(w.Body.String(), "GEMINI_API_KEY") {
+		t.Fatalf("hint does not name the configured provider's key: %s", w.Body.String())
+	}
+}
+
+// brokenWriter accepts the response headers but fails every write, like a connection
+// the client has dropped
+type brokenWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (brokenWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestAIStreamsLogLostResults(t *testing.T) {
+	h := newAITestHandler(t, nil)
+	body := `{"challengeId": 1, "code": "package main", "hintLevel": 1}`
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"/api/ai/code-hint/stream":             h.AICodeHintStream,
+		"/api/ai/code-review/stream":           h.AICodeReviewStream,
+		"/api/ai/interviewer-questions/stream": h.AIInterviewerQuestionsStream,
+	} {
+		t.Run(strings.TrimPrefix(name, "/api/ai/")+" cancelled", func(t *testing.T) {
+			logs.Reset()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			r := httptest.NewRequest("POST", name, strings.NewReader(body)).WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if strings.Contains(w.Body.String(), "event: done") {
+				t.Fatalf("sent a result to a cancelled request: %s", w.Body.String())
+			}
+			if !strings.Contains(logs.String(), name) {
+				t.Fatalf("nothing logged: %q", logs.String())
+			}
+		})
+
+		t.Run(strings.TrimPrefix(name, "/api/ai/")+" write error", func(t *testing.T) {
+			logs.Reset()
+			r := httptest.NewRequest("POST", name, strings.NewReader(body))
+			handler(brokenWriter{httptest.NewRecorder()}, r)
+			if !strings.Contains(logs.String(), "connection reset by peer") {
+				t.Fatalf("write error not logged: %q", logs.String())
+			}
+		})
+	}
+}
@@ -2,13 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
-	"path/filepath"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -69,6 +67,21 @@ func (h *APIHandler) LoadSponsors() map[string]bool {
 	return sponsors
 }
 
+// invalidateSponsorCache forces the next LoadSponsors call to re-scrape GitHub
+func invalidateSponsorCache() {
+	sponsorCache.mutex.Lock()
+	sponsorCache.sponsors = make(map[string]bool)
+	sponsorCache.lastUpdated = time.Time{} // Reset to zero time to force refresh
+	sponsorCache.mutex.Unlock()
+}
+
+// sponsorCacheInfo reports how many sponsors are cached and when they were fetched
+func sponsorCacheInfo() (int, time.Time) {
+	sponsorCache.mutex.RLock()
+	defer sponsorCache.mutex.RUnlock()
+	return len(sponsorCache.sponsors), sponsorCache.lastUpdated
+}
+
 // scrapeSponsorsFromGitHub scrapes the public GitHub sponsors page
 func (h *APIHandler) scrapeSponsorsFromGitHub() map[string]bool {
 	sponsorMap := make(map[string]bool)
@@ -146,54 +159,118 @@ func (h *APIHandler) scrapeSponsorsFromGitHub() map[string]bool {
 
 // APIHandler handles all API endpoints
 type APIHandler struct {
-	challengeService  *services.ChallengeService
-	scoreboardService *services.ScoreboardService
-	userService       *services.UserService
-	executionService  *services.ExecutionService
-	packageService    *services.PackageService
-	aiService         *services.AIService
-	submissions       []models.Submission
+	challengeService   *services.ChallengeService
+	scoreboardService  *services.ScoreboardService
+	userService        *services.UserService
+	executionService   *services.ExecutionService
+	packageService     *services.PackageService
+	aiService          *services.AIService
+	workspaceService   *services.WorkspaceService
+	leaderboardService *services.LeaderboardService
+	profileService     *services.ProfileService
+	teamService        *services.TeamService
+	cohortService      *services.CohortService
+	interviewService   *services.InterviewService
+	authoringService   *services.AuthoringService
+	searchService      *services.SearchService
+	trackService       *services.TrackService
+	referenceService   *services.ReferenceService
+	submissions        []models.Submission
+}
+
+// Services are the services the handlers are built from. A handler only uses some of
+// them; the others may be left nil, as in tests.
+type Services struct {
+	ChallengeService   *services.ChallengeService
+	ScoreboardService  *services.ScoreboardService
+	UserService        *services.UserService
+	ExecutionService   *services.ExecutionService
+	PackageService     *services.PackageService
+	AIService          *services.AIService
+	WorkspaceService   *services.WorkspaceService
+	LeaderboardService *services.LeaderboardService
+	ProfileService     *services.ProfileService
+	TeamService        *services.TeamService
+	CohortService      *services.CohortService
+	InterviewService   *services.InterviewService
+	AuthoringService   *services.AuthoringService
+	SearchService      *services.SearchService
+	TrackService       *services.TrackService
+	ReferenceService   *services.ReferenceService
 }
 
 // NewAPIHandler creates a new API handler
-func NewAPIHandler(
-	challengeService *services.ChallengeService,
-	scoreboardService *services.ScoreboardService,
-	userService *services.UserService,
-	executionService *services.ExecutionService,
-	packageService *services.PackageService,
-	aiService *services.AIService,
-) *APIHandler {
+func NewAPIHandler(deps Services) *APIHandler {
 	return &APIHandler{
-		challengeService:  challengeService,
-		scoreboardService: scoreboardService,
-		userService:       userService,
-		executionService:  executionService,
-		packageService:    packageService,
-		aiService:         aiService,
-		submissions:       make([]models.Submission, 0),
+		challengeService:   deps.ChallengeService,
+		scoreboardService:  deps.ScoreboardService,
+		userService:        deps.UserService,
+		executionService:   deps.ExecutionService,
+		packageService:     deps.PackageService,
+		aiService:          deps.AIService,
+		workspaceService:   deps.WorkspaceService,
+		leaderboardService: deps.LeaderboardService,
+		profileService:     deps.ProfileService,
+		teamService:        deps.TeamService,
+		cohortService:      deps.CohortService,
+		interviewService:   deps.InterviewService,
+		authoringService:   deps.AuthoringService,
+		searchService:      deps.SearchService,
+		trackService:       deps.TrackService,
+		referenceService:   deps.ReferenceService,
+		submissions:        make([]models.Submission, 0),
 	}
 }
 
-// GetAllChallenges returns all challenges
+// GetAllChallenges returns all challenges, or those matching the difficulty and tag filters
 func (h *APIHandler) GetAllChallenges(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	challenges := h.challengeService.GetChallenges()
-
-	// Convert map to slice for JSON response
-	var challengeList []*models.Challenge
-	for _, challenge := range challenges {
-		challengeList = append(challengeList, challenge)
+	// Optionally narrowed down with ?difficulty= and ?tag=
+	challengeList := h.challengeService.FilterChallenges(services.ParseChallengeFilter(r.URL.Query()))
+	if challengeList == nil {
+		challengeList = []*models.Challenge{}
+	}
+	language := contentLanguage(r, h.challengeService, h.packageService)
+	for i, challenge := range challengeList {
+		challengeList[i] = services.LocalizeChallenge(challenge, language)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(challengeList)
 }
 
+// Search runs a full-text query over challenges, packages and learning materials:
+// /api/search?q=context+cancellation, optionally filtered by kind, difficulty and tag
+func (h *APIHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	text := strings.TrimSpace(query.Get("q"))
+	if text == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	response := h.searchService.Search(services.SearchQuery{
+		Text:       text,
+		Kind:       query.Get("kind"),
+		Difficulty: query.Get("difficulty"),
+		Tag:        query.Get("tag"),
+		Limit:      limit,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // GetChallengeByID returns a specific challenge by ID
 func (h *APIHandler) GetChallengeByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -214,6 +291,7 @@ func (h *APIHandler) GetChallengeByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
+	challenge = services.LocalizeChallenge(challenge, contentLanguage(r, h.challengeService, h.packageService))
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(challenge)
@@ -255,6 +333,7 @@ func (h *APIHandler) createSubmission(w http.ResponseWriter, r *http.Request) {
 	submission.Passed = result.Passed
 	submission.TestOutput = result.Output
 	submission.ExecutionMs = result.ExecutionMs
+	submission.SuiteVersion = challenge.SuiteVersion
 
 	// Store submission
 	h.submissions = append(h.submissions, submission)
@@ -262,6 +341,7 @@ func (h *APIHandler) createSubmission(w http.ResponseWriter, r *http.Request) {
 	// Add to scoreboard if passed
 	if submission.Passed {
 		h.scoreboardService.AddSubmission(submission)
+		h.referenceService.RecordPass(ensureSession(w, r), challenge)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -347,9 +427,6 @@ func (h *APIHandler) SaveSubmissionToFilesystem(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Set username cookie
-	h.setUsernameCookie(w, request.Username)
-
 	// Validate challenge exists
 	_, exists := h.challengeService.GetChallenge(request.ChallengeID)
 	if !exists {
@@ -357,7 +434,14 @@ func (h *APIHandler) SaveSubmissionToFilesystem(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	response := h.executionService.SaveSubmissionToFilesystem(request)
+	response, err := h.workspaceService.SaveClassicSolution(request.Username, request.ChallengeID, request.Code)
+	if err != nil {
+		h.writeWorkspaceError(w, err)
+		return
+	}
+
+	// Set username cookie
+	h.setUsernameCookie(w, request.Username)
 
 	// Clear user attempts cache
 	h.userService.RefreshUserAttempts(request.Username, h.challengeService.GetChallenges())
@@ -366,6 +450,38 @@ func (h *APIHandler) SaveSubmissionToFilesystem(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(response)
 }
 
+// writeWorkspaceError maps workspace errors to a JSON failure response
+func (h *APIHandler) writeWorkspaceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "Failed to save solution: " + err.Error()
+
+	switch {
+	case errors.Is(err, services.ErrNotWorkspaceOwner):
+		status = http.StatusForbidden
+		if owner := h.workspaceService.Owner(); owner != "" {
+			message = fmt.Sprintf("This workspace belongs to %s; you can only save solutions into your own submissions folder", owner)
+		} else {
+			message = "No workspace owner detected. Set WORKSPACE_USER or configure a GitHub remote to save solutions"
+		}
+	case errors.Is(err, services.ErrInvalidPathComponent):
+		status = http.StatusBadRequest
+		message = "Invalid submission path: " + err.Error()
+	case errors.Is(err, services.ErrUnknownChallenge):
+		status = http.StatusNotFound
+		message = "Challenge not found"
+	case errors.Is(err, services.ErrOutsideWorkspace):
+		status = http.StatusBadRequest
+		message = "Refusing to write outside the repository"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(services.SaveSubmissionResponse{
+		Success: false,
+		Message: message,
+	})
+}
+
 // RefreshUserAttempts refreshes user's attempt cache
 func (h *APIHandler) RefreshUserAttempts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -459,7 +575,7 @@ func (h *APIHandler) GetMainScoreboardRank(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Calculate user's rank in main scoreboard
-	rank := h.calculateMainScoreboardRank(username)
+	rank := h.leaderboardService.MainRank(username)
 
 	response := struct {
 		Username string `json:"username"`
@@ -475,76 +591,6 @@ func (h *APIHandler) GetMainScoreboardRank(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
-// calculateMainScoreboardRank calculates the user's rank based on completed challenges
-func (h *APIHandler) calculateMainScoreboardRank(username string) int {
-	// Get all users and their completion counts (only count if ALL tests passed)
-	challenges := h.challengeService.GetChallenges()
-	userCompletions := make(map[string]int)
-
-	// Process all challenge scoreboards to count actual completions
-	for challengeID := range challenges {
-		// Read scoreboard file directly to check test results
-		scoreboardPath := fmt.Sprintf("../challenge-%d/SCOREBOARD.md", challengeID)
-		content, err := ioutil.ReadFile(scoreboardPath)
-		if err != nil {
-			// Try alternative path
-			scoreboardPath = fmt.Sprintf("challenge-%d/SCOREBOARD.md", challengeID)
-			content, err = ioutil.ReadFile(scoreboardPath)
-			if err != nil {
-				continue
-			}
-		}
-
-		// Parse scoreboard to find users who passed ALL tests
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			// Skip header and separator lines
-			if !strings.Contains(line, "|") || strings.Contains(line, "Username") || strings.Contains(line, "---") {
-				continue
-			}
-
-			parts := strings.Split(line, "|")
-			if len(parts) < 4 {
-				continue
-			}
-
-			username := strings.TrimSpace(parts[1])
-			passedTestsStr := strings.TrimSpace(parts[2])
-			totalTestsStr := strings.TrimSpace(parts[3])
-
-			// Skip empty usernames or placeholders
-			if username == "" || username == "------" {
-				continue
-			}
-
-			// Parse test counts
-			passedTests, err1 := strconv.Atoi(passedTestsStr)
-			totalTests, err2 := strconv.Atoi(totalTestsStr)
-
-			// Only count as completed if ALL tests passed
-			if err1 == nil && err2 == nil && passedTests > 0 && passedTests == totalTests {
-				userCompletions[username]++
-			}
-		}
-	}
-
-	// Get the target user's completion count
-	targetCompletions := userCompletions[username]
-	if targetCompletions == 0 {
-		return 0 // User is unranked
-	}
-
-	// Count how many users have more completions (following Python script logic)
-	rank := 1
-	for user, completions := range userCompletions {
-		if user != username && completions > targetCompletions {
-			rank++
-		}
-	}
-
-	return rank
-}
-
 // GetMainLeaderboard returns the main leaderboard data
 func (h *APIHandler) GetMainLeaderboard(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -553,15 +599,15 @@ func (h *APIHandler) GetMainLeaderboard(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Calculate leaderboard data
-	leaderboard := h.calculateMainLeaderboard()
+	leaderboard := h.leaderboardService.MainLeaderboard(h.LoadSponsors())
 
 	// Include total number of classic challenges for dynamic UI rendering
 	totalChallenges := len(h.challengeService.GetChallenges())
 
 	response := struct {
-		Leaderboard     []LeaderboardUser `json:"leaderboard"`
-		Success         bool              `json:"success"`
-		TotalChallenges int               `json:"totalChallenges"`
+		Leaderboard     []models.LeaderboardUser `json:"leaderboard"`
+		Success         bool                     `json:"success"`
+		TotalChallenges int                      `json:"totalChallenges"`
 	}{
 		Leaderboard:     leaderboard,
 		Success:         true,
@@ -572,6 +618,30 @@ func (h *APIHandler) GetMainLeaderboard(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetUserProfile returns a user's classic and package progress
+func (h *APIHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract username from URL: /api/users/{username}
+	username := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users/"), "/")
+	if username == "" || strings.Contains(username, "/") {
+		http.Error(w, "Username is required", http.StatusBadRequest)
+		return
+	}
+
+	profile, found := h.profileService.BuildProfile(username, h.LoadSponsors())
+	if !found {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
 // GetPackageLeaderboard returns leaderboard data for a package learning path
 func (h *APIHandler) GetPackageLeaderboard(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -585,27 +655,14 @@ func (h *APIHandler) GetPackageLeaderboard(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Load package and its challenges
-	pkg, err := h.packageService.GetPackage(packageName)
+	// Load package and its challenges in learning path order
+	pkg, challenges, err := h.leaderboardService.PackageChallenges(packageName)
 	if err != nil {
 		http.Error(w, "Package not found", http.StatusNotFound)
 		return
 	}
 
-	// Build package challenge list in learning path order
-	challengesMap, err := h.packageService.GetPackageChallenges(packageName)
-	if err != nil {
-		challengesMap = make(map[string]*models.PackageChallenge)
-	}
-	var challenges []*models.PackageChallenge
-	for _, id := range pkg.LearningPath {
-		if ch, ok := challengesMap[id]; ok {
-			challenges = append(challenges, ch)
-		}
-	}
-
-	// Reuse existing creator to gather leaderboard
-	leaderboard := h.createPackageLeaderboard(packageName, challenges)
+	leaderboard := h.leaderboardService.PackageLeaderboard(packageName, challenges, h.LoadSponsors())
 
 	response := map[string]interface{}{
 		"success":         true,
@@ -619,204 +676,6 @@ func (h *APIHandler) GetPackageLeaderboard(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
-// createPackageLeaderboard builds the package leaderboard based on filesystem submissions
-func (h *APIHandler) createPackageLeaderboard(packageName string, challenges []*models.PackageChallenge) []models.PackageScoreboardEntry {
-	var leaderboard []models.PackageScoreboardEntry
-	type userPackageStats struct {
-		username            string
-		completedCount      int
-		lastSubmission      time.Time
-		challengesCompleted map[string]bool
-	}
-	userStats := make(map[string]*userPackageStats)
-
-	// Load sponsors for package leaderboard
-	sponsors := h.LoadSponsors()
-
-	for _, challenge := range challenges {
-		submissionsDir := filepath.Join("..", "packages", packageName, challenge.ID, "submissions")
-		if _, err := os.Stat(submissionsDir); os.IsNotExist(err) {
-			continue
-		}
-		entries, err := ioutil.ReadDir(submissionsDir)
-		if err != nil {
-			continue
-		}
-		for _, entry := range entries {
-			if entry.IsDir() {
-				username := entry.Name()
-				userDir := filepath.Join(submissionsDir, username)
-				solutionPath := filepath.Join(userDir, "solution.go")
-				altSolutionPath := filepath.Join(userDir, "solution-template.go")
-
-				var modTime time.Time
-				if stat, err := os.Stat(solutionPath); err == nil {
-					modTime = stat.ModTime()
-				} else if stat, err := os.Stat(altSolutionPath); err == nil {
-					modTime = stat.ModTime()
-				} else {
-					continue
-				}
-
-				if userStats[username] == nil {
-					userStats[username] = &userPackageStats{
-						username:            username,
-						completedCount:      0,
-						lastSubmission:      modTime,
-						challengesCompleted: make(map[string]bool),
-					}
-				}
-				if !userStats[username].challengesCompleted[challenge.ID] {
-					userStats[username].completedCount++
-					userStats[username].challengesCompleted[challenge.ID] = true
-					if modTime.After(userStats[username].lastSubmission) {
-						userStats[username].lastSubmission = modTime
-					}
-				}
-			}
-		}
-	}
-
-	for username, stats := range userStats {
-		if stats.completedCount > 0 {
-			leaderboard = append(leaderboard, models.PackageScoreboardEntry{
-				Username:    username,
-				PackageName: packageName,
-				ChallengeID: "",
-				SubmittedAt: stats.lastSubmission,
-				TestsPassed: stats.completedCount,
-				TestsTotal:  len(challenges),
-				IsSponsor:   sponsors[username],
-			})
-		}
-	}
-
-	sort.Slice(leaderboard, func(i, j int) bool {
-		if leaderboard[i].TestsPassed != leaderboard[j].TestsPassed {
-			return leaderboard[i].TestsPassed > leaderboard[j].TestsPassed
-		}
-		return leaderboard[i].SubmittedAt.Before(leaderboard[j].SubmittedAt)
-	})
-
-	return leaderboard
-}
-
-// LeaderboardUser represents a user in the leaderboard
-type LeaderboardUser struct {
-	Username            string       `json:"username"`
-	CompletedCount      int          `json:"completedCount"`
-	CompletionRate      float64      `json:"completionRate"`
-	CompletedChallenges map[int]bool `json:"completedChallenges"`
-	Achievement         string       `json:"achievement"`
-	Rank                int          `json:"rank"`
-	IsSponsor           bool         `json:"isSponsor"`
-}
-
-// calculateMainLeaderboard calculates the main leaderboard data
-func (h *APIHandler) calculateMainLeaderboard() []LeaderboardUser {
-	challenges := h.challengeService.GetChallenges()
-	totalChallenges := len(challenges)
-	userCompletions := make(map[string]map[int]bool)
-
-	// Load sponsor information
-	sponsors := h.LoadSponsors()
-
-	// Process all challenge scoreboards to find completions
-	for challengeID := range challenges {
-		// Read scoreboard file directly to check test results
-		scoreboardPath := fmt.Sprintf("../challenge-%d/SCOREBOARD.md", challengeID)
-		content, err := ioutil.ReadFile(scoreboardPath)
-		if err != nil {
-			// Try alternative path
-			scoreboardPath = fmt.Sprintf("challenge-%d/SCOREBOARD.md", challengeID)
-			content, err = ioutil.ReadFile(scoreboardPath)
-			if err != nil {
-				continue
-			}
-		}
-
-		// Parse scoreboard to find users who passed ALL tests
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			// Skip header and separator lines
-			if !strings.Contains(line, "|") || strings.Contains(line, "Username") || strings.Contains(line, "---") {
-				continue
-			}
-
-			parts := strings.Split(line, "|")
-			if len(parts) < 4 {
-				continue
-			}
-
-			username := strings.TrimSpace(parts[1])
-			passedTestsStr := strings.TrimSpace(parts[2])
-			totalTestsStr := strings.TrimSpace(parts[3])
-
-			// Skip empty usernames or placeholders
-			if username == "" || username == "------" {
-				continue
-			}
-
-			// Parse test counts
-			passedTests, err1 := strconv.Atoi(passedTestsStr)
-			totalTests, err2 := strconv.Atoi(totalTestsStr)
-
-			// Only count as completed if ALL tests passed
-			if err1 == nil && err2 == nil && passedTests > 0 && passedTests == totalTests {
-				if userCompletions[username] == nil {
-					userCompletions[username] = make(map[int]bool)
-				}
-				userCompletions[username][challengeID] = true
-			}
-		}
-	}
-
-	// Convert to leaderboard format
-	var leaderboard []LeaderboardUser
-	for username, completions := range userCompletions {
-		completedCount := len(completions)
-		completionRate := float64(completedCount) / float64(totalChallenges) * 100
-
-		// Determine achievement
-		achievement := "🌱 Beginner"
-		if completedCount >= 20 {
-			achievement = "🔥 Master"
-		} else if completedCount >= 15 {
-			achievement = "⭐ Expert"
-		} else if completedCount >= 10 {
-			achievement = "💪 Advanced"
-		} else if completedCount >= 5 {
-			achievement = "🚀 Intermediate"
-		}
-
-		leaderboard = append(leaderboard, LeaderboardUser{
-			Username:            username,
-			CompletedCount:      completedCount,
-			CompletionRate:      completionRate,
-			CompletedChallenges: completions,
-			Achievement:         achievement,
-			IsSponsor:           sponsors[username],
-		})
-	}
-
-	// Sort by completion count (descending), then by username
-	for i := 0; i < len(leaderboard); i++ {
-		for j := i + 1; j < len(leaderboard); j++ {
-			if leaderboard[j].CompletedCount > leaderboard[i].CompletedCount ||
-				(leaderboard[j].CompletedCount == leaderboard[i].CompletedCount && leaderboard[j].Username < leaderboard[i].Username) {
-				leaderboard[i], leaderboard[j] = leaderboard[j], leaderboard[i]
-			}
-		}
-	}
-
-	// Assign ranks
-	for i := range leaderboard {
-		leaderboard[i].Rank = i + 1
-	}
-
-	return leaderboard
-}
-
 // HandlePackageChallenge handles package challenge test and submit requests
 func (h *APIHandler) HandlePackageChallenge(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -866,22 +725,13 @@ func (h *APIHandler) HandlePackageChallenge(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Use the existing package service
-	packageService := h.packageService
-
-	challenge, err := packageService.GetPackageChallenge(packageName, challengeId)
+	// Convert the package challenge to Challenge format for ExecutionService
+	challengeForExecution, err := h.packageService.AsChallenge(packageName, challengeId)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Challenge not found: %v", err), http.StatusNotFound)
 		return
 	}
 
-	// Convert PackageChallenge to Challenge format for ExecutionService
-	challengeForExecution := &models.Challenge{
-		ID:       0, // Package challenges don't use numeric IDs
-		Title:    challenge.Title,
-		TestFile: challenge.TestFile,
-	}
-
 	// Run the actual tests using ExecutionService
 	result := h.executionService.RunCode(request.Code, challengeForExecution)
 
@@ -905,6 +755,7 @@ func (h *APIHandler) HandlePackageChallenge(w http.ResponseWriter, r *http.Reque
 		if request.Username != "" {
 			h.setUsernameCookie(w, request.Username)
 		}
+		h.referenceService.RecordPass(ensureSession(w, r), challengeForExecution)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -966,9 +817,6 @@ func (h *APIHandler) SavePackageChallengeToFilesystem(w http.ResponseWriter, r *
 		return
 	}
 
-	// Set username cookie
-	h.setUsernameCookie(w, request.Username)
-
 	// Validate challenge exists
 	_, err = h.packageService.GetPackageChallenge(request.PackageName, request.ChallengeID)
 	if err != nil {
@@ -977,73 +825,50 @@ func (h *APIHandler) SavePackageChallengeToFilesystem(w http.ResponseWriter, r *
 	}
 
 	// Save to filesystem
-	response := h.savePackageChallengeToFilesystem(request)
+	response, err := h.workspaceService.SavePackageSolution(request.Username, request.PackageName, request.ChallengeID, request.Code)
+	if err != nil {
+		h.writeWorkspaceError(w, err)
+		return
+	}
+
+	// Set username cookie
+	h.setUsernameCookie(w, request.Username)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// savePackageChallengeToFilesystem handles the actual file saving for package challenges
-func (h *APIHandler) savePackageChallengeToFilesystem(request struct {
-	Username    string `json:"username"`
-	PackageName string `json:"packageName"`
-	ChallengeID string `json:"challengeId"`
-	Code        string `json:"code"`
-}) services.SaveSubmissionResponse {
-	// Get working directory for correct relative paths
-	workDir, _ := os.Getwd()
-
-	// Try different path approaches to handle potential path issues
-	var submissionDir string
-	var fileSaved bool
-
-	// Try multiple path options for package challenges
-	pathOptions := []string{
-		// Option 1: From web-ui directory (standard case)
-		filepath.Join("..", "packages", request.PackageName, request.ChallengeID, "submissions", request.Username),
-		// Option 2: From root workspace
-		filepath.Join("packages", request.PackageName, request.ChallengeID, "submissions", request.Username),
-		// Option 3: Absolute path from detected workspace root
-		filepath.Join(workDir, "..", "packages", request.PackageName, request.ChallengeID, "submissions", request.Username),
-	}
-
-	for _, dirPath := range pathOptions {
-		err := os.MkdirAll(dirPath, 0755)
-		if err != nil {
-			continue
-		}
-
-		solutionFile := filepath.Join(dirPath, "solution.go")
-		err = ioutil.WriteFile(solutionFile, []byte(request.Code), 0644)
-		if err != nil {
-			continue
-		}
+// challengeRef is the "challengeId" of an AI request: a number for classic challenges, or
+// a directory name such as "challenge-1-basic-routing" together with "package"
+type challengeRef string
 
-		submissionDir = dirPath
-		fileSaved = true
-		break
+// UnmarshalJSON accepts both JSON numbers and strings
+func (c *challengeRef) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		*c = challengeRef(id)
+		return nil
 	}
-
-	if !fileSaved {
-		return services.SaveSubmissionResponse{
-			Success: false,
-			Message: "Failed to save solution to any available path",
-		}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
 	}
+	*c = challengeRef(number.String())
+	return nil
+}
 
-	// Return success response with git commands
-	relativePath := filepath.Join("packages", request.PackageName, request.ChallengeID, "submissions", request.Username, "solution.go")
-	return services.SaveSubmissionResponse{
-		Success:  true,
-		Message:  "Solution saved to filesystem",
-		FilePath: filepath.Join(submissionDir, "solution.go"),
-		GitCommands: []string{
-			"cd " + filepath.Join(workDir, ".."),
-			fmt.Sprintf("git add %s", relativePath),
-			fmt.Sprintf("git commit -m \"Add solution for %s %s by %s\"", request.PackageName, request.ChallengeID, request.Username),
-			"git push origin main",
-		},
+// aiChallenge finds the challenge an AI request is about. Package challenges are
+// converted so the AI service sees their package name, version and objectives.
+func (h *APIHandler) aiChallenge(packageName string, id challengeRef) (*models.Challenge, bool) {
+	if packageName != "" {
+		challenge, err := h.packageService.AsChallenge(packageName, string(id))
+		return challenge, err == nil
 	}
+	number, err := strconv.Atoi(string(id))
+	if err != nil {
+		return nil, false
+	}
+	return h.challengeService.GetChallenge(number)
 }
 
 // AICodeReview performs AI-powered code review
@@ -1054,9 +879,10 @@ func (h *APIHandler) AICodeReview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var request struct {
-		ChallengeID int    `json:"challengeId"`
-		Code        string `json:"code"`
-		Context     string `json:"context"`
+		Package     string       `json:"package"`
+		ChallengeID challengeRef `json:"challengeId"`
+		Code        string       `json:"code"`
+		Context     string       `json:"context"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -1065,15 +891,15 @@ func (h *APIHandler) AICodeReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	challenge, exists := h.challengeService.GetChallenge(request.ChallengeID)
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
 	if !exists {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
 
-	review, err := h.aiService.ReviewCode(request.Code, challenge, request.Context)
+	review, err := h.aiService.ReviewCode(h.aiCaller(w, r), request.Code, challenge, request.Context)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("AI review failed: %v", err), http.StatusInternalServerError)
+		writeAIError(w, err, "AI review failed")
 		return
 	}
 
@@ -1089,9 +915,10 @@ func (h *APIHandler) AIInterviewerQuestions(w http.ResponseWriter, r *http.Reque
 	}
 
 	var request struct {
-		ChallengeID  int    `json:"challengeId"`
-		Code         string `json:"code"`
-		UserProgress string `json:"userProgress"`
+		Package      string       `json:"package"`
+		ChallengeID  challengeRef `json:"challengeId"`
+		Code         string       `json:"code"`
+		UserProgress string       `json:"userProgress"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -1100,24 +927,26 @@ func (h *APIHandler) AIInterviewerQuestions(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	challenge, exists := h.challengeService.GetChallenge(request.ChallengeID)
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
 	if !exists {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
 
-	questions, err := h.aiService.GetInterviewerQuestions(request.Code, challenge, request.UserProgress)
+	questions, promptVersion, err := h.aiService.GetInterviewerQuestions(h.aiCaller(w, r), request.Code, challenge, request.UserProgress)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("AI questions failed: %v", err), http.StatusInternalServerError)
+		writeAIError(w, err, "AI questions failed")
 		return
 	}
 
 	response := struct {
-		Questions []string `json:"questions"`
-		Success   bool     `json:"success"`
+		Questions     []string `json:"questions"`
+		Success       bool     `json:"success"`
+		PromptVersion string   `json:"promptVersion,omitempty"`
 	}{
-		Questions: questions,
-		Success:   true,
+		Questions:     questions,
+		Success:       true,
+		PromptVersion: promptVersion,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1132,9 +961,10 @@ func (h *APIHandler) AICodeHint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var request struct {
-		ChallengeID int    `json:"challengeId"`
-		Code        string `json:"code"`
-		HintLevel   int    `json:"hintLevel"`
+		Package     string       `json:"package"`
+		ChallengeID challengeRef `json:"challengeId"`
+		Code        string       `json:"code"`
+		HintLevel   int          `json:"hintLevel"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -1143,7 +973,7 @@ func (h *APIHandler) AICodeHint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	challenge, exists := h.challengeService.GetChallenge(request.ChallengeID)
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
 	if !exists {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
@@ -1154,26 +984,64 @@ func (h *APIHandler) AICodeHint(w http.ResponseWriter, r *http.Request) {
 		request.HintLevel = 1
 	}
 
-	hint, err := h.aiService.GetCodeHint(request.Code, challenge, request.HintLevel)
+	hint, promptVersion, err := h.aiService.GetCodeHint(h.aiCaller(w, r), request.Code, challenge, request.HintLevel)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("AI hint failed: %v", err), http.StatusInternalServerError)
+		writeAIError(w, err, "AI hint failed")
 		return
 	}
 
 	response := struct {
-		Hint      string `json:"hint"`
-		HintLevel int    `json:"hintLevel"`
-		Success   bool   `json:"success"`
+		Hint          string `json:"hint"`
+		HintLevel     int    `json:"hintLevel"`
+		Success       bool   `json:"success"`
+		PromptVersion string `json:"promptVersion,omitempty"`
 	}{
-		Hint:      hint,
-		HintLevel: request.HintLevel,
-		Success:   true,
+		Hint:          hint,
+		HintLevel:     request.HintLevel,
+		Success:       true,
+		PromptVersion: promptVersion,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// AIEdgeCases challenges a solution with AI-proposed edge-case tests that pass against
+// the reference solution, and reports the cases the solution fails
+func (h *APIHandler) AIEdgeCases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Package     string       `json:"package"`
+		ChallengeID challengeRef `json:"challengeId"`
+		Code        string       `json:"code"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	report, err := h.aiService.ChallengeSolution(h.aiCaller(w, r), request.Code, challenge)
+	if err != nil {
+		writeAIError(w, err, "Edge-case generation failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
 // AIDebugResponse provides raw AI response for debugging
 func (h *APIHandler) AIDebugResponse(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -1182,9 +1050,10 @@ func (h *APIHandler) AIDebugResponse(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var request struct {
-		ChallengeID int    `json:"challengeId"`
-		Code        string `json:"code"`
-		Context     string `json:"context"`
+		Package     string       `json:"package"`
+		ChallengeID challengeRef `json:"challengeId"`
+		Code        string       `json:"code"`
+		Context     string       `json:"context"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -1193,29 +1062,37 @@ func (h *APIHandler) AIDebugResponse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	challenge, exists := h.challengeService.GetChallenge(request.ChallengeID)
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
 	if !exists {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
 
 	// Get raw AI response for debugging
-	prompt := h.aiService.BuildCodeReviewPrompt(request.Code, challenge, request.Context)
-	rawResponse, err := h.aiService.CallLLMRaw(prompt)
+	llmRequest, promptVersion, err := h.aiService.BuildCodeReviewPrompt(request.Code, challenge, request.Context)
+	var rawResponse string
+	if err == nil {
+		rawResponse, err = h.aiService.CallLLMRaw("admin", llmRequest)
+	}
 
 	response := struct {
-		RawResponse string `json:"raw_response"`
-		Prompt      string `json:"prompt"`
-		Success     bool   `json:"success"`
-		Error       string `json:"error,omitempty"`
+		RawResponse      string   `json:"raw_response"`
+		Prompt           string   `json:"prompt"`
+		PromptVersion    string   `json:"prompt_version"`
+		ValidationErrors []string `json:"validation_errors,omitempty"`
+		Success          bool     `json:"success"`
+		Error            string   `json:"error,omitempty"`
 	}{
-		RawResponse: rawResponse,
-		Prompt:      prompt,
-		Success:     err == nil,
+		RawResponse:   rawResponse,
+		Prompt:        llmRequest.Prompt,
+		PromptVersion: promptVersion,
+		Success:       err == nil,
 	}
 
 	if err != nil {
 		response.Error = err.Error()
+	} else {
+		response.ValidationErrors = services.ValidateReviewResponse(rawResponse)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1246,10 +1123,7 @@ func (h *APIHandler) GitHubWebhookHandler(w http.ResponseWriter, r *http.Request
 	eventType := r.Header.Get("X-GitHub-Event")
 	if eventType == "sponsorship" {
 		// Clear the sponsor cache to force a refresh on next request
-		sponsorCache.mutex.Lock()
-		sponsorCache.sponsors = make(map[string]bool)
-		sponsorCache.lastUpdated = time.Time{} // Reset to zero time to force refresh
-		sponsorCache.mutex.Unlock()
+		invalidateSponsorCache()
 
 		fmt.Printf("Sponsor cache cleared due to webhook event: %s\n", eventType)
 	}
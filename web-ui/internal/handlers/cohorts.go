@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+	"web-ui/internal/utils"
+)
+
+// GetCohorts returns all cohort definitions
+func (h *APIHandler) GetCohorts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"cohorts": h.cohortService.GetCohorts(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleCohort serves /api/cohorts/{id} (progress matrix) and /api/cohorts/{id}/export?format=csv|json
+func (h *APIHandler) HandleCohort(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract cohort ID from URL: /api/cohorts/{id}[/export]
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/cohorts/"), "/"), "/")
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "export") {
+		http.NotFound(w, r)
+		return
+	}
+
+	progress, err := h.cohortService.Progress(parts[0])
+	if err != nil {
+		http.Error(w, "Cohort not found", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(progress)
+		return
+	}
+
+	filename := fmt.Sprintf("cohort-%s-progress-%s", progress.Cohort.ID, progress.GeneratedAt.Format("2006-01-02"))
+	switch r.URL.Query().Get("format") {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		if err := services.WriteProgressCSV(w, progress); err != nil {
+			log.Printf("Error writing cohort CSV: %v", err)
+		}
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(progress)
+	default:
+		http.Error(w, "Unsupported format. Use csv or json", http.StatusBadRequest)
+	}
+}
+
+// CohortsPage lists all cohorts
+func (h *WebHandler) CohortsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/cohorts.html")
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Cohorts []*models.Cohort
+	}{
+		Cohorts: h.cohortService.GetCohorts(),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		log.Printf("Template execution error: %v", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// CohortProgressPage renders a cohort's progress matrix with late and missing markers
+func (h *WebHandler) CohortProgressPage(w http.ResponseWriter, r *http.Request) {
+	// Extract cohort ID from URL: /cohorts/{id}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/cohorts/"), "/")
+	if id == "" {
+		h.CohortsPage(w, r)
+		return
+	}
+
+	progress, err := h.cohortService.Progress(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/cohort_progress.html")
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Progress *models.CohortProgress
+	}{
+		Progress: progress,
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		log.Printf("Template execution error: %v", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// interviewCaller returns the caller that owns interview sessions (see aiCaller), handing
+// it the sessions saved before sessions had an owner that were started under its
+// username cookie or address
+func (h *APIHandler) interviewCaller(w http.ResponseWriter, r *http.Request) string {
+	caller := h.aiCaller(w, r)
+	legacy := []string{clientNetwork(r)}
+	if username := usernameCookie(r); username != "" {
+		legacy = append(legacy, username)
+	}
+	h.interviewService.Adopt(caller, legacy...)
+	return caller
+}
+
+// usernameCookie returns the GitHub username the browser says it belongs to, or "". It is
+// only a display name: anyone can set the cookie.
+func usernameCookie(r *http.Request) string {
+	if cookie, err := r.Cookie("username"); err == nil && services.ValidGitHubUsername(strings.TrimSpace(cookie.Value)) {
+		return strings.TrimSpace(cookie.Value)
+	}
+	return ""
+}
+
+// Interviews lists the caller's interview sessions (GET) or starts a new one (POST)
+func (h *APIHandler) Interviews(w http.ResponseWriter, r *http.Request) {
+	caller := h.interviewCaller(w, r)
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"interviews": h.interviewService.List(caller),
+		})
+	case "POST":
+		var request struct {
+			ChallengeID int    `json:"challengeId"`
+			Code        string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request data", http.StatusBadRequest)
+			return
+		}
+
+		session, err := h.interviewService.Start(caller, usernameCookie(r), request.ChallengeID, request.Code)
+		if err != nil {
+			writeInterviewError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(session)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleInterview serves /api/interviews/{id} (GET), /{id}/answer and /{id}/finish (POST)
+// and /{id}/transcript?format=md|json (GET)
+func (h *APIHandler) HandleInterview(w http.ResponseWriter, r *http.Request) {
+	// Extract session ID and action from URL: /api/interviews/{id}[/action]
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/interviews/"), "/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	id, action := parts[0], ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+	caller := h.interviewCaller(w, r)
+
+	switch action {
+	case "":
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		session, err := h.interviewService.Get(caller, id)
+		if err != nil {
+			writeInterviewError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(session)
+
+	case "answer", "finish":
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var request struct {
+			Answer string `json:"answer"`
+			Code   string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request data", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		var session *models.InterviewSession
+		if action == "answer" {
+			session, err = h.interviewService.Answer(caller, id, request.Answer, request.Code)
+		} else {
+			session, err = h.interviewService.Finish(caller, id, request.Code)
+		}
+		if err != nil {
+			writeInterviewError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(session)
+
+	case "transcript":
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		session, err := h.interviewService.Get(caller, id)
+		if err != nil {
+			writeInterviewError(w, err)
+			return
+		}
+
+		filename := fmt.Sprintf("interview-challenge-%d-%s", session.ChallengeID, session.StartedAt.Format("2006-01-02"))
+		switch r.URL.Query().Get("format") {
+		case "", "md", "markdown":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".md"))
+			if err := services.WriteTranscriptMarkdown(w, session); err != nil {
+				log.Printf("Error writing interview transcript: %v", err)
+			}
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(session)
+		default:
+			http.Error(w, "Unsupported format. Use md or json", http.StatusBadRequest)
+		}
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writeInterviewError maps interview and AI errors to a JSON failure response
+func writeInterviewError(w http.ResponseWriter, err error) {
+	if services.IsQuotaError(err) {
+		writeAIError(w, err, "AI interview failed")
+		return
+	}
+
+	status := http.StatusBadGateway // the AI provider failed
+	switch {
+	case errors.Is(err, services.ErrInterviewNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrUnknownChallenge):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAnswer):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrInterviewClosed), errors.Is(err, services.ErrInterviewTooLong):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrAINotConfigured):
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
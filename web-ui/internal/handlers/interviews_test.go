@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-ui/internal/services"
+)
+
+// browser sends requests with the cookies it was given, from one address
+type browser struct {
+	t          *testing.T
+	h          *APIHandler
+	remoteAddr string
+	cookies    []*http.Cookie
+}
+
+func (b *browser) do(method, path, body string) *httptest.ResponseRecorder {
+	b.t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.RemoteAddr = b.remoteAddr
+	for _, cookie := range b.cookies {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	if path == "/api/interviews" {
+		b.h.Interviews(w, r)
+	} else {
+		b.h.HandleInterview(w, r)
+	}
+	b.cookies = append(b.cookies, w.Result().Cookies()...)
+	return w
+}
+
+func (b *browser) list() []string {
+	b.t.Helper()
+	var response struct {
+		Interviews []struct{ ID string } `json:"interviews"`
+	}
+	if err := json.Unmarshal(b.do("GET", "/api/interviews", "").Body.Bytes(), &response); err != nil {
+		b.t.Fatal(err)
+	}
+	var ids []string
+	for _, interview := range response.Interviews {
+		ids = append(ids, interview.ID)
+	}
+	return ids
+}
+
+func (b *browser) start() string {
+	b.t.Helper()
+	w := b.do("POST", "/api/interviews", `{"challengeId": 1, "code": "package main"}`)
+	if w.Code != http.StatusCreated {
+		b.t.Fatalf("start: status = %d, body %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), `"owner"`) {
+		b.t.Fatalf("the owner key was sent to the client: %s", w.Body.String())
+	}
+	var session struct{ ID string }
+	if err := json.Unmarshal(w.Body.Bytes(), &session); err != nil {
+		b.t.Fatal(err)
+	}
+	return session.ID
+}
+
+func newInterviewTestHandler(t *testing.T) *APIHandler {
+	t.Helper()
+	h := newAITestHandler(t, map[string]string{"WORKSPACE_USER": "alice"})
+	workspace, err := services.NewWorkspaceService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.workspaceService = workspace
+	h.interviewService = services.NewInterviewService(workspace, h.challengeService, h.aiService)
+	return h
+}
+
+func TestInterviewsBelongToBrowserSessions(t *testing.T) {
+	h := newInterviewTestHandler(t)
+	office := "203.0.113.7:5000"
+	alice := &browser{t: t, h: h, remoteAddr: office, cookies: []*http.Cookie{{Name: "username", Value: "alice"}}}
+	bob := &browser{t: t, h: h, remoteAddr: office}
+
+	aliceID := alice.start()
+	bobID := bob.start()
+
+	if ids := alice.list(); len(ids) != 1 || ids[0] != aliceID {
+		t.Fatalf("alice lists %v, want only %s", ids, aliceID)
+	}
+	if ids := bob.list(); len(ids) != 1 || ids[0] != bobID {
+		t.Fatalf("bob lists %v, want only %s", ids, bobID)
+	}
+
+	for _, request := range []struct{ method, path, body string }{
+		{"GET", "/api/interviews/" + aliceID, ""},
+		{"GET", "/api/interviews/" + aliceID + "/transcript", ""},
+		{"POST", "/api/interviews/" + aliceID + "/answer", `{"answer": "a map"}`},
+		{"POST", "/api/interviews/" + aliceID + "/finish", `{}`},
+	} {
+		if w := bob.do(request.method, request.path, request.body); w.Code != http.StatusNotFound {
+			t.Fatalf("bob %s %s: status = %d, want %d", request.method, request.path, w.Code, http.StatusNotFound)
+		}
+	}
+
+	// The session can be resumed from another network
+	travelling := &browser{t: t, h: h, remoteAddr: "198.51.100.1:5000", cookies: alice.cookies}
+	if w := travelling.do("GET", "/api/interviews/"+aliceID, ""); w.Code != http.StatusOK {
+		t.Fatalf("resume from another network: status = %d", w.Code)
+	}
+
+	transcript := alice.do("GET", "/api/interviews/"+aliceID+"/transcript", "").Body.String()
+	if !strings.Contains(transcript, "- Candidate: alice\n") {
+		t.Fatalf("transcript does not name the candidate:\n%s", transcript)
+	}
+	if bobTranscript := bob.do("GET", "/api/interviews/"+bobID+"/transcript", "").Body.String(); strings.Contains(bobTranscript, "Candidate:") {
+		t.Fatalf("transcript names an anonymous candidate:\n%s", bobTranscript)
+	}
+}
+
+func TestInterviewsAdoptLegacySessions(t *testing.T) {
+	h := newInterviewTestHandler(t)
+	dir := filepath.Join(h.workspaceService.Root(), services.InterviewsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := map[string]string{
+		"aaaaaaaaaaaaaaaaaaaaaaaa": "carol",
+		"bbbbbbbbbbbbbbbbbbbbbbbb": "ip:198.51.100.9",
+	}
+	for id, username := range legacy {
+		session := `{"id": "` + id + `", "username": "` + username + `", "challengeId": 1, "status": "active", "turns": []}`
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(session), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.interviewService.LoadInterviews(); err != nil {
+		t.Fatal(err)
+	}
+
+	stranger := &browser{t: t, h: h, remoteAddr: "203.0.113.7:5000"}
+	if ids := stranger.list(); len(ids) != 0 {
+		t.Fatalf("a stranger sees %v", ids)
+	}
+
+	carol := &browser{t: t, h: h, remoteAddr: "203.0.113.7:5000", cookies: []*http.Cookie{{Name: "username", Value: "carol"}}}
+	if ids := carol.list(); len(ids) != 1 || ids[0] != "aaaaaaaaaaaaaaaaaaaaaaaa" {
+		t.Fatalf("carol lists %v", ids)
+	}
+	imposter := &browser{t: t, h: h, remoteAddr: "203.0.113.7:5000", cookies: []*http.Cookie{{Name: "username", Value: "carol"}}}
+	if ids := imposter.list(); len(ids) != 0 {
+		t.Fatalf("a second browser claiming to be carol sees %v", ids)
+	}
+
+	home := &browser{t: t, h: h, remoteAddr: "198.51.100.9:5000"}
+	if ids := home.list(); len(ids) != 1 || ids[0] != "bbbbbbbbbbbbbbbbbbbbbbbb" {
+		t.Fatalf("the browser at the saved address lists %v", ids)
+	}
+	transcript := home.do("GET", "/api/interviews/bbbbbbbbbbbbbbbbbbbbbbbb/transcript", "").Body.String()
+	if strings.Contains(transcript, "ip:") {
+		t.Fatalf("transcript shows an address as the candidate:\n%s", transcript)
+	}
+
+	// Adoption is saved, so it survives a restart
+	if err := h.interviewService.LoadInterviews(); err != nil {
+		t.Fatal(err)
+	}
+	if ids := carol.list(); len(ids) != 1 {
+		t.Fatalf("after a restart carol lists %v", ids)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"web-ui/internal/services"
+)
+
+// languageCookie holds the content language picked in the language menu
+const languageCookie = "lang"
+
+// requestLanguages returns the caller's preferred languages: ?lang=, then the lang
+// cookie, then Accept-Language
+func requestLanguages(r *http.Request) []string {
+	var languages []string
+	if language := services.NormalizeLanguage(r.URL.Query().Get("lang")); language != "" {
+		languages = append(languages, language)
+	}
+	if cookie, err := r.Cookie(languageCookie); err == nil {
+		if language := services.NormalizeLanguage(cookie.Value); language != "" {
+			languages = append(languages, language)
+		}
+	}
+	return append(languages, services.ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+}
+
+// contentLanguage picks the language to show challenge content in
+func contentLanguage(r *http.Request, cs *services.ChallengeService, ps *services.PackageService) string {
+	return services.MatchLanguage(requestLanguages(r), services.ContentLanguages(cs, ps))
+}
+
+// GetLanguages lists the languages challenge content is available in, with the
+// translation coverage of each, and the language chosen for this request
+func (h *APIHandler) GetLanguages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"default":   services.DefaultLanguage,
+		"current":   contentLanguage(r, h.challengeService, h.packageService),
+		"languages": services.TranslationCoverage(h.challengeService, h.packageService),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"web-ui/internal/utils"
+)
+
+// maxMarkdownRequest caps the body of a render request; READMEs are a few kilobytes
+const maxMarkdownRequest = 1 << 20
+
+type markdownDocument struct {
+	Markdown string `json:"markdown"`
+	Breaks   bool   `json:"breaks"`
+}
+
+func (d markdownDocument) render() string {
+	return utils.RenderMarkdownWithOptions(d.Markdown, utils.MarkdownOptions{HardBreaks: d.Breaks})
+}
+
+// RenderMarkdown serves POST /api/markdown, rendering Markdown to sanitized HTML for
+// content that is built in the browser, such as AI responses. The body is either
+// {"markdown": "...", "breaks": true} or {"documents": [{"markdown", "breaks"}, ...]};
+// "html" in the response is a string or an array accordingly.
+func (h *APIHandler) RenderMarkdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		markdownDocument
+		Documents []markdownDocument `json:"documents"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxMarkdownRequest)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{"success": true}
+	if request.Documents != nil {
+		rendered := make([]string, len(request.Documents))
+		for i, document := range request.Documents {
+			rendered[i] = document.render()
+		}
+		response["html"] = rendered
+	} else {
+		response["html"] = request.render()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"web-ui/internal/models"
+)
+
+// sessionCookie holds a random ID the server issues to tie the passing submissions it ran
+// to the browser that sent them. Unlike the username cookie, a client cannot choose it to
+// claim someone else's passes.
+const sessionCookie = "session"
+
+var sessionIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// requestSession returns the server-issued session of the request, or ""
+func requestSession(r *http.Request) string {
+	if cookie, err := r.Cookie(sessionCookie); err == nil && sessionIDPattern.MatchString(cookie.Value) {
+		return cookie.Value
+	}
+	return ""
+}
+
+// ensureSession returns the request's session, issuing a new one when it has none. It
+// must be called before the response is written.
+func ensureSession(w http.ResponseWriter, r *http.Request) string {
+	if session := requestSession(r); session != "" {
+		return session
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	session := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return session
+}
+
+// referenceUnlocked reports whether the requester may see the challenge's reference. It
+// only trusts identities the server controls: the session cookie it issued and the owner
+// of the checkout.
+func (h *APIHandler) referenceUnlocked(r *http.Request, challenge *models.Challenge) bool {
+	owner := ""
+	if h.workspaceService != nil {
+		owner = h.workspaceService.Owner()
+	}
+	return h.referenceService.Unlocked(requestSession(r), owner, challenge)
+}
+
+// HandleReference serves /api/reference. GET ?challengeId=1[&package=gin] reports whether
+// the challenge ships a reference solution and whether the requester may see it; POST
+// {"challengeId", "package", "code"} compares the code with it. The reference stays hidden
+// until this browser session has a passing submission of the challenge, or the owner of the
+// checkout has a passing row on the scoreboard.
+func (h *APIHandler) HandleReference(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getReferenceStatus(w, r)
+	case "POST":
+		h.compareWithReference(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *APIHandler) getReferenceStatus(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	challenge, exists := h.aiChallenge(query.Get("package"), challengeRef(query.Get("challengeId")))
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	_, available := h.referenceService.Shipped(challenge)
+	response := map[string]interface{}{
+		"success":   true,
+		"available": available,
+		"unlocked":  available && h.referenceUnlocked(r, challenge),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIHandler) compareWithReference(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Package     string       `json:"package"`
+		ChallengeID challengeRef `json:"challengeId"`
+		Code        string       `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	challenge, exists := h.aiChallenge(request.Package, request.ChallengeID)
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+	if _, available := h.referenceService.Shipped(challenge); !available {
+		http.Error(w, "This challenge has no reference solution", http.StatusNotFound)
+		return
+	}
+
+	if !h.referenceUnlocked(r, challenge) {
+		http.Error(w, "Submit a passing solution to see the reference solution", http.StatusForbidden)
+		return
+	}
+
+	comparison, err := h.referenceService.Compare(request.Code, challenge)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":    true,
+		"comparison": comparison,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
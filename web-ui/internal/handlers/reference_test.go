@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-ui/internal/services"
+)
+
+func TestReferenceIgnoresClientUsernames(t *testing.T) {
+	h := newAITestHandler(t, nil)
+	h.referenceService = services.NewReferenceService(nil)
+
+	challenge, _ := h.challengeService.GetChallenge(1)
+	dir := h.referenceService.ChallengeDir(challenge)
+	if err := os.MkdirAll(filepath.Join(dir, "reference"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "reference", "solution-template.go"), []byte(testSolution), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scoreboard := "| Username | Passed Tests | Total Tests |\n|---|---|---|\n| alice | 1 | 1 |\n"
+	if err := os.WriteFile(filepath.Join(dir, "SCOREBOARD.md"), []byte(scoreboard), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	status := func(r *http.Request) bool {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.HandleReference(w, r)
+		var response struct {
+			Available bool `json:"available"`
+			Unlocked  bool `json:"unlocked"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || !response.Available {
+			t.Fatalf("status response %s", w.Body.String())
+		}
+		return response.Unlocked
+	}
+
+	// alice solved the challenge on the scoreboard, but naming her proves nothing
+	byQuery := httptest.NewRequest("GET", "/api/reference?challengeId=1&username=alice", nil)
+	byCookie := httptest.NewRequest("GET", "/api/reference?challengeId=1", nil)
+	byCookie.AddCookie(&http.Cookie{Name: "username", Value: "alice"})
+	if status(byQuery) || status(byCookie) {
+		t.Fatal("a client-supplied username unlocked the reference")
+	}
+
+	compare := httptest.NewRequest("POST", "/api/reference", strings.NewReader(`{"challengeId":"1","username":"alice","code":"package main"}`))
+	w := httptest.NewRecorder()
+	h.HandleReference(w, compare)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("compare status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// A session the server issued and saw pass is unlocked
+	recorder := httptest.NewRecorder()
+	session := ensureSession(recorder, httptest.NewRequest("POST", "/api/submissions", nil))
+	h.referenceService.RecordPass(session, challenge)
+	withSession := httptest.NewRequest("GET", "/api/reference?challengeId=1", nil)
+	for _, cookie := range recorder.Result().Cookies() {
+		withSession.AddCookie(cookie)
+	}
+	if !status(withSession) {
+		t.Fatal("the passing session was not unlocked")
+	}
+
+	forged := httptest.NewRequest("GET", "/api/reference?challengeId=1", nil)
+	forged.AddCookie(&http.Cookie{Name: sessionCookie, Value: "alice"})
+	if status(forged) {
+		t.Fatal("a malformed session unlocked the reference")
+	}
+}
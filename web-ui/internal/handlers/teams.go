@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"web-ui/internal/models"
+	"web-ui/internal/utils"
+)
+
+// GetTeams returns all teams with the classic team leaderboard
+func (h *APIHandler) GetTeams(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"teams":       h.teamService.GetTeams(),
+		"leaderboard": h.teamService.ClassicLeaderboard(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTeamDashboard returns per-member progress for a team
+func (h *APIHandler) GetTeamDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract team ID from URL: /api/teams/{id}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/teams/"), "/")
+	dashboard, err := h.teamService.Dashboard(id)
+	if err != nil {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboard)
+}
+
+// GetTeamLeaderboard returns the team leaderboard for classic challenges or, with ?package=, a package path
+func (h *APIHandler) GetTeamLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	packageName := r.URL.Query().Get("package")
+	leaderboard := h.teamService.ClassicLeaderboard()
+	if packageName != "" {
+		var err error
+		leaderboard, err = h.teamService.PackageLeaderboard(packageName)
+		if err != nil {
+			http.Error(w, "Package not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"package":     packageName,
+		"leaderboard": leaderboard,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// TeamsPage renders team leaderboards for classic challenges and every package
+func (h *WebHandler) TeamsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/teams.html")
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type packageBoard struct {
+		Name        string
+		DisplayName string
+		Leaderboard []models.TeamLeaderboardEntry
+	}
+
+	var packageBoards []packageBoard
+	for name, pkg := range h.packageService.GetPackages() {
+		leaderboard, err := h.teamService.PackageLeaderboard(name)
+		if err != nil {
+			continue
+		}
+		packageBoards = append(packageBoards, packageBoard{
+			Name:        name,
+			DisplayName: pkg.DisplayName,
+			Leaderboard: leaderboard,
+		})
+	}
+	sort.Slice(packageBoards, func(i, j int) bool {
+		return packageBoards[i].DisplayName < packageBoards[j].DisplayName
+	})
+
+	data := struct {
+		Teams              []*models.Team
+		ClassicLeaderboard []models.TeamLeaderboardEntry
+		PackageBoards      []packageBoard
+		Username           string
+	}{
+		Teams:              h.teamService.GetTeams(),
+		ClassicLeaderboard: h.teamService.ClassicLeaderboard(),
+		PackageBoards:      packageBoards,
+		Username:           h.getUsernameFromCookie(r),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		log.Printf("Template execution error: %v", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
+// TeamDashboardPage renders who in a team solved what and who is stuck
+func (h *WebHandler) TeamDashboardPage(w http.ResponseWriter, r *http.Request) {
+	// Extract team ID from URL: /teams/{id}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/teams/"), "/")
+	if id == "" {
+		h.TeamsPage(w, r)
+		return
+	}
+
+	dashboard, err := h.teamService.Dashboard(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/team_dashboard.html")
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Dashboard  *models.TeamDashboard
+		Challenges models.ChallengeMap
+		Packages   map[string]*models.Package
+	}{
+		Dashboard:  dashboard,
+		Challenges: h.challengeService.GetChallenges(),
+		Packages:   h.packageService.GetPackages(),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		log.Printf("Template execution error: %v", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
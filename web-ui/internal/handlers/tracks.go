@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/services"
+)
+
+// requestUsername returns the username query parameter, or else the username cookie
+func requestUsername(r *http.Request) string {
+	if username := strings.TrimSpace(r.URL.Query().Get("username")); username != "" {
+		return username
+	}
+	if cookie, err := r.Cookie("username"); err == nil {
+		return strings.TrimSpace(cookie.Value)
+	}
+	return ""
+}
+
+// GetTracks returns every learning track with the user's progress, and the
+// prerequisite graph of the classic challenges
+func (h *APIHandler) GetTracks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":       true,
+		"tracks":        h.trackService.Progress(requestUsername(r)),
+		"prerequisites": h.trackService.Prerequisites(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleTrack serves /api/tracks/{id}: one track with the user's progress
+func (h *APIHandler) HandleTrack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tracks/"), "/")
+	progress, err := h.trackService.TrackProgress(id, requestUsername(r))
+	if err != nil {
+		http.Error(w, "Track not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// GetRecommendations suggests the user's next challenges:
+// /api/recommendations?username=alice[&track=concurrency][&limit=3]
+func (h *APIHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := requestUsername(r)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	recommendations, err := h.trackService.Recommend(username, r.URL.Query().Get("track"), limit)
+	if err == services.ErrTrackNotFound {
+		http.Error(w, "Track not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":         true,
+		"username":        username,
+		"recommendations": recommendations,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -20,11 +20,15 @@ import (
 
 // WebHandler handles web page rendering
 type WebHandler struct {
-	content           embed.FS
-	challengeService  *services.ChallengeService
-	scoreboardService *services.ScoreboardService
-	userService       *services.UserService
-	packageService    *services.PackageService
+	content            embed.FS
+	challengeService   *services.ChallengeService
+	scoreboardService  *services.ScoreboardService
+	userService        *services.UserService
+	packageService     *services.PackageService
+	leaderboardService *services.LeaderboardService
+	profileService     *services.ProfileService
+	teamService        *services.TeamService
+	cohortService      *services.CohortService
 }
 
 // NewWebHandler creates a new web handler
@@ -34,13 +38,21 @@ func NewWebHandler(
 	scoreboardService *services.ScoreboardService,
 	userService *services.UserService,
 	packageService *services.PackageService,
+	leaderboardService *services.LeaderboardService,
+	profileService *services.ProfileService,
+	teamService *services.TeamService,
+	cohortService *services.CohortService,
 ) *WebHandler {
 	return &WebHandler{
-		content:           content,
-		challengeService:  challengeService,
-		scoreboardService: scoreboardService,
-		userService:       userService,
-		packageService:    packageService,
+		content:            content,
+		challengeService:   challengeService,
+		scoreboardService:  scoreboardService,
+		userService:        userService,
+		packageService:     packageService,
+		leaderboardService: leaderboardService,
+		profileService:     profileService,
+		teamService:        teamService,
+		cohortService:      cohortService,
 	}
 }
 
@@ -58,10 +70,12 @@ func (h *WebHandler) HomePage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert map to slice for template
-	var challengeList []*models.Challenge
-	for _, challenge := range h.challengeService.GetChallenges() {
-		challengeList = append(challengeList, challenge)
+	// Classic challenges, narrowed down by ?difficulty= and ?tag=
+	filter := services.ParseChallengeFilter(r.URL.Query())
+	challengeList := h.challengeService.FilterChallenges(filter)
+	language := contentLanguage(r, h.challengeService, h.packageService)
+	for i, challenge := range challengeList {
+		challengeList[i] = services.LocalizeChallenge(challenge, language)
 	}
 
 	// Get packages for the Package Mastery tab
@@ -118,12 +132,16 @@ func (h *WebHandler) HomePage(w http.ResponseWriter, r *http.Request) {
 
 	data := struct {
 		Challenges   []*models.Challenge
+		Filter       services.ChallengeFilter
+		Tags         []string
 		Username     string
 		UserAttempts *models.UserAttemptedChallenges
 		Packages     map[string]*models.Package
 		PackagesList []*PackageWithName
 	}{
 		Challenges:   challengeList,
+		Filter:       filter,
+		Tags:         h.challengeService.Tags(),
 		Username:     username,
 		UserAttempts: userAttempt,
 		Packages:     packages,
@@ -152,6 +170,7 @@ func (h *WebHandler) ChallengePage(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	challenge = services.LocalizeChallenge(challenge, contentLanguage(r, h.challengeService, h.packageService))
 
 	// Get username from cookie first
 	username := h.getUsernameFromCookie(r)
@@ -360,6 +379,45 @@ func (h *WebHandler) InterviewPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// UserProfilePage renders a user's progress across classic and package challenges
+func (h *WebHandler) UserProfilePage(w http.ResponseWriter, r *http.Request) {
+	// Extract username from URL: /users/{username}
+	username := strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+	if username == "" || strings.Contains(username, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	profile, found := h.profileService.BuildProfile(username, h.loadSponsors())
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/user_profile.html")
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Profile        *models.UserProfile
+		Username       string
+		ViewerUsername string
+	}{
+		Profile:        profile,
+		Username:       profile.Username,
+		ViewerUsername: h.getUsernameFromCookie(r),
+	}
+
+	err = tmpl.ExecuteTemplate(w, "base", data)
+	if err != nil {
+		log.Printf("Template execution error: %v", err)
+		// Don't call http.Error here since headers may already be sent during template execution
+	}
+}
+
 // getUsernameFromCookie retrieves the username from cookie
 func (h *WebHandler) getUsernameFromCookie(r *http.Request) string {
 	cookie, err := r.Cookie("username")
@@ -420,9 +478,10 @@ func (h *WebHandler) PackageDetailPage(w http.ResponseWriter, r *http.Request) {
 	sort.Strings(challengeIDs)
 
 	// Convert map to sorted slice using learning path order
+	language := contentLanguage(r, h.challengeService, h.packageService)
 	for _, challengeID := range pkg.LearningPath {
 		if challenge, exists := challengesMap[challengeID]; exists {
-			challenges = append(challenges, challenge)
+			challenges = append(challenges, services.LocalizePackageChallenge(challenge, language))
 		}
 	}
 
@@ -521,6 +580,7 @@ func (h *WebHandler) PackageChallengePage(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
+	challenge = services.LocalizePackageChallenge(challenge, contentLanguage(r, h.challengeService, h.packageService))
 
 	tmpl, err := template.New("").Funcs(utils.GetTemplateFuncs()).ParseFS(h.content, "templates/base.html", "templates/package_challenge.html")
 	if err != nil {
@@ -649,100 +709,12 @@ func (h *WebHandler) countPackageChallengeSubmissions(packageName, challengeID s
 
 // createPackageLeaderboard creates a leaderboard for package challenges similar to classic challenges
 func (h *WebHandler) createPackageLeaderboard(packageName string, challenges []*models.PackageChallenge) []models.PackageScoreboardEntry {
-	var leaderboard []models.PackageScoreboardEntry
-	userStats := make(map[string]*userPackageStats)
-	
-	// Load sponsors for package leaderboard (reuse from API handler)
-	// Create a temporary API handler instance to access LoadSponsors
-	tempHandler := &APIHandler{}
-	sponsors := tempHandler.LoadSponsors()
-
-	// Collect submission data for each challenge
-	for _, challenge := range challenges {
-		submissionsDir := filepath.Join("..", "packages", packageName, challenge.ID, "submissions")
-
-		// Check if submissions directory exists
-		if _, err := os.Stat(submissionsDir); os.IsNotExist(err) {
-			continue
-		}
-
-		// Read the submissions directory
-		entries, err := ioutil.ReadDir(submissionsDir)
-		if err != nil {
-			continue
-		}
-
-		for _, entry := range entries {
-			if entry.IsDir() {
-				username := entry.Name()
-				userDir := filepath.Join(submissionsDir, username)
-				solutionPath := filepath.Join(userDir, "solution.go")
-				altSolutionPath := filepath.Join(userDir, "solution-template.go")
-
-				// Check if user has a solution file (either solution.go or solution-template.go)
-				var modTime time.Time
-				if stat, err := os.Stat(solutionPath); err == nil {
-					modTime = stat.ModTime()
-				} else if stat, err := os.Stat(altSolutionPath); err == nil {
-					modTime = stat.ModTime()
-				} else {
-					continue
-				}
-
-				if userStats[username] == nil {
-					userStats[username] = &userPackageStats{
-						username:            username,
-						completedCount:      0,
-						lastSubmission:      modTime,
-						challengesCompleted: make(map[string]bool),
-					}
-				}
-
-				// Only count if not already counted
-				if !userStats[username].challengesCompleted[challenge.ID] {
-					userStats[username].completedCount++
-					userStats[username].challengesCompleted[challenge.ID] = true
-
-					// Update last submission time if this is more recent
-					if modTime.After(userStats[username].lastSubmission) {
-						userStats[username].lastSubmission = modTime
-					}
-				}
-			}
-		}
-	}
-
-	// Convert to leaderboard entries and sort
-	for username, stats := range userStats {
-		if stats.completedCount > 0 {
-			entry := models.PackageScoreboardEntry{
-				Username:    username,
-				PackageName: packageName,
-				ChallengeID: "", // Not specific to one challenge
-				SubmittedAt: stats.lastSubmission,
-				TestsPassed: stats.completedCount,
-				TestsTotal:  len(challenges),
-				IsSponsor:   sponsors[username],
-			}
-			leaderboard = append(leaderboard, entry)
-		}
-	}
-
-	// Sort by completed count (descending), then by submission time (ascending for earliest)
-	sort.Slice(leaderboard, func(i, j int) bool {
-		if leaderboard[i].TestsPassed != leaderboard[j].TestsPassed {
-			return leaderboard[i].TestsPassed > leaderboard[j].TestsPassed
-		}
-		return leaderboard[i].SubmittedAt.Before(leaderboard[j].SubmittedAt)
-	})
-
-	return leaderboard
+	return h.leaderboardService.PackageLeaderboard(packageName, challenges, h.loadSponsors())
 }
 
-// userPackageStats helper struct for collecting user statistics
-type userPackageStats struct {
-	username            string
-	completedCount      int
-	lastSubmission      time.Time
-	challengesCompleted map[string]bool
+// loadSponsors returns the cached sponsor list shared with the API handler
+func (h *WebHandler) loadSponsors() map[string]bool {
+	// Create a temporary API handler instance to access LoadSponsors
+	tempHandler := &APIHandler{}
+	return tempHandler.LoadSponsors()
 }
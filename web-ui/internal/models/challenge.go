@@ -12,8 +12,33 @@ type Challenge struct {
 	Difficulty        string `json:"difficulty"`
 	Template          string `json:"template"`
 	TestFile          string `json:"testFile"`
+	SuiteVersion      string `json:"suiteVersion,omitempty"` // content hash of TestFile
 	LearningMaterials string `json:"learningMaterials"`
 	Hints             string `json:"hints"`
+
+	// From metadata.json
+	ShortDescription   string   `json:"shortDescription,omitempty"`
+	EstimatedTime      string   `json:"estimatedTime,omitempty"`
+	LearningObjectives []string `json:"learningObjectives,omitempty"`
+	Prerequisites      []string `json:"prerequisites,omitempty"` // "challenge-N" for classic challenges
+	Tags               []string `json:"tags,omitempty"`
+
+	Package *ChallengePackage `json:"package,omitempty"` // Set when converted from a package challenge
+
+	// Language of the content; Untranslated lists the parts shown in English instead
+	Language     string                           `json:"language,omitempty"`
+	Untranslated []string                         `json:"untranslated,omitempty"`
+	Translations map[string]*ChallengeTranslation `json:"-"` // by language, e.g. "es"
+}
+
+// ChallengeTranslation is a challenge's content in one language, read from
+// README.<lang>.md, learning.<lang>.md, hints.<lang>.md and the "translations" of
+// metadata.json. Empty parts fall back to English.
+type ChallengeTranslation struct {
+	Description       string
+	LearningMaterials string
+	Hints             string
+	Metadata          *MetadataTranslation
 }
 
 // Submission represents a user's submitted solution
@@ -25,13 +50,29 @@ type Submission struct {
 	Passed      bool      `json:"passed"`
 	TestOutput  string    `json:"testOutput"`
 	ExecutionMs int64     `json:"executionMs"`
+
+	SuiteVersion string `json:"suiteVersion,omitempty"` // test suite the submission was graded against
 }
 
 // ScoreboardEntry represents an entry in the scoreboard
 type ScoreboardEntry struct {
-	Username    string    `json:"username"`
-	ChallengeID int       `json:"challengeId"`
-	SubmittedAt time.Time `json:"submittedAt"`
+	Username     string    `json:"username"`
+	ChallengeID  int       `json:"challengeId"`
+	SubmittedAt  time.Time `json:"submittedAt"`
+	SuiteVersion string    `json:"suiteVersion,omitempty"`
+	Stale        bool      `json:"stale,omitempty"` // graded against an older test suite
+}
+
+// LeaderboardUser represents a user in the main leaderboard
+type LeaderboardUser struct {
+	Username            string       `json:"username"`
+	CompletedCount      int          `json:"completedCount"`
+	CompletionRate      float64      `json:"completionRate"`
+	CompletedChallenges map[int]bool `json:"completedChallenges"`
+	Achievement         string       `json:"achievement"`
+	Rank                int          `json:"rank"`
+	IsSponsor           bool         `json:"isSponsor"`
+	StaleCount          int          `json:"staleCount,omitempty"` // completions graded against an older test suite
 }
 
 // UserAttemptedChallenges tracks attempted challenges by username
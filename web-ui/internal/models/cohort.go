@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Cohort status values for a single member/challenge cell
+const (
+	CohortStatusCompleted  = "completed"   // all tests passed on or before the due date
+	CohortStatusLate       = "late"        // all tests passed after the due date
+	CohortStatusMissing    = "missing"     // due date passed without a passing submission
+	CohortStatusInProgress = "in-progress" // attempted, not yet passing, not yet due
+	CohortStatusPending    = "pending"     // not started, not yet due
+)
+
+// Cohort is a group of users working through an assigned set of challenges
+type Cohort struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Members     []string           `json:"members"`
+	Assignments []CohortAssignment `json:"assignments"`
+}
+
+// CohortAssignment assigns classic challenges or package challenges with a due date.
+// A package assignment with no PackageChallenges covers the whole package.
+type CohortAssignment struct {
+	Challenges        []int    `json:"challenges,omitempty"`
+	Package           string   `json:"package,omitempty"`
+	PackageChallenges []string `json:"packageChallenges,omitempty"`
+	DueDate           string   `json:"dueDate"` // YYYY-MM-DD, due at the end of that day
+}
+
+// CohortsFile is the on-disk layout of cohorts.json
+type CohortsFile struct {
+	Cohorts []*Cohort `json:"cohorts"`
+}
+
+// CohortItem is one assigned challenge, a column of the progress matrix
+type CohortItem struct {
+	Key         string    `json:"key"` // "challenge-3" or "gin/challenge-1-basic-routing"
+	Title       string    `json:"title"`
+	ChallengeID int       `json:"challengeId,omitempty"`
+	Package     string    `json:"package,omitempty"`
+	PackageID   string    `json:"packageChallengeId,omitempty"`
+	DueDate     string    `json:"dueDate"`
+	Due         time.Time `json:"due"`
+	Overdue     bool      `json:"overdue"`
+}
+
+// CohortCell is one member's status on one assigned challenge
+type CohortCell struct {
+	Status      string     `json:"status"`
+	Score       int        `json:"score"` // percentage of tests passed, 0..100
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// CohortMemberProgress is a row of the progress matrix
+type CohortMemberProgress struct {
+	Username   string       `json:"username"`
+	Cells      []CohortCell `json:"cells"` // same order as CohortProgress.Items
+	Completed  int          `json:"completed"`
+	Late       int          `json:"late"`
+	Missing    int          `json:"missing"`
+	InProgress int          `json:"inProgress"`
+	Progress   int          `json:"progress"` // percentage of items completed, on time or late
+}
+
+// CohortProgress is the member x assigned-challenge progress matrix for a cohort
+type CohortProgress struct {
+	Cohort      *Cohort                `json:"cohort"`
+	Items       []CohortItem           `json:"items"`
+	Members     []CohortMemberProgress `json:"members"`
+	GeneratedAt time.Time              `json:"generatedAt"`
+}
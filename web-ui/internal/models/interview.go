@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Interview session statuses
+const (
+	InterviewActive    = "active"
+	InterviewCompleted = "completed"
+)
+
+// Interview turn roles
+const (
+	InterviewerRole = "interviewer"
+	CandidateRole   = "candidate"
+)
+
+// InterviewTurn is one message in an interview transcript
+type InterviewTurn struct {
+	Role      string    `json:"role"` // "interviewer" or "candidate"
+	Content   string    `json:"content"`
+	Code      string    `json:"code,omitempty"` // the candidate's code when they answered
+	CreatedAt time.Time `json:"createdAt"`
+
+	PromptVersion string `json:"promptVersion,omitempty"` // prompt templates an interviewer turn was generated from
+}
+
+// InterviewScores rates the candidate on a 1-5 scale per dimension
+type InterviewScores struct {
+	ProblemSolving int `json:"problem_solving"`
+	Communication  int `json:"communication"`
+	GoKnowledge    int `json:"go_knowledge"`
+	CodeQuality    int `json:"code_quality"`
+	Testing        int `json:"testing"`
+}
+
+// InterviewEvaluation is the AI interviewer's structured verdict at the end of a session
+type InterviewEvaluation struct {
+	OverallScore   float64         `json:"overall_score"`  // 0-100
+	Recommendation string          `json:"recommendation"` // "strong_hire", "hire", "lean_hire", "lean_no_hire", "no_hire"
+	Summary        string          `json:"summary"`
+	Strengths      []string        `json:"strengths"`
+	Weaknesses     []string        `json:"weaknesses"`
+	Scores         InterviewScores `json:"scores"`
+	NextSteps      []string        `json:"next_steps"`
+	PromptVersion  string          `json:"prompt_version,omitempty"` // prompt templates the evaluation was generated from
+}
+
+// InterviewSession is a server-side, multi-turn AI interview on one challenge
+type InterviewSession struct {
+	ID             string               `json:"id"`
+	Owner          string               `json:"owner,omitempty"`    // caller key of the browser session it belongs to; never sent to clients
+	Username       string               `json:"username,omitempty"` // candidate's GitHub username, when known
+	ChallengeID    int                  `json:"challengeId"`
+	ChallengeTitle string               `json:"challengeTitle"`
+	Status         string               `json:"status"`
+	Code           string               `json:"code"` // latest code seen by the interviewer
+	Turns          []InterviewTurn      `json:"turns"`
+	Evaluation     *InterviewEvaluation `json:"evaluation,omitempty"`
+	StartedAt      time.Time            `json:"startedAt"`
+	UpdatedAt      time.Time            `json:"updatedAt"`
+	CompletedAt    *time.Time           `json:"completedAt,omitempty"`
+}
+
+// InterviewSummary lists a session without its transcript
+type InterviewSummary struct {
+	ID             string     `json:"id"`
+	ChallengeID    int        `json:"challengeId"`
+	ChallengeTitle string     `json:"challengeTitle"`
+	Status         string     `json:"status"`
+	Turns          int        `json:"turns"`
+	OverallScore   *float64   `json:"overallScore,omitempty"`
+	StartedAt      time.Time  `json:"startedAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+}
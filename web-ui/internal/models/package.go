@@ -9,18 +9,18 @@ type Package struct {
 	Name             string                    `json:"name"`
 	DisplayName      string                    `json:"display_name"`
 	Description      string                    `json:"description"`
-	Version          string                    `json:"version"`
-	GitHubURL        string                    `json:"github_url"`
-	DocumentationURL string                    `json:"documentation_url"`
-	Stars            int                       `json:"stars"`
-	Category         string                    `json:"category"`
-	Difficulty       string                    `json:"difficulty"`
-	Prerequisites    []string                  `json:"prerequisites"`
+	Version          string                    `json:"version" schema:"optional"`
+	GitHubURL        string                    `json:"github_url" schema:"optional"`
+	DocumentationURL string                    `json:"documentation_url" schema:"optional"`
+	Stars            int                       `json:"stars" schema:"optional"`
+	Category         string                    `json:"category" schema:"optional"`
+	Difficulty       string                    `json:"difficulty" schema:"optional"`
+	Prerequisites    []string                  `json:"prerequisites" schema:"optional"`
 	LearningPath     []string                  `json:"learning_path"`
-	Tags             []string                  `json:"tags"`
-	EstimatedTime    string                    `json:"estimated_time"`
-	RealWorldUsage   []string                  `json:"real_world_usage"`
-	ChallengeDetails map[string]*ChallengeInfo `json:"challenge_details,omitempty"` // Dynamic challenge metadata
+	Tags             []string                  `json:"tags" schema:"optional"`
+	EstimatedTime    string                    `json:"estimated_time" schema:"optional"`
+	RealWorldUsage   []string                  `json:"real_world_usage" schema:"optional"`
+	ChallengeDetails map[string]*ChallengeInfo `json:"challenge_details,omitempty" schema:"-"` // Dynamic challenge metadata
 }
 
 // ChallengeInfo contains metadata about each challenge in the learning path
@@ -42,18 +42,31 @@ type ChallengeInfo struct {
 // ChallengeMetadata represents metadata that can be loaded from challenge directories
 type ChallengeMetadata struct {
 	Title               string   `json:"title"`
-	Description         string   `json:"description"`
+	Description         string   `json:"description" schema:"optional"`
 	ShortDescription    string   `json:"short_description"` // Brief description for cards
-	Difficulty          string   `json:"difficulty"`
+	Difficulty          string   `json:"difficulty" schema:"enum=Beginner|Intermediate|Advanced"`
 	EstimatedTime       string   `json:"estimated_time"`
 	LearningObjectives  []string `json:"learning_objectives"`
 	Prerequisites       []string `json:"prerequisites"`
 	Tags                []string `json:"tags"`
-	RealWorldConnection string   `json:"real_world_connection"`
-	Requirements        []string `json:"requirements"`
-	BonusPoints         []string `json:"bonus_points"`
-	Icon                string   `json:"icon,omitempty"`
-	Order               int      `json:"order"`
+	RealWorldConnection string   `json:"real_world_connection" schema:"optional"`
+	Requirements        []string `json:"requirements" schema:"optional"`
+	BonusPoints         []string `json:"bonus_points" schema:"optional"`
+	Icon                string   `json:"icon,omitempty" schema:"optional"`
+	Order               int      `json:"order" schema:"optional"`
+
+	Translations map[string]*MetadataTranslation `json:"translations,omitempty" schema:"optional"` // by language, e.g. "es"
+}
+
+// MetadataTranslation holds the translated text fields of a challenge's metadata.json
+type MetadataTranslation struct {
+	Title               string   `json:"title,omitempty"`
+	Description         string   `json:"description,omitempty"`
+	ShortDescription    string   `json:"short_description,omitempty"`
+	LearningObjectives  []string `json:"learning_objectives,omitempty"`
+	RealWorldConnection string   `json:"real_world_connection,omitempty"`
+	Requirements        []string `json:"requirements,omitempty"`
+	BonusPoints         []string `json:"bonus_points,omitempty"`
 }
 
 // PackageChallenge represents a challenge specific to a package
@@ -67,6 +80,7 @@ type PackageChallenge struct {
 	LearningObjectives  []string `json:"learning_objectives"`
 	Template            string   `json:"template"`
 	TestFile            string   `json:"testFile"`
+	SuiteVersion        string   `json:"suiteVersion,omitempty"` // content hash of TestFile
 	LearningMaterials   string   `json:"learningMaterials"`
 	Hints               string   `json:"hints"`
 	Requirements        []string `json:"requirements"`
@@ -78,6 +92,23 @@ type PackageChallenge struct {
 	Icon                string   `json:"icon,omitempty"`
 	Order               int      `json:"order"`
 	Status              string   `json:"status,omitempty"` // "available", "coming-soon", etc.
+
+	// Language of the content; Untranslated lists the parts shown in English instead
+	Language     string                           `json:"language,omitempty"`
+	Untranslated []string                         `json:"untranslated,omitempty"`
+	Translations map[string]*ChallengeTranslation `json:"-"` // by language, e.g. "es"
+}
+
+// ChallengePackage describes the package behind a Challenge converted from a package
+// challenge, so features written for classic challenges (execution, AI) know about it
+type ChallengePackage struct {
+	Name                string   `json:"name"`         // e.g., "gin"
+	DisplayName         string   `json:"display_name"` // e.g., "Gin Web Framework"
+	Version             string   `json:"version"`
+	ChallengeID         string   `json:"challenge_id"` // e.g., "challenge-1-basic-routing"
+	LearningObjectives  []string `json:"learning_objectives"`
+	Requirements        []string `json:"requirements"`
+	RealWorldConnection string   `json:"real_world_connection"`
 }
 
 // PackageSubmission represents a user's submitted solution for a package challenge
@@ -117,6 +148,7 @@ type PackageScoreboardEntry struct {
 	TestsPassed int       `json:"tests_passed"`
 	TestsTotal  int       `json:"tests_total"`
 	IsSponsor   bool      `json:"isSponsor"`
+	StaleCount  int       `json:"staleCount,omitempty"` // completions graded against an older test suite
 }
 
 // Type aliases for collections
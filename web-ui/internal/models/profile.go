@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// UserProfile aggregates a user's progress across classic and package challenges
+type UserProfile struct {
+	Username     string                   `json:"username"`
+	IsSponsor    bool                     `json:"isSponsor"`
+	MainRank     int                      `json:"mainRank"` // 0 when unranked
+	Achievement  string                   `json:"achievement"`
+	Achievements []string                 `json:"achievements"`
+	Classic      ClassicProgress          `json:"classic"`
+	Packages     []PackageProfileProgress `json:"packages"`
+}
+
+// ClassicProgress summarizes a user's classic challenge results
+type ClassicProgress struct {
+	Solved         int                 `json:"solved"`
+	Attempted      int                 `json:"attempted"`
+	Total          int                 `json:"total"`
+	CompletionRate float64             `json:"completionRate"`
+	AverageScore   int                 `json:"averageScore"` // over the challenges with a score
+	Challenges     []ChallengeProgress `json:"challenges"`
+}
+
+// ChallengeProgress is a user's status on a single classic challenge
+type ChallengeProgress struct {
+	ID         int        `json:"id"`
+	Title      string     `json:"title"`
+	Difficulty string     `json:"difficulty"`
+	Attempted  bool       `json:"attempted"`
+	Solved     bool       `json:"solved"`
+	Score      *int       `json:"score,omitempty"` // 0..100 from the scoreboard; nil without a scoreboard row
+	SolvedAt   *time.Time `json:"solvedAt,omitempty"`
+}
+
+// PackageProfileProgress summarizes a user's progress in one package learning path
+type PackageProfileProgress struct {
+	Name        string                     `json:"name"`
+	DisplayName string                     `json:"displayName"`
+	Rank        int                        `json:"rank"` // 0 when unranked
+	Completed   int                        `json:"completed"`
+	Total       int                        `json:"total"`
+	Challenges  []PackageChallengeProgress `json:"challenges"`
+}
+
+// PackageChallengeProgress is a user's status on a single package challenge
+type PackageChallengeProgress struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Completed bool       `json:"completed"`
+	Score     *int       `json:"score,omitempty"` // 0..100 from the package scoreboard; nil without a scoreboard row
+	SolvedAt  *time.Time `json:"solvedAt,omitempty"`
+}
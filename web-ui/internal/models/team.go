@@ -0,0 +1,54 @@
+package models
+
+// Team is a named group of users, defined in teams.json or through the admin API
+type Team struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Members     []string `json:"members"`
+}
+
+// TeamsFile is the on-disk layout of teams.json
+type TeamsFile struct {
+	Teams []*Team `json:"teams"`
+}
+
+// TeamLeaderboardEntry is one team's aggregate on a classic or package leaderboard
+type TeamLeaderboardEntry struct {
+	TeamID           string  `json:"teamId"`
+	TeamName         string  `json:"teamName"`
+	MemberCount      int     `json:"memberCount"`
+	ActiveMembers    int     `json:"activeMembers"`    // members with at least one completion
+	TotalCompleted   int     `json:"totalCompleted"`   // sum of member completions
+	UniqueCompleted  int     `json:"uniqueCompleted"`  // challenges solved by at least one member
+	AveragePerMember float64 `json:"averagePerMember"` // TotalCompleted / MemberCount
+	CompletionRate   float64 `json:"completionRate"`   // TotalCompleted / (MemberCount * TotalChallenges)
+	TotalChallenges  int     `json:"totalChallenges"`
+	Rank             int     `json:"rank"`
+}
+
+// TeamMemberProgress is one member's row on the team dashboard
+type TeamMemberProgress struct {
+	Username         string         `json:"username"`
+	MainRank         int            `json:"mainRank"` // 0 when unranked
+	ClassicSolved    map[int]bool   `json:"classicSolved"`
+	ClassicCount     int            `json:"classicCount"`
+	ClassicStuck     []int          `json:"classicStuck"` // attempted but not all tests passed
+	PackageCompleted map[string]int `json:"packageCompleted"`
+	PackageStuck     []string       `json:"packageStuck"` // "pkg/challenge" with a partial scoreboard result
+	Stuck            bool           `json:"stuck"`
+	Inactive         bool           `json:"inactive"` // no completions anywhere
+}
+
+// TeamDashboard shows who in a team solved what and who is stuck
+type TeamDashboard struct {
+	Team               *Team                           `json:"team"`
+	Members            []TeamMemberProgress            `json:"members"`
+	ClassicChallenges  []int                           `json:"classicChallenges"`
+	ClassicSummary     TeamLeaderboardEntry            `json:"classicSummary"`
+	PackageSummaries   map[string]TeamLeaderboardEntry `json:"packageSummaries"`
+	ChallengeSolvers   map[int][]string                `json:"challengeSolvers"` // challenge -> members who solved it
+	StuckMembers       []string                        `json:"stuckMembers"`
+	InactiveMembers    []string                        `json:"inactiveMembers"`
+	UnsolvedByEveryone []int                           `json:"unsolvedByEveryone"`
+}
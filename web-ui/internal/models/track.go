@@ -0,0 +1,52 @@
+package models
+
+// Track challenge status values for one user
+const (
+	TrackStatusSolved    = "solved"    // all tests passed
+	TrackStatusAvailable = "available" // every prerequisite is solved
+	TrackStatusLocked    = "locked"    // some prerequisites are unsolved
+)
+
+// Track is a curated sequence of classic challenges on one topic
+type Track struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Challenges  []int  `json:"challenges"` // in the suggested order
+}
+
+// TracksFile is the on-disk layout of tracks.json
+type TracksFile struct {
+	Tracks []*Track `json:"tracks"`
+}
+
+// TrackChallenge is one challenge of a track and where a user stands on it
+type TrackChallenge struct {
+	ID            int    `json:"id"`
+	Title         string `json:"title"`
+	Difficulty    string `json:"difficulty"`
+	Prerequisites []int  `json:"prerequisites,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Missing       []int  `json:"missingPrerequisites,omitempty"` // unsolved prerequisites of a locked challenge
+}
+
+// TrackProgress is a user's progress through a track
+type TrackProgress struct {
+	Track      *Track           `json:"track"`
+	Username   string           `json:"username,omitempty"`
+	Challenges []TrackChallenge `json:"challenges"`
+	Solved     int              `json:"solved"`
+	Total      int              `json:"total"`
+	Progress   int              `json:"progress"`       // percentage of challenges solved
+	Next       *TrackChallenge  `json:"next,omitempty"` // first available challenge in track order
+}
+
+// Recommendation suggests an unsolved challenge whose prerequisites are solved
+type Recommendation struct {
+	ChallengeID int      `json:"challengeId"`
+	Title       string   `json:"title"`
+	Difficulty  string   `json:"difficulty"`
+	Tracks      []string `json:"tracks,omitempty"` // IDs of the tracks containing it
+	Unlocks     int      `json:"unlocks"`          // challenges that list it as a prerequisite
+	Reason      string   `json:"reason"`
+}
@@ -3,11 +3,9 @@ package server
 import (
 	"embed"
 	"encoding/json"
-	"fmt"
 	"io/fs"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 
 	"web-ui/internal/handlers"
@@ -16,33 +14,15 @@ import (
 
 // Server represents the web server with all its dependencies
 type Server struct {
-	content           embed.FS
-	challengeService  *services.ChallengeService
-	scoreboardService *services.ScoreboardService
-	userService       *services.UserService
-	executionService  *services.ExecutionService
-	packageService    *services.PackageService
-	aiService         *services.AIService
+	content  embed.FS
+	services handlers.Services
 }
 
 // NewServer creates a new server instance
-func NewServer(
-	content embed.FS,
-	challengeService *services.ChallengeService,
-	scoreboardService *services.ScoreboardService,
-	userService *services.UserService,
-	executionService *services.ExecutionService,
-	packageService *services.PackageService,
-	aiService *services.AIService,
-) *Server {
+func NewServer(content embed.FS, deps handlers.Services) *Server {
 	return &Server{
-		content:           content,
-		challengeService:  challengeService,
-		scoreboardService: scoreboardService,
-		userService:       userService,
-		executionService:  executionService,
-		packageService:    packageService,
-		aiService:         aiService,
+		content:  content,
+		services: deps,
 	}
 }
 
@@ -54,26 +34,26 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 	s.setupStaticFiles(mux)
 
 	// Initialize handlers
-	apiHandler := handlers.NewAPIHandler(
-		s.challengeService,
-		s.scoreboardService,
-		s.userService,
-		s.executionService,
-		s.packageService,
-		s.aiService,
-	)
+	apiHandler := handlers.NewAPIHandler(s.services)
 
 	webHandler := handlers.NewWebHandler(
 		s.content,
-		s.challengeService,
-		s.scoreboardService,
-		s.userService,
-		s.packageService,
+		s.services.ChallengeService,
+		s.services.ScoreboardService,
+		s.services.UserService,
+		s.services.PackageService,
+		s.services.LeaderboardService,
+		s.services.ProfileService,
+		s.services.TeamService,
+		s.services.CohortService,
 	)
 
 	// API routes
 	mux.HandleFunc("/api/challenges", apiHandler.GetAllChallenges)
 	mux.HandleFunc("/api/challenges/", apiHandler.GetChallengeByID)
+	mux.HandleFunc("/api/search", apiHandler.Search)
+	mux.HandleFunc("/api/markdown", apiHandler.RenderMarkdown)
+	mux.HandleFunc("/api/languages", apiHandler.GetLanguages)
 	mux.HandleFunc("/api/submissions", apiHandler.HandleSubmissions)
 	mux.HandleFunc("/api/scoreboard/", apiHandler.GetScoreboard)
 	mux.HandleFunc("/api/run", apiHandler.RunCode)
@@ -82,6 +62,37 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 	mux.HandleFunc("/api/git-username", apiHandler.GetGitUsername)
 	mux.HandleFunc("/api/main-scoreboard-rank", apiHandler.GetMainScoreboardRank)
 	mux.HandleFunc("/api/main-leaderboard", apiHandler.GetMainLeaderboard)
+	mux.HandleFunc("/api/users/", apiHandler.GetUserProfile)
+
+	// Team API routes
+	mux.HandleFunc("/api/teams", apiHandler.GetTeams)
+	mux.HandleFunc("/api/teams/", apiHandler.GetTeamDashboard)
+	mux.HandleFunc("/api/team-leaderboard", apiHandler.GetTeamLeaderboard)
+
+	// Cohort API routes
+	mux.HandleFunc("/api/cohorts", apiHandler.GetCohorts)
+	mux.HandleFunc("/api/cohorts/", apiHandler.HandleCohort)
+	mux.HandleFunc("/api/tracks", apiHandler.GetTracks)
+	mux.HandleFunc("/api/tracks/", apiHandler.HandleTrack)
+	mux.HandleFunc("/api/recommendations", apiHandler.GetRecommendations)
+	mux.HandleFunc("/api/reference", apiHandler.HandleReference)
+
+	// Admin API routes (require ADMIN_TOKEN)
+	mux.HandleFunc("/api/admin/teams", apiHandler.AdminTeams)
+	mux.HandleFunc("/api/admin/teams/", apiHandler.AdminTeams)
+	mux.HandleFunc("/api/admin/cohorts", apiHandler.AdminCohorts)
+	mux.HandleFunc("/api/admin/cohorts/", apiHandler.AdminCohorts)
+	mux.HandleFunc("/api/admin/status", apiHandler.AdminStatus)
+	mux.HandleFunc("/api/admin/executions", apiHandler.AdminExecutions)
+	mux.HandleFunc("/api/admin/reload", apiHandler.AdminReload)
+	mux.HandleFunc("/api/admin/cache/invalidate", apiHandler.AdminInvalidateCache)
+	mux.HandleFunc("/api/admin/users", apiHandler.AdminUsers)
+	mux.HandleFunc("/api/admin/users/", apiHandler.AdminUsers)
+	mux.HandleFunc("/api/admin/debug/sponsors", apiHandler.AdminSponsorsDebug)
+	mux.HandleFunc("/api/admin/ai/debug", apiHandler.AdminAIDebug)
+	mux.HandleFunc("/api/admin/ai/usage", apiHandler.AdminAIUsage)
+	mux.HandleFunc("/api/admin/ai/prompts", apiHandler.AdminAIPrompts)
+	mux.HandleFunc("/api/admin/ai/author", apiHandler.AdminAIAuthor)
 
 	// Package challenge API routes
 	mux.HandleFunc("/api/package-leaderboard", apiHandler.GetPackageLeaderboard)
@@ -92,7 +103,12 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 	mux.HandleFunc("/api/ai/code-review", apiHandler.AICodeReview)
 	mux.HandleFunc("/api/ai/interviewer-questions", apiHandler.AIInterviewerQuestions)
 	mux.HandleFunc("/api/ai/code-hint", apiHandler.AICodeHint)
-	mux.HandleFunc("/api/ai/debug", apiHandler.AIDebugResponse)
+	mux.HandleFunc("/api/ai/edge-cases", apiHandler.AIEdgeCases)
+	mux.HandleFunc("/api/ai/code-review/stream", apiHandler.AICodeReviewStream)
+	mux.HandleFunc("/api/ai/interviewer-questions/stream", apiHandler.AIInterviewerQuestionsStream)
+	mux.HandleFunc("/api/ai/code-hint/stream", apiHandler.AICodeHintStream)
+	mux.HandleFunc("/api/interviews", apiHandler.Interviews)
+	mux.HandleFunc("/api/interviews/", apiHandler.HandleInterview)
 
 	// GitHub webhook route
 	mux.HandleFunc("/webhook/github", apiHandler.GitHubWebhookHandler)
@@ -108,43 +124,38 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 		})
 	})
 
-	// Debug route for sponsors
-	mux.HandleFunc("/api/debug/sponsors", apiHandler.GetSponsorsDebug)
 	mux.HandleFunc("/api/ai/status", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		provider := os.Getenv("AI_PROVIDER")
-		if provider == "" {
-			provider = "gemini"
+		// Probe each provider rather than trusting the environment; ?refresh=1 skips
+		// the cached probe results
+		features := s.services.AIService.Status(r.Context(), r.URL.Query().Get("refresh") == "1")
+
+		status, available, configured := "ready", 0, 0
+		for _, feature := range features {
+			if feature.Available {
+				available++
+			}
+			for _, provider := range feature.Providers {
+				if provider.Ready {
+					configured++
+					break
+				}
+			}
 		}
-		var apiKey string
-		switch strings.ToLower(provider) {
-		case "openai":
-			apiKey = os.Getenv("OPENAI_API_KEY")
-		case "claude":
-			apiKey = os.Getenv("CLAUDE_API_KEY")
-		default:
-			apiKey = os.Getenv("GEMINI_API_KEY")
+		switch {
+		case configured == 0:
+			status = "not_configured"
+		case available == 0:
+			status = "unavailable"
+		case available < len(features):
+			status = "degraded"
 		}
 
-		// Check if API key looks valid
-		hasValidKey := apiKey != "" && !strings.Contains(apiKey, "Example") && len(apiKey) > 30
-
-		response := map[string]interface{}{
-			"provider":    provider,
-			"status":      "ready",
-			"message":     fmt.Sprintf("AI provider set to: %s", provider),
-			"has_api_key": apiKey != "",
-			"key_length":  len(apiKey),
-			"key_preview": func() string {
-				if len(apiKey) > 10 {
-					return apiKey[:10] + "..."
-				}
-				return apiKey + "..."
-			}(),
-			"is_example_key": strings.Contains(apiKey, "Example"),
-			"has_valid_key":  hasValidKey,
-		}
-		json.NewEncoder(w).Encode(response)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"provider": s.services.AIService.Provider(services.FeatureReview).Name(),
+			"status":   status,
+			"features": features,
+		})
 	})
 
 	// Web routes
@@ -153,6 +164,12 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 	mux.HandleFunc("/interview", webHandler.InterviewPage)
 	mux.HandleFunc("/scoreboard", webHandler.ScoreboardPage)
 	mux.HandleFunc("/scoreboard/", webHandler.ScoreChallengeHandler)
+	mux.HandleFunc("/users/", webHandler.UserProfilePage)
+	mux.HandleFunc("/teams", webHandler.TeamsPage)
+	mux.HandleFunc("/teams/", webHandler.TeamDashboardPage)
+	mux.HandleFunc("/cohorts", webHandler.CohortsPage)
+	mux.HandleFunc("/cohorts/", webHandler.CohortProgressPage)
+	mux.HandleFunc("/admin", webHandler.AdminPage)
 	mux.HandleFunc("/packages/", func(w http.ResponseWriter, r *http.Request) {
 		// Route to appropriate handler based on URL structure
 		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
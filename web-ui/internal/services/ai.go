@@ -1,709 +1,827 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"os"
+	"log"
 	"strings"
-	"time"
+	"sync"
 
 	"web-ui/internal/models"
 )
 
-// LLMProvider represents different LLM providers
-type LLMProvider string
-
-const (
-	ProviderGemini LLMProvider = "gemini"
-	ProviderOpenAI LLMProvider = "openai"
-	ProviderClaude LLMProvider = "claude"
-)
-
-// LLMConfig holds configuration for different LLM providers
-type LLMConfig struct {
-	Provider    LLMProvider
-	APIKey      string
-	Model       string
-	BaseURL     string
-	MaxTokens   int
-	Temperature float64
-}
-
-// AIService handles AI-powered code review and interview simulation
+// AIService handles AI-powered code review and interview simulation.
+// Each feature (review, hint, questions) has its own provider so they can use
+// different backends and models.
+// Every call is made on behalf of a user and counted against their quota.
 type AIService struct {
-	config     LLMConfig
-	httpClient *http.Client
-}
-
-// NewAIService creates a new AI service with the specified provider
-func NewAIService() *AIService {
-	cfgProvider := getProviderFromEnv()
-	config := LLMConfig{
-		Provider:    cfgProvider,
-		APIKey:      getAPIKeyFromEnvFor(cfgProvider),
-		Model:       getModelFromEnv(),
-		MaxTokens:   4000, // Increased for longer responses
-		Temperature: 0.3,
-	}
-
-	// Set provider-specific defaults
-	switch config.Provider {
-	case ProviderGemini:
-		config.BaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
-		if config.Model == "" {
-			config.Model = "gemini-2.5-flash"
-		}
-	case ProviderOpenAI:
-		config.BaseURL = "https://api.openai.com/v1/chat/completions"
-		if config.Model == "" {
-			// Use a modern default that supports structured outputs well
-			config.Model = "gpt-4o-mini"
-		}
-	case ProviderClaude:
-		config.BaseURL = "https://api.anthropic.com/v1/messages"
-		if config.Model == "" {
-			config.Model = "claude-3-sonnet-20240229"
-		}
-	default:
-		// Default to Gemini if provider is not recognized
-		config.Provider = ProviderGemini
-		config.BaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
-		if config.Model == "" {
-			config.Model = "gemini-2.5-flash"
-		}
+	providers  map[AIFeature]LLMProvider
+	configs    map[AIFeature]LLMConfig // primary provider configuration of each feature
+	usage      *AIUsageService
+	executor   *ExecutionService // runs submissions so reviews see real test results
+	references *ReferenceService // solutions generated edge-case tests are checked against
+	prompts    *PromptStore
+
+	probeMu sync.Mutex
+	probes  map[string]probeResult // last status probe per provider endpoint
+}
+
+// NewAIService creates a new AI service configured from the environment. Each feature
+// gets a chain of its provider and fallbacks; features share circuit breakers for
+// providers they have in common.
+func NewAIService(usage *AIUsageService, executor *ExecutionService, references *ReferenceService) *AIService {
+	breakers := newBreakerSet()
+	providers := make(map[AIFeature]LLMProvider)
+	configs := make(map[AIFeature]LLMConfig)
+	for _, feature := range AIFeatures {
+		chain := LoadLLMConfigs(feature)
+		providers[feature] = newProviderChain(chain, breakers)
+		configs[feature] = chain[0]
 	}
-
 	return &AIService{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		providers:  providers,
+		configs:    configs,
+		usage:      usage,
+		executor:   executor,
+		references: references,
+		prompts:    NewPromptStore(),
+		probes:     make(map[string]probeResult),
 	}
 }
 
-// Helper functions to get configuration from environment
-func getProviderFromEnv() LLMProvider {
-	provider := strings.ToLower(os.Getenv("AI_PROVIDER"))
-	switch provider {
-	case "gemini":
-		return ProviderGemini
-	case "openai":
-		return ProviderOpenAI
-	case "claude":
-		return ProviderClaude
-	default:
-		return ProviderGemini // Default to Gemini
-	}
+// Provider returns the provider used for a feature
+func (ai *AIService) Provider(feature AIFeature) LLMProvider {
+	return ai.providers[feature]
 }
 
-func getAPIKeyFromEnvFor(provider LLMProvider) string {
-	switch provider {
-	case ProviderGemini:
-		if key := os.Getenv("GEMINI_API_KEY"); key != "" {
-			return key
-		}
-	case ProviderOpenAI:
-		if key := os.Getenv("OPENAI_API_KEY"); key != "" {
-			return key
-		}
-	case ProviderClaude:
-		if key := os.Getenv("CLAUDE_API_KEY"); key != "" {
-			return key
-		}
-	}
-	// Fall back to generic AI_API_KEY
-	return os.Getenv("AI_API_KEY")
+// Prompts returns the prompt templates
+func (ai *AIService) Prompts() *PromptStore {
+	return ai.prompts
+}
+
+// Usage returns the quota and usage tracker
+func (ai *AIService) Usage() *AIUsageService {
+	return ai.usage
 }
 
-func getModelFromEnv() string {
-	return os.Getenv("AI_MODEL")
+// IsQuotaError reports whether err means a daily AI quota was exhausted
+func IsQuotaError(err error) bool {
+	var quotaErr *QuotaError
+	return errors.As(err, &quotaErr)
 }
 
 // AICodeReview represents the response from AI code review
 type AICodeReview struct {
-	OverallScore        float64            `json:"overall_score"`        // 0-100 score
-	Issues              []CodeIssue        `json:"issues"`               // Code quality issues
-	Suggestions         []CodeSuggestion   `json:"suggestions"`          // Improvement suggestions
-	InterviewerFeedback string             `json:"interviewer_feedback"` // What an interviewer would say
-	FollowUpQuestions   []string           `json:"follow_up_questions"`  // Questions to ask the candidate
-	Complexity          ComplexityAnalysis `json:"complexity"`           // Time/space complexity analysis
-	ReadabilityScore    float64            `json:"readability_score"`    // 0-100 readability score
-	TestCoverage        string             `json:"test_coverage"`        // Coverage assessment
+	OverallScore        float64            `json:"overall_score" schema:"min=0,max=100"`     // 0-100 score
+	Issues              []CodeIssue        `json:"issues"`                                   // Code quality issues
+	Suggestions         []CodeSuggestion   `json:"suggestions"`                              // Improvement suggestions
+	InterviewerFeedback string             `json:"interviewer_feedback"`                     // What an interviewer would say
+	FollowUpQuestions   []string           `json:"follow_up_questions"`                      // Questions to ask the candidate
+	Complexity          ComplexityAnalysis `json:"complexity"`                               // Time/space complexity analysis
+	ReadabilityScore    float64            `json:"readability_score" schema:"min=0,max=100"` // 0-100 readability score
+	TestCoverage        string             `json:"test_coverage"`                            // Coverage assessment
+	PromptVersion       string             `json:"prompt_version,omitempty" schema:"-"`      // Prompt templates the review was generated from
+	Repaired            bool               `json:"repaired,omitempty" schema:"-"`            // The first response failed validation and the model corrected it
+	Analysis            *CodeAnalysis      `json:"analysis,omitempty" schema:"-"`            // Test, vet and race results the review is based on
 }
 
 // CodeIssue represents a specific issue in the code
 type CodeIssue struct {
-	Type        string `json:"type"`        // "bug", "performance", "style", "logic"
-	Severity    string `json:"severity"`    // "low", "medium", "high", "critical"
-	LineNumber  int    `json:"line_number"` // Approximate line number
-	Description string `json:"description"` // Human-readable description
-	Solution    string `json:"solution"`    // Suggested fix
+	Type        string `json:"type" schema:"enum=bug|performance|style|logic"`  // "bug", "performance", "style", "logic"
+	Severity    string `json:"severity" schema:"enum=low|medium|high|critical"` // "low", "medium", "high", "critical"
+	LineNumber  int    `json:"line_number" schema:"min=0"`                      // Line in the submitted code, 0 when unknown
+	Description string `json:"description"`                                     // Human-readable description
+	Solution    string `json:"solution"`                                        // Suggested fix
+
+	Snippet      string `json:"snippet,omitempty" schema:"optional"`      // The code at LineNumber
+	LineVerified bool   `json:"line_verified" schema:"-"`                 // LineNumber was checked against the code or a tool report
+	RelatedTest  string `json:"related_test,omitempty" schema:"optional"` // The failing test this issue explains
+	Source       string `json:"source,omitempty" schema:"-"`              // "ai", "test", "compiler", "vet" or "race"
 }
 
 // CodeSuggestion represents an improvement suggestion
 type CodeSuggestion struct {
-	Category    string `json:"category"`    // "optimization", "best_practice", "alternative"
-	Priority    string `json:"priority"`    // "low", "medium", "high"
-	Description string `json:"description"` // What to improve
-	Example     string `json:"example"`     // Code example if applicable
+	Category    string `json:"category" schema:"enum=optimization|best_practice|alternative"` // "optimization", "best_practice", "alternative"
+	Priority    string `json:"priority" schema:"enum=low|medium|high"`                        // "low", "medium", "high"
+	Description string `json:"description"`                                                   // What to improve
+	Example     string `json:"example" schema:"optional"`                                     // Code example if applicable
 }
 
 // ComplexityAnalysis represents time/space complexity analysis
 type ComplexityAnalysis struct {
-	TimeComplexity    string `json:"time_complexity"`    // "O(n)", "O(log n)", etc.
-	SpaceComplexity   string `json:"space_complexity"`   // "O(1)", "O(n)", etc.
-	CanOptimize       bool   `json:"can_optimize"`       // Whether it can be optimized
-	OptimizedApproach string `json:"optimized_approach"` // How to optimize
-}
-
-// Universal request/response structures for different LLM providers
-
-// GeminiRequest represents the request structure for Gemini API
-type GeminiRequest struct {
-	Contents         []GeminiContent         `json:"contents"`
-	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
-}
-
-type GeminiContent struct {
-	Parts []GeminiPart `json:"parts"`
-}
-
-type GeminiPart struct {
-	Text string `json:"text"`
-}
-
-type GeminiGenerationConfig struct {
-	Temperature     *float64 `json:"temperature,omitempty"`
-	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"`
-	ResponseMIME    string   `json:"responseMimeType,omitempty"`
-}
-
-// GeminiResponse represents the response from Gemini API
-type GeminiResponse struct {
-	Candidates []GeminiCandidate `json:"candidates"`
-	Error      *GeminiError      `json:"error,omitempty"`
-}
-
-type GeminiCandidate struct {
-	Content GeminiContent `json:"content"`
-}
-
-type GeminiError struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
-}
-
-// ClaudeRequest represents the request structure for Claude API
-type ClaudeRequest struct {
-	Model       string          `json:"model"`
-	Messages    []ClaudeMessage `json:"messages"`
-	MaxTokens   int             `json:"max_tokens"`
-	Temperature float64         `json:"temperature"`
+	TimeComplexity    string `json:"time_complexity"`                      // "O(n)", "O(log n)", etc.
+	SpaceComplexity   string `json:"space_complexity"`                     // "O(1)", "O(n)", etc.
+	CanOptimize       bool   `json:"can_optimize"`                         // Whether it can be optimized
+	OptimizedApproach string `json:"optimized_approach" schema:"optional"` // How to optimize
 }
 
-type ClaudeMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// ReviewCode performs AI-powered code review
+func (ai *AIService) ReviewCode(user, code string, challenge *models.Challenge, reviewContext string) (*AICodeReview, error) {
+	return ai.StreamReview(context.Background(), user, code, challenge, reviewContext, nil)
 }
 
-// ClaudeResponse represents the response from Claude API
-type ClaudeResponse struct {
-	Content []ClaudeContent `json:"content"`
-	Error   *ClaudeError    `json:"error,omitempty"`
-}
+// StreamReview performs a code review, passing the raw JSON to onChunk as it is generated.
+// The review is parsed and validated once the stream completes. A nil onChunk makes a
+// regular request.
+func (ai *AIService) StreamReview(ctx context.Context, user, code string, challenge *models.Challenge, reviewContext string, onChunk func(string) error) (*AICodeReview, error) {
+	if !ai.providers[FeatureReview].Ready() {
+		return ai.missingKeyReview(), nil
+	}
 
-type ClaudeContent struct {
-	Text string `json:"text"`
-	Type string `json:"type"`
-}
+	analysis := ai.analyze(code, challenge)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	request, version, err := ai.buildCodeReviewPrompt(code, challenge, reviewContext, analysis)
+	if err != nil {
+		return groundReview(ai.unavailableReview(err), code, analysis), nil
+	}
 
-type ClaudeError struct {
-	Message string `json:"message"`
-	Type    string `json:"type"`
-}
+	response, err := ai.callLLM(ctx, user, FeatureReview, request, onChunk)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if IsQuotaError(err) {
+			return nil, err
+		}
+		return groundReview(ai.unavailableReview(err), code, analysis), nil
+	}
 
-// OpenAIRequest represents the request structure for OpenAI API
-type OpenAIRequest struct {
-	Model          string                `json:"model"`
-	Messages       []Message             `json:"messages"`
-	MaxTokens      int                   `json:"max_tokens"`
-	Temperature    float64               `json:"temperature"`
-	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+	review, err := ai.validatedReview(ctx, user, challenge, request, response)
+	if err != nil {
+		return nil, err
+	}
+	review.PromptVersion = version
+	return groundReview(review, code, analysis), nil
 }
 
-// Message represents a message in the OpenAI chat
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// analyze runs the submission so the review can be based on what actually happened.
+// It returns nil when no executor is configured.
+func (ai *AIService) analyze(code string, challenge *models.Challenge) *CodeAnalysis {
+	if ai.executor == nil || challenge.TestFile == "" {
+		return nil
+	}
+	return ai.executor.AnalyzeCode(code, challenge)
 }
 
-// OpenAIResponse represents the response from OpenAI API
-type OpenAIResponse struct {
-	Choices []Choice     `json:"choices"`
-	Error   *OpenAIError `json:"error,omitempty"`
+// missingKeyReview is returned when the review provider is not configured
+func (ai *AIService) missingKeyReview() *AICodeReview {
+	return &AICodeReview{
+		OverallScore:        0,
+		Issues:              []CodeIssue{},
+		Suggestions:         []CodeSuggestion{},
+		InterviewerFeedback: missingKeyMessage(FeatureReview, ai.configs[FeatureReview]),
+		FollowUpQuestions:   []string{"Would you like to set up AI code review?"},
+		Complexity: ComplexityAnalysis{
+			TimeComplexity:    "N/A",
+			SpaceComplexity:   "N/A",
+			CanOptimize:       false,
+			OptimizedApproach: "Set up your API key first",
+		},
+		ReadabilityScore: 0,
+		TestCoverage:     "API key required for AI analysis",
+	}
 }
 
-// Choice represents a choice in OpenAI response
-type Choice struct {
-	Message Message `json:"message"`
+// unavailableReview is returned when the provider call fails
+func (ai *AIService) unavailableReview(err error) *AICodeReview {
+	return &AICodeReview{
+		OverallScore:        0,
+		Issues:              []CodeIssue{},
+		Suggestions:         []CodeSuggestion{},
+		InterviewerFeedback: fmt.Sprintf("❌ AI service temporarily unavailable: %v. Please try again later.", err),
+		FollowUpQuestions:   []string{"Would you like to try again?"},
+		Complexity: ComplexityAnalysis{
+			TimeComplexity:    "N/A",
+			SpaceComplexity:   "N/A",
+			CanOptimize:       false,
+			OptimizedApproach: "API service temporarily unavailable",
+		},
+		ReadabilityScore: 0,
+		TestCoverage:     "AI service unavailable",
+	}
 }
 
-// OpenAIError represents an error from OpenAI API
-type OpenAIError struct {
-	Message string `json:"message"`
-	Type    string `json:"type"`
+// GetInterviewerQuestions generates follow-up questions based on code. promptVersion
+// identifies the prompt templates used, and is empty when no prompt was sent.
+func (ai *AIService) GetInterviewerQuestions(user, code string, challenge *models.Challenge, userProgress string) (questions []string, promptVersion string, err error) {
+	return ai.StreamInterviewerQuestions(context.Background(), user, code, challenge, userProgress, nil)
 }
 
-// ReviewCode performs AI-powered code review
-func (ai *AIService) ReviewCode(code string, challenge *models.Challenge, context string) (*AICodeReview, error) {
-
-	if ai.config.APIKey == "" {
-		return &AICodeReview{
-			OverallScore:        0,
-			Issues:              []CodeIssue{},
-			Suggestions:         []CodeSuggestion{},
-			InterviewerFeedback: "⚠️ AI features require an API key. Please add GEMINI_API_KEY to your .env file. Get your free key at: https://makersuite.google.com/app/apikey",
-			FollowUpQuestions:   []string{"Would you like to set up AI code review?"},
-			Complexity: ComplexityAnalysis{
-				TimeComplexity:    "N/A",
-				SpaceComplexity:   "N/A",
-				CanOptimize:       false,
-				OptimizedApproach: "Set up your API key first",
-			},
-			ReadabilityScore: 0,
-			TestCoverage:     "API key required for AI analysis",
-		}, nil
+// StreamInterviewerQuestions generates follow-up questions, passing the raw JSON to onChunk as it is generated
+func (ai *AIService) StreamInterviewerQuestions(ctx context.Context, user, code string, challenge *models.Challenge, userProgress string, onChunk func(string) error) (questions []string, promptVersion string, err error) {
+	if !ai.providers[FeatureQuestions].Ready() {
+		return []string{missingKeyMessage(FeatureQuestions, ai.configs[FeatureQuestions])}, "", nil
 	}
 
-	prompt := ai.buildCodeReviewPrompt(code, challenge, context)
-
-	response, err := ai.callLLMWithOpts(prompt, true /* expectJSON */)
+	request, version, err := ai.buildQuestionPrompt(code, challenge, userProgress)
 	if err != nil {
-		return &AICodeReview{
-			OverallScore:        0,
-			Issues:              []CodeIssue{},
-			Suggestions:         []CodeSuggestion{},
-			InterviewerFeedback: fmt.Sprintf("❌ AI service temporarily unavailable: %v. Please try again later.", err),
-			FollowUpQuestions:   []string{"Would you like to try again?"},
-			Complexity: ComplexityAnalysis{
-				TimeComplexity:    "N/A",
-				SpaceComplexity:   "N/A",
-				CanOptimize:       false,
-				OptimizedApproach: "API service temporarily unavailable",
-			},
-			ReadabilityScore: 0,
-			TestCoverage:     "AI service unavailable",
-		}, nil
+		return []string{fmt.Sprintf("❌ AI service unavailable: %v", err)}, "", nil
 	}
 
-	review, err := ai.parseAIResponse(response)
+	response, err := ai.callLLM(ctx, user, FeatureQuestions, request, onChunk)
 	if err != nil {
-		// This shouldn't happen anymore since parseAIResponse returns fallback instead of error
-		return ai.createFallbackReview("Unexpected parsing error", response), nil
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		if IsQuotaError(err) {
+			return nil, "", err
+		}
+		return []string{fmt.Sprintf("❌ AI service unavailable: %v", err)}, version, nil
 	}
 
-	return review, nil
+	return ai.parseQuestions(response), version, nil
 }
 
-// GetInterviewerQuestions generates follow-up questions based on code
-func (ai *AIService) GetInterviewerQuestions(code string, challenge *models.Challenge, userProgress string) ([]string, error) {
-	if ai.config.APIKey == "" {
-		return []string{"⚠️ AI features require an API key. Get your free key at: https://makersuite.google.com/app/apikey"}, nil
-	}
-
-	prompt := ai.buildQuestionPrompt(code, challenge, userProgress)
-
-	response, err := ai.callLLMWithOpts(prompt, true /* expectJSON */)
-	if err != nil {
-		return []string{fmt.Sprintf("❌ AI service unavailable: %v", err)}, nil
-	}
-
-	questions := ai.parseQuestions(response)
-	return questions, nil
+// GetCodeHint provides context-aware hints. promptVersion identifies the prompt templates
+// used, and is empty when no prompt was sent.
+func (ai *AIService) GetCodeHint(user, code string, challenge *models.Challenge, hintLevel int) (hint string, promptVersion string, err error) {
+	return ai.StreamCodeHint(context.Background(), user, code, challenge, hintLevel, nil)
 }
 
-// GetCodeHint provides context-aware hints
-func (ai *AIService) GetCodeHint(code string, challenge *models.Challenge, hintLevel int) (string, error) {
-	if ai.config.APIKey == "" {
-		return "⚠️ AI features require an API key. Get your free key at: https://makersuite.google.com/app/apikey", nil
+// StreamCodeHint provides a hint, passing text to onChunk as it is generated
+func (ai *AIService) StreamCodeHint(ctx context.Context, user, code string, challenge *models.Challenge, hintLevel int, onChunk func(string) error) (hint string, promptVersion string, err error) {
+	if !ai.providers[FeatureHint].Ready() {
+		return missingKeyMessage(FeatureHint, ai.configs[FeatureHint]), "", nil
 	}
 
-	prompt := ai.buildHintPrompt(code, challenge, hintLevel)
+	request, version, err := ai.buildHintPrompt(code, challenge, hintLevel)
+	if err != nil {
+		return fmt.Sprintf("❌ AI service unavailable: %v", err), "", nil
+	}
 
-	response, err := ai.callLLMWithOpts(prompt, false /* expectJSON */)
+	response, err := ai.callLLM(ctx, user, FeatureHint, request, onChunk)
 	if err != nil {
-		return fmt.Sprintf("❌ AI service unavailable: %v", err), nil
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+		if IsQuotaError(err) {
+			return "", "", err
+		}
+		return fmt.Sprintf("❌ AI service unavailable: %v", err), version, nil
 	}
 
-	return ai.parseHint(response), nil
+	return ai.parseHint(response), version, nil
 }
 
-// BuildCodeReviewPrompt exposes the prompt builder for debugging
-func (ai *AIService) BuildCodeReviewPrompt(code string, challenge *models.Challenge, context string) string {
-	return ai.buildCodeReviewPrompt(code, challenge, context)
+// BuildCodeReviewPrompt exposes the prompt builder for debugging. The code is run first,
+// exactly as for a real review.
+func (ai *AIService) BuildCodeReviewPrompt(code string, challenge *models.Challenge, context string) (request LLMRequest, promptVersion string, err error) {
+	return ai.buildCodeReviewPrompt(code, challenge, context, ai.analyze(code, challenge))
 }
 
-// CallLLMRaw calls the LLM and returns raw response for debugging
-func (ai *AIService) CallLLMRaw(prompt string) (string, error) {
-	return ai.callLLMWithOpts(prompt, true)
+// CallLLMRaw calls the review provider and returns raw response for debugging
+func (ai *AIService) CallLLMRaw(user string, request LLMRequest) (string, error) {
+	return ai.callLLM(context.Background(), user, FeatureReview, request, nil)
 }
 
-// buildCodeReviewPrompt creates the prompt for code review
-func (ai *AIService) buildCodeReviewPrompt(code string, challenge *models.Challenge, context string) string {
-	return fmt.Sprintf(`You are a senior Go interviewer. Respond ONLY with a single JSON object. Do NOT include markdown or code fences. All numeric fields must be JSON numbers, not strings.
-
-SCHEMA:
-{
-  "overall_score": integer (0..100),
-  "issues": [
-    {
-      "type": "bug|performance|style|logic",
-      "severity": "low|medium|high|critical",
-      "line_number": integer,
-      "description": string,
-      "solution": string
-    }
-  ],
-  "suggestions": [
-    {
-      "category": "optimization|best_practice|alternative",
-      "priority": "low|medium|high",
-      "description": string,
-      "example": string
-    }
-  ],
-  "interviewer_feedback": string,
-  "follow_up_questions": [string],
-  "complexity": {
-    "time_complexity": string,
-    "space_complexity": string,
-    "can_optimize": boolean,
-    "optimized_approach": string
-  },
-  "readability_score": integer (0..100),
-  "test_coverage": string
+// ValidateReviewResponse lists what is wrong with a raw review response, if anything
+func ValidateReviewResponse(response string) []string {
+	_, problems := decodeReview(response)
+	return problems
 }
 
-CHALLENGE: %s
-CONTEXT: %s
-
-CODE (Go):
-BEGIN_CODE
-%s
-END_CODE
-
-Focus on: (1) correctness and edge cases, (2) Go idioms, (3) performance, (4) readability, (5) interviewer follow-ups.`, challenge.Title, context, code)
+// buildCodeReviewPrompt renders the code review prompt from the problem statement, the
+// numbered code and the results of running it
+func (ai *AIService) buildCodeReviewPrompt(code string, challenge *models.Challenge, context string, analysis *CodeAnalysis) (LLMRequest, string, error) {
+	return ai.reviewRequest(PromptReview, PromptData{
+		Challenge:    challenge,
+		Header:       challengeHeader(challenge),
+		Description:  truncateDescription(challenge.Description),
+		Code:         code,
+		NumberedCode: numberLines(code),
+		SolutionFile: SolutionFile,
+		TestResults:  formatAnalysis(analysis),
+		Context:      context,
+	})
 }
 
-// buildQuestionPrompt creates the prompt for generating interview questions
-func (ai *AIService) buildQuestionPrompt(code string, challenge *models.Challenge, userProgress string) string {
-	return fmt.Sprintf(`You are a technical interviewer. Respond ONLY with a JSON array of strings. No markdown, no prose outside the array.
-
-CHALLENGE: %s
-USER PROGRESS: %s
+// challengeHeader names the challenge in a prompt. For package challenges it adds the
+// package and version the solution must use, the learning objectives, the requirements
+// and the real-world connection, so feedback is about using that package well.
+func challengeHeader(challenge *models.Challenge) string {
+	header := "CHALLENGE: " + challenge.Title
+	pkg := challenge.Package
+	if pkg == nil {
+		return header
+	}
 
-CODE (Go):
-BEGIN_CODE
-%s
-END_CODE
+	var b strings.Builder
+	b.WriteString(header)
+	name := pkg.Name
+	if pkg.DisplayName != "" {
+		name = fmt.Sprintf("%s (%s)", pkg.Name, pkg.DisplayName)
+	}
+	if pkg.Version != "" {
+		name += " " + pkg.Version
+	}
+	fmt.Fprintf(&b, "\nPACKAGE: %s. The solution is expected to use this package idiomatically; judge it against the package's conventions and APIs at this version.", name)
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:", title)
+		for _, item := range items {
+			fmt.Fprintf(&b, "\n- %s", item)
+		}
+	}
+	writeList("LEARNING OBJECTIVES", pkg.LearningObjectives)
+	writeList("REQUIREMENTS", pkg.Requirements)
+	if pkg.RealWorldConnection != "" {
+		fmt.Fprintf(&b, "\nREAL-WORLD CONNECTION: %s", pkg.RealWorldConnection)
+	}
+	return b.String()
+}
 
-Generate 3-5 follow-up questions that probe: deeper understanding, edge cases, optimizations, Go-specific concepts, and trade-offs.`, challenge.Title, userProgress, code)
+// numberLines prefixes each line with its 1-based number so reported line numbers are exact
+func numberLines(code string) string {
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%4d| %s\n", i+1, line)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
 }
 
-// buildHintPrompt creates the prompt for generating hints
-func (ai *AIService) buildHintPrompt(code string, challenge *models.Challenge, hintLevel int) string {
-	hintTypes := map[int]string{
-		1: "a subtle nudge in the right direction",
-		2: "a more direct hint about the approach",
-		3: "a specific suggestion about implementation",
-		4: "a detailed explanation with partial code example",
+// formatAnalysis summarizes test outcomes and findings for the review prompt. Timings are
+// only mentioned for slow tests, in whole seconds, so identical code yields an identical
+// prompt and can be answered from the cache.
+func formatAnalysis(analysis *CodeAnalysis) string {
+	if analysis == nil {
+		return "The tests were not run for this review."
+	}
+	if analysis.Error != "" && len(analysis.Tests) == 0 {
+		return "The tests could not be run: " + analysis.Error
 	}
 
-	return fmt.Sprintf(`You are a helpful coding mentor. Return only the hint text as plain text. No JSON, no code fences.
+	var b strings.Builder
+	passed, failed, skipped := analysis.Counts()
+	switch {
+	case !analysis.Compiled:
+		b.WriteString("The code does NOT compile, so no tests ran.\n")
+	case len(analysis.Tests) == 0:
+		b.WriteString("No tests ran; go test stopped on the findings below.\n")
+	case analysis.Passed:
+		fmt.Fprintf(&b, "All %d tests passed.\n", passed)
+	default:
+		fmt.Fprintf(&b, "%d passed, %d failed, %d skipped.\n", passed, failed, skipped)
+	}
+	if analysis.Error != "" {
+		fmt.Fprintf(&b, "Note: %s\n", analysis.Error)
+	}
 
-CHALLENGE: %s
-CURRENT CODE:
-%s
+	if failedTests := analysis.FailedTests(); len(failedTests) > 0 {
+		b.WriteString("\nFAILING TESTS:\n")
+		for _, test := range failedTests {
+			message := test.Message
+			if message == "" {
+				message = "(no output)"
+			}
+			fmt.Fprintf(&b, "- %s:\n%s\n", test.Name, indent(message, "    "))
+		}
+	}
 
-Provide %s (level %d/4). Be encouraging and educational, not just giving the answer.
+	var slow []string
+	for _, test := range analysis.Tests {
+		if test.ElapsedMs >= 1000 {
+			slow = append(slow, fmt.Sprintf("%s (~%ds)", test.Name, test.ElapsedMs/1000))
+		}
+	}
+	if len(slow) > 0 {
+		fmt.Fprintf(&b, "\nSLOW TESTS: %s\n", strings.Join(slow, ", "))
+	}
 
-Return only the hint text.`, challenge.Title, code, hintTypes[hintLevel], hintLevel)
+	for _, source := range []string{SourceCompiler, SourceVet, SourceRace} {
+		var lines []string
+		for _, finding := range analysis.Findings {
+			if finding.Source != source {
+				continue
+			}
+			line := fmt.Sprintf("- %s:%d: %s", finding.File, finding.Line, finding.Message)
+			if finding.Test != "" {
+				line += fmt.Sprintf(" (in %s)", finding.Test)
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n%s FINDINGS:\n%s\n", strings.ToUpper(source), strings.Join(lines, "\n"))
+		}
+	}
+	if analysis.Compiled && !analysis.RaceChecked {
+		b.WriteString("\n(The race detector is not available on this server.)\n")
+	} else if analysis.Compiled && len(analysis.Findings) == 0 {
+		b.WriteString("\ngo vet and the race detector reported nothing.\n")
+	}
+	return strings.TrimSpace(b.String())
 }
 
-// callLLM makes a request to the configured LLM provider
-func (ai *AIService) callLLM(prompt string) (string, error) {
-	return ai.callLLMWithOpts(prompt, false)
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
 }
 
-// callLLMWithOpts allows specifying whether JSON output is expected (to enforce provider features)
-func (ai *AIService) callLLMWithOpts(prompt string, expectJSON bool) (string, error) {
-	switch ai.config.Provider {
-	case ProviderGemini:
-		return ai.callGeminiWithOpts(prompt, expectJSON)
-	case ProviderOpenAI:
-		return ai.callOpenAIWithOpts(prompt, expectJSON)
-	case ProviderClaude:
-		return ai.callClaudeWithOpts(prompt, expectJSON)
-	default:
-		return "", fmt.Errorf("unsupported provider: %s", ai.config.Provider)
-	}
+// buildQuestionPrompt renders the prompt for generating interview questions
+func (ai *AIService) buildQuestionPrompt(code string, challenge *models.Challenge, userProgress string) (LLMRequest, string, error) {
+	return ai.newRequest(PromptQuestions, PromptData{
+		Challenge:    challenge,
+		Header:       challengeHeader(challenge),
+		Description:  truncateDescription(challenge.Description),
+		Code:         code,
+		UserProgress: userProgress,
+	}, true /* expectJSON */)
 }
 
-// callGemini makes a request to the Gemini API
-func (ai *AIService) callGeminiWithOpts(prompt string, expectJSON bool) (string, error) {
-	url := fmt.Sprintf("%s/%s:generateContent?key=%s", ai.config.BaseURL, ai.config.Model, ai.config.APIKey)
+// hintTypes describes what each hint level asks for
+var hintTypes = map[int]string{
+	1: "a subtle nudge in the right direction",
+	2: "a more direct hint about the approach",
+	3: "a specific suggestion about implementation",
+	4: "a detailed explanation with partial code example",
+}
 
-	requestBody := GeminiRequest{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{
-					{Text: prompt},
-				},
-			},
-		},
-		GenerationConfig: &GeminiGenerationConfig{
-			Temperature:     &ai.config.Temperature,
-			MaxOutputTokens: &ai.config.MaxTokens,
-			ResponseMIME: func() string {
-				if expectJSON {
-					return "application/json"
-				}
-				return ""
-			}(),
-		},
-	}
+// buildHintPrompt renders the prompt for generating hints
+func (ai *AIService) buildHintPrompt(code string, challenge *models.Challenge, hintLevel int) (LLMRequest, string, error) {
+	return ai.newRequest(PromptHint, PromptData{
+		Challenge:   challenge,
+		Header:      challengeHeader(challenge),
+		Description: truncateDescription(challenge.Description),
+		Code:        code,
+		HintLevel:   hintLevel,
+		HintType:    hintTypes[hintLevel],
+	}, false /* expectJSON */)
+}
 
-	jsonData, err := json.Marshal(requestBody)
+// newRequest renders the named prompt into a provider request. expectJSON asks the
+// provider for JSON output. The request keeps the prompt name and data for providers
+// that answer from them instead of the text (the mock provider).
+func (ai *AIService) newRequest(name string, data PromptData, expectJSON bool) (LLMRequest, string, error) {
+	prompt, version, err := ai.prompts.Render(name, data)
 	if err != nil {
-		return "", err
+		return LLMRequest{}, "", err
+	}
+	request := newLLMRequest(prompt, expectJSON)
+	request.Task = name
+	request.Data = &data
+	return request, version, nil
+}
+
+// callLLM sends request to the feature's provider on behalf of user. onChunk, when not
+// nil, receives text as the provider generates it; a nil onChunk makes a regular,
+// non-streaming request. Identical requests are answered from the cache; other requests
+// are counted against user's daily quota.
+func (ai *AIService) callLLM(ctx context.Context, user string, feature AIFeature, request LLMRequest, onChunk func(string) error) (string, error) {
+	provider := ai.providers[feature]
+
+	key := CacheKey(provider, request)
+	if !request.NoCache {
+		if text, ok := ai.usage.CachedResponse(user, feature, key); ok {
+			if onChunk != nil {
+				if err := onChunk(text); err != nil {
+					return "", err
+				}
+			}
+			return text, nil
+		}
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
+	if err := ai.usage.Reserve(user, feature); err != nil {
 		return "", err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := ai.httpClient.Do(req)
-	if err != nil {
-		return "", err
+	var response *LLMResponse
+	var err error
+	if onChunk != nil {
+		response, err = provider.Stream(ctx, request, onChunk)
+	} else {
+		response, err = provider.Complete(request)
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		ai.usage.Release(user, feature)
 		return "", err
 	}
 
-	var geminiResp GeminiResponse
-	err = json.Unmarshal(body, &geminiResp)
-	if err != nil {
-		return "", err
+	ai.usage.Commit(user, feature, response.Usage.Total(request, response.Text))
+	if !request.NoCache && (request.Accept == nil || request.Accept(response.Text)) {
+		ai.usage.StoreResponse(key, response.Text)
 	}
+	return response.Text, nil
+}
 
-	if geminiResp.Error != nil {
-		return "", fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
+func newLLMRequest(prompt string, expectJSON bool) LLMRequest {
+	system := "You are a senior Go interviewer. Be concise."
+	if expectJSON {
+		system += " Respond ONLY with strict JSON. No markdown."
 	}
-
-	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response from Gemini")
+	return LLMRequest{
+		Prompt:     prompt,
+		System:     system,
+		ExpectJSON: expectJSON,
 	}
-
-	return geminiResp.Candidates[0].Content.Parts[0].Text, nil
 }
 
-// callClaude makes a request to the Claude API
-// Claude Messages API requires content blocks and benefits from a system message
-type claudeContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
-}
+// reviewSchema is the shape of the model's part of AICodeReview
+var reviewSchema = SchemaFor(AICodeReview{})
 
-type claudeMessage struct {
-	Role    string               `json:"role"`
-	Content []claudeContentBlock `json:"content"`
+// reviewRequest renders a review prompt and asks for the reply in the provider's
+// structured output mode
+func (ai *AIService) reviewRequest(name string, data PromptData) (LLMRequest, string, error) {
+	request, version, err := ai.newRequest(name, data, true /* expectJSON */)
+	request.Schema = reviewSchema
+	request.SchemaName = "code_review"
+	request.Accept = validReview
+	return request, version, err
 }
 
-func (ai *AIService) callClaudeWithOpts(prompt string, expectJSON bool) (string, error) {
-	systemText := "You are a senior Go interviewer. Be concise."
-	if expectJSON {
-		systemText += " Respond ONLY with strict JSON. No markdown."
-	}
-	requestBody := struct {
-		Model       string          `json:"model"`
-		Messages    []claudeMessage `json:"messages"`
-		MaxTokens   int             `json:"max_tokens"`
-		Temperature float64         `json:"temperature"`
-	}{
-		Model: ai.config.Model,
-		Messages: []claudeMessage{
-			{Role: "system", Content: []claudeContentBlock{{Type: "text", Text: systemText}}},
-			{Role: "user", Content: []claudeContentBlock{{Type: "text", Text: prompt}}},
-		},
-		MaxTokens:   ai.config.MaxTokens,
-		Temperature: ai.config.Temperature,
-	}
+// validReview reports whether a review response passes validation as it is
+func validReview(response string) bool {
+	_, problems := decodeReview(response)
+	return len(problems) == 0
+}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
-	}
+// maxRepairProblems caps the validation errors quoted back to the model
+const maxRepairProblems = 20
 
-	req, err := http.NewRequest("POST", ai.config.BaseURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
+// validatedReview decodes the response to a review request and checks it against
+// reviewSchema. An invalid response is sent back to the model once with the validation
+// errors, and a valid correction is cached as the answer to the original request; if the
+// correction is still invalid the review falls back to createFallbackReview.
+func (ai *AIService) validatedReview(ctx context.Context, user string, challenge *models.Challenge, original LLMRequest, response string) (*AICodeReview, error) {
+	review, problems := decodeReview(response)
+	if len(problems) == 0 {
+		return review, nil
 	}
+	log.Printf("AI review failed validation, asking for a repair: %s", strings.Join(problems, "; "))
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", ai.config.APIKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := ai.httpClient.Do(req)
-	if err != nil {
-		return "", err
+	if len(problems) > maxRepairProblems {
+		problems = append(problems[:maxRepairProblems], fmt.Sprintf("... and %d more", len(problems)-maxRepairProblems))
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	request, _, err := ai.reviewRequest(PromptReviewRepair, PromptData{Challenge: challenge, Response: response, Problems: problems})
 	if err != nil {
-		return "", err
+		return ai.createFallbackReview("Invalid response: "+problems[0], response), nil
 	}
-
-	var claudeResp ClaudeResponse
-	err = json.Unmarshal(body, &claudeResp)
+	repaired, err := ai.callLLM(ctx, user, FeatureReview, request, nil)
 	if err != nil {
-		return "", err
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if IsQuotaError(err) {
+			return nil, err
+		}
+		return ai.createFallbackReview("Invalid response: "+problems[0], response), nil
 	}
 
-	if claudeResp.Error != nil {
-		return "", fmt.Errorf("Claude API error: %s", claudeResp.Error.Message)
+	review, problems = decodeReview(repaired)
+	if len(problems) > 0 {
+		log.Printf("AI review repair failed validation: %s", strings.Join(problems, "; "))
+		return ai.createFallbackReview("Invalid response: "+problems[0], repaired), nil
 	}
-
-	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("no response from Claude")
+	if !original.NoCache {
+		ai.usage.StoreResponse(CacheKey(ai.providers[FeatureReview], original), repaired)
 	}
-
-	return claudeResp.Content[0].Text, nil
+	review.Repaired = true
+	return review, nil
 }
 
-// callOpenAI makes a request to the OpenAI API
-type OpenAIResponseFormat struct {
-	Type string `json:"type"`
-}
+// decodeReview parses a review response and validates it against reviewSchema. Markdown
+// fences and text around the JSON object are tolerated for servers without a structured
+// output mode; anything else is reported as a problem.
+func decodeReview(response string) (*AICodeReview, []string) {
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
 
-func (ai *AIService) callOpenAIWithOpts(prompt string, expectJSON bool) (string, error) {
-	// Add a system message to better steer responses
-	messages := []Message{
-		{Role: "system", Content: func() string {
-			if expectJSON {
-				return "You are a senior Go interviewer. Respond ONLY with strict JSON. No markdown."
-			}
-			return "You are a senior Go interviewer."
-		}()},
-		{Role: "user", Content: prompt},
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return nil, []string{"response: no JSON object found"}
 	}
+	jsonStr := response[start : end+1]
 
-	requestBody := OpenAIRequest{
-		Model:       ai.config.Model,
-		Messages:    messages,
-		MaxTokens:   ai.config.MaxTokens,
-		Temperature: ai.config.Temperature,
+	var raw interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &raw); err != nil {
+		return nil, []string{fmt.Sprintf("response: invalid JSON: %v", err)}
 	}
-	if expectJSON {
-		// Only force json_object when the prompt expects a single JSON object, not an array
-		if strings.Contains(strings.ToLower(prompt), "single json object") {
-			requestBody.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
-		}
+	if problems := reviewSchema.Validate(raw); len(problems) > 0 {
+		return nil, problems
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
+	var review AICodeReview
+	if err := json.Unmarshal([]byte(jsonStr), &review); err != nil {
+		return nil, []string{fmt.Sprintf("response: %v", err)}
 	}
+	normalizeReview(&review)
+	return &review, nil
+}
 
-	req, err := http.NewRequest("POST", ai.config.BaseURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
+// normalizeReview clamps scores to 0..100 and replaces missing lists with empty ones
+// so clients can render the review without null checks
+func normalizeReview(review *AICodeReview) {
+	clamp := func(score float64) float64 {
+		if score < 0 {
+			return 0
+		}
+		if score > 100 {
+			return 100
+		}
+		return score
 	}
+	review.OverallScore = clamp(review.OverallScore)
+	review.ReadabilityScore = clamp(review.ReadabilityScore)
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+ai.config.APIKey)
-
-	resp, err := ai.httpClient.Do(req)
-	if err != nil {
-		return "", err
+	if review.Issues == nil {
+		review.Issues = []CodeIssue{}
 	}
-	defer resp.Body.Close()
+	if review.Suggestions == nil {
+		review.Suggestions = []CodeSuggestion{}
+	}
+	if review.FollowUpQuestions == nil {
+		review.FollowUpQuestions = []string{}
+	}
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+// groundReview checks the review against the code and the analysis it was based on:
+// line numbers are corrected from the quoted snippet (or cleared when they point
+// nowhere), issues are linked to the failing tests they explain, and failures or
+// findings the model did not mention are added as issues of their own.
+func groundReview(review *AICodeReview, code string, analysis *CodeAnalysis) *AICodeReview {
+	lines := strings.Split(code, "\n")
+	var failedTests []TestOutcome
+	if analysis != nil {
+		failedTests = analysis.FailedTests()
 	}
 
-	var openAIResp OpenAIResponse
-	err = json.Unmarshal(body, &openAIResp)
-	if err != nil {
-		return "", err
+	for i := range review.Issues {
+		issue := &review.Issues[i]
+		if issue.Source == "" {
+			issue.Source = "ai"
+		}
+		verifyIssueLine(issue, lines)
+		issue.RelatedTest = matchFailedTest(issue.RelatedTest, analysis)
+		if issue.RelatedTest == "" && (issue.Type == "bug" || issue.Type == "logic") {
+			issue.RelatedTest = testForLine(issue.LineNumber, failedTests)
+		}
+	}
+	if analysis == nil {
+		return review
 	}
+	review.Analysis = analysis
 
-	if openAIResp.Error != nil {
-		return "", fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
+	// Tool findings are facts; keep the ones the model did not already cover
+	for _, finding := range analysis.Findings {
+		if finding.File != SolutionFile && finding.Source != SourceCompiler {
+			continue
+		}
+		if finding.File == SolutionFile && issueAtLine(review.Issues, finding.Line) {
+			continue
+		}
+		issue := CodeIssue{
+			Type:         "bug",
+			Severity:     findingSeverity[finding.Source],
+			Description:  fmt.Sprintf("%s reports: %s", finding.Source, finding.Message),
+			Solution:     findingSolution[finding.Source],
+			RelatedTest:  finding.Test,
+			Source:       finding.Source,
+			LineVerified: true,
+		}
+		if finding.File == SolutionFile && finding.Line >= 1 && finding.Line <= len(lines) {
+			issue.LineNumber = finding.Line
+			issue.Snippet = strings.TrimSpace(lines[finding.Line-1])
+		} else {
+			issue.Description = fmt.Sprintf("%s reports in %s:%d: %s", finding.Source, finding.File, finding.Line, finding.Message)
+		}
+		review.Issues = append(review.Issues, issue)
 	}
 
-	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+	// Every failing test should be explained by at least one issue
+	for _, test := range failedTests {
+		explained := false
+		for _, issue := range review.Issues {
+			if explainsTest(issue.RelatedTest, test.Name) {
+				explained = true
+				break
+			}
+		}
+		if explained {
+			continue
+		}
+		issue := CodeIssue{
+			Type:        "logic",
+			Severity:    "high",
+			Description: fmt.Sprintf("Test %s fails.", test.Name),
+			Solution:    "Compare the expected and actual values in the test output and fix the behaviour it exercises.",
+			RelatedTest: test.Name,
+			Source:      "test",
+		}
+		if test.Message != "" {
+			issue.Description += "\n\n" + test.Message
+		}
+		if len(test.Lines) > 0 && test.Lines[0] <= len(lines) {
+			issue.LineNumber = test.Lines[0]
+			issue.Snippet = strings.TrimSpace(lines[test.Lines[0]-1])
+			issue.LineVerified = true
+		}
+		review.Issues = append(review.Issues, issue)
 	}
+	return review
+}
 
-	return openAIResp.Choices[0].Message.Content, nil
+// findingSeverity and findingSolution describe issues added from tool findings
+var findingSeverity = map[string]string{
+	SourceCompiler: "critical",
+	SourceRace:     "high",
+	SourceVet:      "medium",
 }
 
-// parseAIResponse parses the AI response into a structured review
-func (ai *AIService) parseAIResponse(response string) (*AICodeReview, error) {
-	// Remove markdown code blocks if present
-	response = strings.TrimSpace(response)
-	response = strings.TrimPrefix(response, "```json")
-	response = strings.TrimPrefix(response, "```")
-	response = strings.TrimSuffix(response, "```")
-	response = strings.TrimSpace(response)
+var findingSolution = map[string]string{
+	SourceCompiler: "Fix the compile error first; no test can run until the package builds.",
+	SourceRace:     "Guard the shared data with a mutex, a channel or the sync/atomic package.",
+	SourceVet:      "Address the go vet report; it usually points at a real bug.",
+}
 
-	// Try to extract JSON from the response
-	start := strings.Index(response, "{")
-	end := strings.LastIndex(response, "}")
+// verifyIssueLine makes the issue's line number agree with the code. The quoted snippet
+// wins over the number: the closest line containing it is used. Numbers outside the file
+// are cleared.
+func verifyIssueLine(issue *CodeIssue, lines []string) {
+	snippet := strings.TrimSpace(issue.Snippet)
+	issue.LineVerified = false
+	if snippet != "" {
+		best := 0
+		for i, line := range lines {
+			if !strings.Contains(line, snippet) {
+				continue
+			}
+			if best == 0 || abs(i+1-issue.LineNumber) < abs(best-issue.LineNumber) {
+				best = i + 1
+			}
+		}
+		if best > 0 {
+			issue.LineNumber = best
+			issue.LineVerified = true
+		}
+	}
+	if issue.LineNumber < 1 || issue.LineNumber > len(lines) {
+		issue.LineNumber = 0
+		issue.Snippet = ""
+		return
+	}
+	issue.Snippet = strings.TrimSpace(lines[issue.LineNumber-1])
+}
 
-	if start == -1 || end == -1 {
-		// Log the raw response for debugging
-		fmt.Printf("AI Response parsing failed - no JSON braces found. Raw response: %s\n", response)
-		return ai.createFallbackReview("No JSON found in AI response", response), nil
+// matchFailedTest returns the failing test the model named, or "" when no such test failed.
+// A failing parent test stands for all of its failing subtests.
+func matchFailedTest(name string, analysis *CodeAnalysis) string {
+	name = strings.TrimSpace(name)
+	if name == "" || analysis == nil {
+		return ""
+	}
+	for _, test := range analysis.Tests {
+		if test.Status == "fail" && test.Name == name {
+			return test.Name
+		}
 	}
+	return ""
+}
 
-	jsonStr := response[start : end+1]
+// explainsTest reports whether an issue linked to related explains the given failing test
+func explainsTest(related, test string) bool {
+	return related != "" && (related == test || strings.HasPrefix(test, related+"/"))
+}
 
-	// Check if JSON is complete by counting braces
-	openBraces := strings.Count(jsonStr, "{")
-	closeBraces := strings.Count(jsonStr, "}")
-	if openBraces != closeBraces {
-		fmt.Printf("AI Response parsing failed - mismatched braces. JSON: %s\n", jsonStr)
-		return ai.createFallbackReview("Incomplete JSON response", jsonStr), nil
+// testForLine returns the only failing test, or the failing test whose output points at the line
+func testForLine(line int, failedTests []TestOutcome) string {
+	if len(failedTests) == 1 {
+		return failedTests[0].Name
 	}
-
-	var review AICodeReview
-	err := json.Unmarshal([]byte(jsonStr), &review)
-	if err != nil {
-		fmt.Printf("AI Response JSON unmarshal error: %v. JSON: %s\n", err, jsonStr)
-		return ai.createFallbackReview("JSON parsing error", jsonStr), nil
+	if line == 0 {
+		return ""
+	}
+	for _, test := range failedTests {
+		for _, l := range test.Lines {
+			if l == line {
+				return test.Name
+			}
+		}
 	}
+	return ""
+}
 
-	// Validate critical fields and provide defaults
-	if review.OverallScore == 0 && review.ReadabilityScore == 0 && review.InterviewerFeedback == "" {
-		fmt.Printf("AI Response appears incomplete - all key fields empty. JSON: %s\n", jsonStr)
-		return ai.createFallbackReview("Incomplete AI response", jsonStr), nil
+func issueAtLine(issues []CodeIssue, line int) bool {
+	for _, issue := range issues {
+		if issue.LineNumber == line {
+			return true
+		}
 	}
+	return false
+}
 
-	return &review, nil
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
 }
 
 // createFallbackReview creates a reasonable fallback when AI parsing fails
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// ErrAINotConfigured is returned when a feature's provider has no API key
+var ErrAINotConfigured = errors.New("AI features require an API key; see AI_CONFIG.md")
+
+// maxPromptDescription limits how much of the challenge README goes into interview prompts
+const maxPromptDescription = 4000
+
+// interviewRecommendations are the verdicts an evaluation may return
+var interviewRecommendations = map[string]bool{
+	"strong_hire":  true,
+	"hire":         true,
+	"lean_hire":    true,
+	"lean_no_hire": true,
+	"no_hire":      true,
+}
+
+// InterviewerTurn asks the next interview question based on the whole transcript and the
+// current code. It also returns the version of the prompt templates used.
+func (ai *AIService) InterviewerTurn(user string, challenge *models.Challenge, session *models.InterviewSession) (string, string, error) {
+	if !ai.providers[FeatureQuestions].Ready() {
+		return "", "", ErrAINotConfigured
+	}
+
+	request, version, err := ai.buildInterviewTurnPrompt(challenge, session)
+	if err != nil {
+		return "", "", err
+	}
+	response, err := ai.callLLM(context.Background(), user, FeatureQuestions, request, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	question := strings.TrimSpace(response)
+	if question == "" {
+		return "", "", errors.New("the AI interviewer returned an empty reply")
+	}
+	return question, version, nil
+}
+
+// EvaluateInterview produces the structured end-of-interview evaluation
+func (ai *AIService) EvaluateInterview(user string, challenge *models.Challenge, session *models.InterviewSession) (*models.InterviewEvaluation, error) {
+	if !ai.providers[FeatureReview].Ready() {
+		return nil, ErrAINotConfigured
+	}
+
+	request, version, err := ai.buildInterviewEvaluationPrompt(challenge, session)
+	if err != nil {
+		return nil, err
+	}
+	response, err := ai.callLLM(context.Background(), user, FeatureReview, request, nil)
+	if err != nil {
+		return nil, err
+	}
+	evaluation, err := parseInterviewEvaluation(response)
+	if err != nil {
+		return nil, err
+	}
+	evaluation.PromptVersion = version
+	return evaluation, nil
+}
+
+// formatTranscript renders the turns as "INTERVIEWER:" / "CANDIDATE:" lines
+func formatTranscript(turns []models.InterviewTurn) string {
+	if len(turns) == 0 {
+		return "(the interview has not started yet)"
+	}
+	var b strings.Builder
+	for _, turn := range turns {
+		fmt.Fprintf(&b, "%s: %s\n\n", strings.ToUpper(turn.Role), strings.TrimSpace(turn.Content))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func truncateDescription(description string) string {
+	if len(description) > maxPromptDescription {
+		return description[:maxPromptDescription] + "\n..."
+	}
+	return description
+}
+
+// buildInterviewTurnPrompt renders the prompt for the interviewer's next message
+func (ai *AIService) buildInterviewTurnPrompt(challenge *models.Challenge, session *models.InterviewSession) (LLMRequest, string, error) {
+	return ai.newRequest(PromptInterviewTurn, PromptData{
+		Challenge:   challenge,
+		Header:      challengeHeader(challenge),
+		Description: truncateDescription(challenge.Description),
+		Code:        session.Code,
+		Transcript:  formatTranscript(session.Turns),
+		FirstTurn:   len(session.Turns) == 0,
+	}, false /* expectJSON */)
+}
+
+// buildInterviewEvaluationPrompt renders the prompt for the final evaluation
+func (ai *AIService) buildInterviewEvaluationPrompt(challenge *models.Challenge, session *models.InterviewSession) (LLMRequest, string, error) {
+	return ai.newRequest(PromptInterviewEvaluation, PromptData{
+		Challenge:   challenge,
+		Header:      challengeHeader(challenge),
+		Description: truncateDescription(challenge.Description),
+		Code:        session.Code,
+		Transcript:  formatTranscript(session.Turns),
+	}, true /* expectJSON */)
+}
+
+// parseInterviewEvaluation extracts and validates the evaluation JSON
+func parseInterviewEvaluation(response string) (*models.InterviewEvaluation, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return nil, errors.New("no JSON found in AI evaluation")
+	}
+
+	var evaluation models.InterviewEvaluation
+	if err := json.Unmarshal([]byte(response[start:end+1]), &evaluation); err != nil {
+		return nil, fmt.Errorf("invalid AI evaluation: %v", err)
+	}
+	if strings.TrimSpace(evaluation.Summary) == "" {
+		return nil, errors.New("AI evaluation has no summary")
+	}
+
+	if evaluation.OverallScore < 0 {
+		evaluation.OverallScore = 0
+	}
+	if evaluation.OverallScore > 100 {
+		evaluation.OverallScore = 100
+	}
+	evaluation.Recommendation = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(evaluation.Recommendation), " ", "_"))
+	if !interviewRecommendations[evaluation.Recommendation] {
+		evaluation.Recommendation = "lean_no_hire"
+		if evaluation.OverallScore >= 60 {
+			evaluation.Recommendation = "lean_hire"
+		}
+	}
+
+	clamp := func(score *int) {
+		if *score < 1 {
+			*score = 1
+		}
+		if *score > 5 {
+			*score = 5
+		}
+	}
+	clamp(&evaluation.Scores.ProblemSolving)
+	clamp(&evaluation.Scores.Communication)
+	clamp(&evaluation.Scores.GoKnowledge)
+	clamp(&evaluation.Scores.CodeQuality)
+	clamp(&evaluation.Scores.Testing)
+
+	if evaluation.Strengths == nil {
+		evaluation.Strengths = []string{}
+	}
+	if evaluation.Weaknesses == nil {
+		evaluation.Weaknesses = []string{}
+	}
+	if evaluation.NextSteps == nil {
+		evaluation.NextSteps = []string{}
+	}
+	return &evaluation, nil
+}
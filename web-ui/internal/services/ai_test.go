@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"web-ui/internal/models"
+)
+
+// scriptedProvider answers with its replies in order, then like the mock provider,
+// and counts the requests it receives
+type scriptedProvider struct {
+	mu      sync.Mutex
+	replies []string
+	calls   int
+}
+
+func (p *scriptedProvider) Name() ProviderName              { return ProviderMock }
+func (p *scriptedProvider) Model() string                   { return "scripted" }
+func (p *scriptedProvider) BaseURL() string                 { return "" }
+func (p *scriptedProvider) Ready() bool                     { return true }
+func (p *scriptedProvider) Probe(ctx context.Context) error { return nil }
+
+func (p *scriptedProvider) Complete(request LLMRequest) (*LLMResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if len(p.replies) > 0 {
+		reply := p.replies[0]
+		p.replies = p.replies[1:]
+		return &LLMResponse{Text: reply}, nil
+	}
+	return &LLMResponse{Text: mockResponse(request)}, nil
+}
+
+func (p *scriptedProvider) Stream(ctx context.Context, request LLMRequest, onChunk func(string) error) (*LLMResponse, error) {
+	response, err := p.Complete(request)
+	if err != nil {
+		return nil, err
+	}
+	return response, onChunk(response.Text)
+}
+
+func (p *scriptedProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func newTestAIService(t *testing.T, provider LLMProvider) *AIService {
+	t.Helper()
+	t.Setenv("AI_PROVIDER", "mock")
+	t.Setenv("AI_FALLBACK_PROVIDERS", "")
+	t.Setenv("AI_CACHE_TTL", "1h")
+	t.Setenv("AI_PROMPTS_DIR", t.TempDir())
+	ai := NewAIService(NewAIUsageService(), nil, nil)
+	ai.providers[FeatureReview] = provider
+	return ai
+}
+
+func TestReviewCachesOnlyValidReplies(t *testing.T) {
+	challenge := &models.Challenge{ID: 1, Title: "Sum", Description: "Add two numbers."}
+	code := "package main\n\nfunc Sum(a, b int) int { return a + b }\n"
+
+	t.Run("repaired", func(t *testing.T) {
+		provider := &scriptedProvider{replies: []string{"Here is my review: looks good"}}
+		ai := newTestAIService(t, provider)
+
+		review, err := ai.ReviewCode("alice", code, challenge, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !review.Repaired || provider.callCount() != 2 {
+			t.Fatalf("repaired = %v after %d calls, want a repair after 2", review.Repaired, provider.callCount())
+		}
+
+		again, err := ai.ReviewCode("alice", code, challenge, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if provider.callCount() != 2 {
+			t.Fatalf("the repeated request made %d more provider calls", provider.callCount()-2)
+		}
+		if again.Repaired {
+			t.Fatal("the repeated request was answered with the invalid reply and repaired again")
+		}
+		if again.OverallScore != review.OverallScore || again.InterviewerFeedback != review.InterviewerFeedback {
+			t.Fatalf("cached review %+v differs from %+v", again, review)
+		}
+	})
+
+	t.Run("never valid", func(t *testing.T) {
+		provider := &scriptedProvider{replies: []string{"not a review", "still not a review", "not a review", "still not a review"}}
+		ai := newTestAIService(t, provider)
+
+		for i := 1; i <= 2; i++ {
+			if _, err := ai.ReviewCode("alice", code, challenge, ""); err != nil {
+				t.Fatal(err)
+			}
+			if provider.callCount() != 2*i {
+				t.Fatalf("request %d: %d provider calls, want %d; an invalid reply was cached", i, provider.callCount(), 2*i)
+			}
+		}
+	})
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default daily AI limits; override with the AI_*_DAILY_* variables. 0 means unlimited.
+const (
+	defaultUserDailyRequests    = 50
+	defaultUserDailyTokens      = 200000
+	defaultNetworkDailyRequests = 500
+	defaultNetworkDailyTokens   = 2000000
+	defaultGlobalDailyRequests  = 2000
+	defaultGlobalDailyTokens    = 5000000
+	defaultAICacheTTL           = time.Hour
+	defaultAICacheSize          = 500
+)
+
+// AILimits holds the daily request and token limits. A limit of 0 is unlimited.
+type AILimits struct {
+	UserRequests    int `json:"userRequests"`
+	UserTokens      int `json:"userTokens"`
+	NetworkRequests int `json:"networkRequests"`
+	NetworkTokens   int `json:"networkTokens"`
+	GlobalRequests  int `json:"globalRequests"`
+	GlobalTokens    int `json:"globalTokens"`
+}
+
+// QuotaError is returned when a user, their network or the whole site has used its daily
+// AI allowance
+type QuotaError struct {
+	Scope    string    `json:"scope"` // "user", "network" or "global"
+	Limit    string    `json:"limit"` // "requests" or "tokens"
+	Max      int       `json:"max"`
+	ResetsAt time.Time `json:"resetsAt"`
+}
+
+func (e *QuotaError) Error() string {
+	who := "Your"
+	switch e.Scope {
+	case "network":
+		who = "Your network's"
+	case "global":
+		who = "The site's"
+	}
+	return fmt.Sprintf("%s daily AI %s limit (%d) has been reached. It resets at %s.",
+		who, e.Limit, e.Max, e.ResetsAt.Format("15:04 MST"))
+}
+
+// AIUsageCounter counts requests and tokens for one day
+type AIUsageCounter struct {
+	Requests  int `json:"requests"`
+	Tokens    int `json:"tokens"`
+	CacheHits int `json:"cacheHits"`
+}
+
+func (c *AIUsageCounter) add(other AIUsageCounter) {
+	c.Requests += other.Requests
+	c.Tokens += other.Tokens
+	c.CacheHits += other.CacheHits
+}
+
+// AIUserUsage is one user's usage today, in total and per feature
+type AIUserUsage struct {
+	User     string                       `json:"user"`
+	Network  string                       `json:"network,omitempty"`
+	Total    AIUsageCounter               `json:"total"`
+	Features map[AIFeature]AIUsageCounter `json:"features"`
+}
+
+// AICacheStats describes the prompt/response cache
+type AICacheStats struct {
+	Entries    int    `json:"entries"`
+	MaxEntries int    `json:"maxEntries"`
+	TTL        string `json:"ttl"`
+	Hits       int    `json:"hits"`
+	Misses     int    `json:"misses"`
+}
+
+// AIUsageReport is the admin view of today's AI usage
+type AIUsageReport struct {
+	Date     string                       `json:"date"`
+	ResetsAt time.Time                    `json:"resetsAt"`
+	Limits   AILimits                     `json:"limits"`
+	Global   AIUsageCounter               `json:"global"`
+	Features map[AIFeature]AIUsageCounter `json:"features"`
+	Users    []AIUserUsage                `json:"users"`
+	Cache    AICacheStats                 `json:"cache"`
+}
+
+type aiCacheEntry struct {
+	text    string
+	expires time.Time
+}
+
+// AIUsageService enforces daily AI quotas, records token usage and caches
+// responses to identical prompts. Counters are kept in memory and reset at local midnight.
+// Users on the same network also share a network quota, so that a client cannot reset its
+// allowance by starting a new session.
+type AIUsageService struct {
+	mu       sync.Mutex
+	limits   AILimits
+	day      string
+	usage    map[string]map[AIFeature]*AIUsageCounter
+	networks map[string]string // user -> network they last made a request from
+
+	cacheTTL  time.Duration
+	cacheSize int
+	cache     map[string]aiCacheEntry
+	hits      int
+	misses    int
+}
+
+// NewAIUsageService creates the usage service configured from the environment
+func NewAIUsageService() *AIUsageService {
+	return &AIUsageService{
+		limits: AILimits{
+			UserRequests:    envInt("AI_USER_DAILY_REQUESTS", defaultUserDailyRequests),
+			UserTokens:      envInt("AI_USER_DAILY_TOKENS", defaultUserDailyTokens),
+			NetworkRequests: envInt("AI_NETWORK_DAILY_REQUESTS", defaultNetworkDailyRequests),
+			NetworkTokens:   envInt("AI_NETWORK_DAILY_TOKENS", defaultNetworkDailyTokens),
+			GlobalRequests:  envInt("AI_GLOBAL_DAILY_REQUESTS", defaultGlobalDailyRequests),
+			GlobalTokens:    envInt("AI_GLOBAL_DAILY_TOKENS", defaultGlobalDailyTokens),
+		},
+		day:       today(),
+		usage:     make(map[string]map[AIFeature]*AIUsageCounter),
+		networks:  make(map[string]string),
+		cacheTTL:  envDuration("AI_CACHE_TTL", defaultAICacheTTL),
+		cacheSize: envInt("AI_CACHE_SIZE", defaultAICacheSize),
+		cache:     make(map[string]aiCacheEntry),
+	}
+}
+
+// envInt reads a non-negative integer from the environment
+func envInt(name string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Ignoring invalid %s=%q", name, value)
+		return fallback
+	}
+	return n
+}
+
+// envDuration reads a duration such as "30m" from the environment; "0" disables
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	if value == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Ignoring invalid %s=%q", name, value)
+		return fallback
+	}
+	return d
+}
+
+// envBool reads a boolean such as "true" or "1" from the environment
+func envBool(name string, fallback bool) bool {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q", name, value)
+		return fallback
+	}
+	return b
+}
+
+func today() string {
+	return time.Now().Format("2006-01-02")
+}
+
+// nextMidnight returns when today's counters reset
+func nextMidnight() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+}
+
+// rolloverLocked clears the counters when the day changes
+func (s *AIUsageService) rolloverLocked() {
+	if day := today(); day != s.day {
+		s.day = day
+		s.usage = make(map[string]map[AIFeature]*AIUsageCounter)
+		s.networks = make(map[string]string)
+	}
+}
+
+// SetNetwork records the network user's requests come from, e.g. "ip:203.0.113.7"
+func (s *AIUsageService) SetNetwork(user, network string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked()
+	s.networks[user] = network
+}
+
+// totalsLocked returns a user's usage, the usage of everyone on their network and the
+// global usage today
+func (s *AIUsageService) totalsLocked(user string) (userTotal, networkTotal, global AIUsageCounter) {
+	network := s.networks[user]
+	for name, features := range s.usage {
+		for _, counter := range features {
+			global.add(*counter)
+			if name == user {
+				userTotal.add(*counter)
+			}
+			if network != "" && s.networks[name] == network {
+				networkTotal.add(*counter)
+			}
+		}
+	}
+	return userTotal, networkTotal, global
+}
+
+// checkLocked returns a QuotaError if user may not make another request
+func (s *AIUsageService) checkLocked(user string) error {
+	s.rolloverLocked()
+	userTotal, networkTotal, global := s.totalsLocked(user)
+
+	exceeded := func(scope, limit string, used, max int) error {
+		if max > 0 && used >= max {
+			return &QuotaError{Scope: scope, Limit: limit, Max: max, ResetsAt: nextMidnight()}
+		}
+		return nil
+	}
+	if err := exceeded("global", "requests", global.Requests, s.limits.GlobalRequests); err != nil {
+		return err
+	}
+	if err := exceeded("global", "tokens", global.Tokens, s.limits.GlobalTokens); err != nil {
+		return err
+	}
+	if err := exceeded("network", "requests", networkTotal.Requests, s.limits.NetworkRequests); err != nil {
+		return err
+	}
+	if err := exceeded("network", "tokens", networkTotal.Tokens, s.limits.NetworkTokens); err != nil {
+		return err
+	}
+	if err := exceeded("user", "requests", userTotal.Requests, s.limits.UserRequests); err != nil {
+		return err
+	}
+	return exceeded("user", "tokens", userTotal.Tokens, s.limits.UserTokens)
+}
+
+// Check reports whether user has quota left without using any of it
+func (s *AIUsageService) Check(user string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkLocked(user)
+}
+
+// Reserve counts a request against user's quota before it is sent, so concurrent
+// requests cannot overshoot the request limit. Call Commit with the tokens used,
+// or Release if the request failed.
+func (s *AIUsageService) Reserve(user string, feature AIFeature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkLocked(user); err != nil {
+		return err
+	}
+	s.counterLocked(user, feature).Requests++
+	return nil
+}
+
+// Commit records the tokens used by a reserved request
+func (s *AIUsageService) Commit(user string, feature AIFeature, tokens int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked()
+	s.counterLocked(user, feature).Tokens += tokens
+}
+
+// Release returns a reserved request that failed
+func (s *AIUsageService) Release(user string, feature AIFeature) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked()
+	if counter := s.counterLocked(user, feature); counter.Requests > 0 {
+		counter.Requests--
+	}
+}
+
+func (s *AIUsageService) counterLocked(user string, feature AIFeature) *AIUsageCounter {
+	features, ok := s.usage[user]
+	if !ok {
+		features = make(map[AIFeature]*AIUsageCounter)
+		s.usage[user] = features
+	}
+	counter, ok := features[feature]
+	if !ok {
+		counter = &AIUsageCounter{}
+		features[feature] = counter
+	}
+	return counter
+}
+
+// CacheKey identifies a request to a specific provider and model
+func CacheKey(provider LLMProvider, request LLMRequest) string {
+	hash := sha256.New()
+	for _, part := range []string{string(provider.Name()), provider.Model(), provider.BaseURL(), request.System, request.Prompt, strconv.FormatBool(request.ExpectJSON), request.SchemaName} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// CachedResponse returns a cached response for key. Hits are free: they count
+// towards the user's cache hits but not their request or token quota.
+func (s *AIUsageService) CachedResponse(user string, feature AIFeature, key string) (string, bool) {
+	if s.cacheTTL == 0 {
+		return "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(s.cache, key)
+		s.misses++
+		return "", false
+	}
+	s.hits++
+	s.rolloverLocked()
+	s.counterLocked(user, feature).CacheHits++
+	return entry.text, true
+}
+
+// StoreResponse caches text for key until the cache TTL expires
+func (s *AIUsageService) StoreResponse(key, text string) {
+	if s.cacheTTL == 0 || s.cacheSize == 0 || strings.TrimSpace(text) == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.cache) >= s.cacheSize {
+		// Drop expired entries, then the entry closest to expiring
+		oldestKey := ""
+		var oldest time.Time
+		for k, entry := range s.cache {
+			if now.After(entry.expires) {
+				delete(s.cache, k)
+				continue
+			}
+			if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = k, entry.expires
+			}
+		}
+		if len(s.cache) >= s.cacheSize && oldestKey != "" {
+			delete(s.cache, oldestKey)
+		}
+	}
+	s.cache[key] = aiCacheEntry{text: text, expires: now.Add(s.cacheTTL)}
+}
+
+// ClearCache empties the response cache and returns the number of entries removed
+func (s *AIUsageService) ClearCache() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.cache)
+	s.cache = make(map[string]aiCacheEntry)
+	return n
+}
+
+// Limits returns the configured daily limits
+func (s *AIUsageService) Limits() AILimits {
+	return s.limits
+}
+
+// Report summarises today's usage per user and per feature, heaviest users first
+func (s *AIUsageService) Report() AIUsageReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked()
+
+	report := AIUsageReport{
+		Date:     s.day,
+		ResetsAt: nextMidnight(),
+		Limits:   s.limits,
+		Features: make(map[AIFeature]AIUsageCounter),
+		Users:    []AIUserUsage{},
+		Cache: AICacheStats{
+			Entries:    len(s.cache),
+			MaxEntries: s.cacheSize,
+			TTL:        s.cacheTTL.String(),
+			Hits:       s.hits,
+			Misses:     s.misses,
+		},
+	}
+
+	for user, features := range s.usage {
+		entry := AIUserUsage{User: user, Network: s.networks[user], Features: make(map[AIFeature]AIUsageCounter)}
+		for feature, counter := range features {
+			entry.Features[feature] = *counter
+			entry.Total.add(*counter)
+
+			total := report.Features[feature]
+			total.add(*counter)
+			report.Features[feature] = total
+		}
+		report.Global.add(entry.Total)
+		report.Users = append(report.Users, entry)
+	}
+
+	sort.Slice(report.Users, func(i, j int) bool {
+		a, b := report.Users[i].Total, report.Users[j].Total
+		if a.Tokens != b.Tokens {
+			return a.Tokens > b.Tokens
+		}
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return report.Users[i].User < report.Users[j].User
+	})
+	return report
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// Names of the files a submission is tested as
+const (
+	SolutionFile = "solution-template.go"
+	TestFileName = "solution_test.go"
+)
+
+// analysisTimeout bounds the whole test run of an analysis, race detector included
+const analysisTimeout = 2 * time.Minute
+
+// maxTestMessage limits how much failure output is kept per test
+const maxTestMessage = 1500
+
+// Finding sources
+const (
+	SourceCompiler = "compiler"
+	SourceVet      = "vet"
+	SourceRace     = "race"
+)
+
+// CodeAnalysis is what actually happened when a submission was built, vetted and tested.
+// AI code reviews are grounded in it.
+type CodeAnalysis struct {
+	Passed      bool          `json:"passed"`
+	Compiled    bool          `json:"compiled"`
+	RaceChecked bool          `json:"raceChecked"` // false when the race detector is unavailable (no cgo)
+	Tests       []TestOutcome `json:"tests"`
+	Findings    []CodeFinding `json:"findings"` // compiler errors, vet reports and data races
+	ExecutionMs int64         `json:"executionMs"`
+	Error       string        `json:"error,omitempty"` // the analysis could not be run at all
+}
+
+// TestOutcome is the result of one test or subtest
+type TestOutcome struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"` // "pass", "fail" or "skip"
+	ElapsedMs int64  `json:"elapsedMs"`
+	Message   string `json:"message,omitempty"` // failure output without the RUN/FAIL framing
+	Lines     []int  `json:"lines,omitempty"`   // solution lines named in the output (panics, races)
+}
+
+// CodeFinding is a located report from the compiler, go vet or the race detector
+type CodeFinding struct {
+	Source  string `json:"source"` // "compiler", "vet" or "race"
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	Test    string `json:"test,omitempty"` // the test that triggered a data race
+}
+
+// FailedTests returns the failing tests, leaving out parents whose subtests already failed
+func (a *CodeAnalysis) FailedTests() []TestOutcome {
+	var failed []TestOutcome
+	for _, test := range a.Tests {
+		if test.Status != "fail" {
+			continue
+		}
+		hasFailedChild := false
+		for _, other := range a.Tests {
+			if other.Status == "fail" && strings.HasPrefix(other.Name, test.Name+"/") {
+				hasFailedChild = true
+				break
+			}
+		}
+		if !hasFailedChild {
+			failed = append(failed, test)
+		}
+	}
+	return failed
+}
+
+// Counts returns how many tests passed, failed and were skipped
+func (a *CodeAnalysis) Counts() (passed, failed, skipped int) {
+	for _, test := range a.Tests {
+		switch test.Status {
+		case "pass":
+			passed++
+		case "fail":
+			failed++
+		case "skip":
+			skipped++
+		}
+	}
+	return passed, failed, skipped
+}
+
+var (
+	// file.go:12:5: message, optionally prefixed by a path
+	locationPattern = regexp.MustCompile(`^(?:\S*/)?([\w.-]+\.go):(\d+)(?::(\d+))?: (.+)$`)
+	// file.go:12 anywhere in a line, as in stack traces
+	solutionLinePattern = regexp.MustCompile(regexp.QuoteMeta(SolutionFile) + `:(\d+)`)
+	// "Read at 0x00c0000182b8 by goroutine 9:" and friends in race reports
+	raceAccessPattern = regexp.MustCompile(`^(Read|Write|Previous read|Previous write) at 0x[0-9a-f]+ by (goroutine \d+|main goroutine)`)
+)
+
+var (
+	raceOnce      sync.Once
+	raceAvailable bool
+)
+
+// raceSupported reports whether `go test -race` can run here; it needs cgo
+func raceSupported() bool {
+	raceOnce.Do(func() {
+		out, err := exec.Command("go", "env", "CGO_ENABLED").Output()
+		raceAvailable = err == nil && strings.TrimSpace(string(out)) == "1"
+	})
+	return raceAvailable
+}
+
+// AnalyzeCode builds, vets and tests the code against a challenge and reports each test,
+// compiler error, vet finding and data race separately. Unlike RunCode it is not recorded
+// as a submission run.
+func (es *ExecutionService) AnalyzeCode(code string, challenge *models.Challenge) *CodeAnalysis {
+	es.mutex.Lock()
+	es.running++
+	es.mutex.Unlock()
+	defer func() {
+		es.mutex.Lock()
+		es.running--
+		es.mutex.Unlock()
+	}()
+
+	start := time.Now()
+	analysis := &CodeAnalysis{Tests: []TestOutcome{}, Findings: []CodeFinding{}}
+
+	tempDir, err := es.prepareWorkspace(code, challenge)
+	if tempDir != "" {
+		defer os.RemoveAll(tempDir)
+	}
+	if err != nil {
+		analysis.Error = err.Error()
+		return analysis
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), analysisTimeout)
+	defer cancel()
+
+	args := []string{"test", "-json", "-count=1", fmt.Sprintf("-timeout=%s", analysisTimeout)}
+	if raceSupported() {
+		args = append(args, "-race")
+		analysis.RaceChecked = true
+	}
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = tempDir
+	output, err := cmd.CombinedOutput()
+	analysis.ExecutionMs = time.Since(start).Milliseconds()
+
+	if ctx.Err() != nil {
+		analysis.Error = fmt.Sprintf("tests did not finish within %s", analysisTimeout)
+	} else if _, isExit := err.(*exec.ExitError); err != nil && !isExit {
+		analysis.Error = fmt.Sprintf("Failed to run tests: %v", err)
+		return analysis
+	}
+
+	buildFindings := parseBuildOutput(parseTestEvents(output, analysis))
+	analysis.Findings = append(analysis.Findings, buildFindings...)
+	analysis.Compiled = !hasFindings(buildFindings, SourceCompiler)
+
+	// go test already ran the vet checks it considers reliable, and vet would only repeat
+	// the errors of a package that does not build; otherwise run the full vet suite
+	if analysis.Compiled && !hasFindings(buildFindings, SourceVet) {
+		vet := exec.CommandContext(ctx, "go", "vet", ".")
+		vet.Dir = tempDir
+		vetOutput, _ := vet.CombinedOutput()
+		analysis.Findings = append(analysis.Findings, parseLocations(string(vetOutput), SourceVet)...)
+	}
+
+	_, failed, _ := analysis.Counts()
+	analysis.Passed = err == nil && analysis.Compiled && failed == 0 && analysis.Error == ""
+	return analysis
+}
+
+// testEvent is one line of `go test -json` output
+type testEvent struct {
+	Action  string  `json:"Action"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// parseTestEvents fills in the test outcomes and race findings and returns the output that
+// did not belong to any test, which holds compiler errors when the package does not build
+func parseTestEvents(output []byte, analysis *CodeAnalysis) string {
+	var other strings.Builder
+	outputs := make(map[string]*strings.Builder)
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event testEvent
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &event) != nil {
+			other.Write(line)
+			other.WriteByte('\n')
+			continue
+		}
+		if event.Test == "" {
+			if event.Action == "output" || event.Action == "build-output" {
+				other.WriteString(event.Output)
+			}
+			continue
+		}
+
+		switch event.Action {
+		case "run":
+			index[event.Test] = len(analysis.Tests)
+			analysis.Tests = append(analysis.Tests, TestOutcome{Name: event.Test})
+			outputs[event.Test] = &strings.Builder{}
+		case "output":
+			if b, ok := outputs[event.Test]; ok {
+				b.WriteString(event.Output)
+			}
+		case "pass", "fail", "skip":
+			i, ok := index[event.Test]
+			if !ok {
+				continue
+			}
+			analysis.Tests[i].Status = event.Action
+			analysis.Tests[i].ElapsedMs = int64(event.Elapsed * 1000)
+		}
+	}
+
+	for i := range analysis.Tests {
+		test := &analysis.Tests[i]
+		if test.Status == "" {
+			// The binary died (timeout or panic) while this test was running
+			test.Status = "fail"
+		}
+		message, races := splitRaceReports(outputs[test.Name].String())
+		for _, race := range races {
+			race.Test = test.Name
+			analysis.Findings = append(analysis.Findings, race)
+		}
+		test.Lines = solutionLines(outputs[test.Name].String())
+		if test.Status == "fail" {
+			if message == "" && len(races) > 0 {
+				message = "the race detector reported a data race during this test"
+			}
+			test.Message = truncateMessage(message)
+		}
+	}
+	return other.String()
+}
+
+// splitRaceReports separates data race reports from a test's output and returns the
+// remaining output without the RUN/PASS/FAIL framing lines
+func splitRaceReports(output string) (string, []CodeFinding) {
+	var kept []string
+	var races []CodeFinding
+	var race *CodeFinding
+	var accesses []string
+	pending := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "WARNING: DATA RACE":
+			race = &CodeFinding{Source: SourceRace, File: SolutionFile}
+			accesses = nil
+			continue
+		case race != nil && trimmed == "==================":
+			if len(accesses) > 0 {
+				race.Message = "data race: " + strings.Join(accesses, ", ")
+			} else {
+				race.Message = "data race detected"
+			}
+			races = append(races, *race)
+			race = nil
+			continue
+		case race != nil:
+			if m := raceAccessPattern.FindStringSubmatch(trimmed); m != nil {
+				pending = strings.ToLower(m[1]) + " by " + m[2]
+			} else if m := solutionLinePattern.FindStringSubmatch(trimmed); m != nil && pending != "" {
+				line, _ := strconv.Atoi(m[1])
+				accesses = append(accesses, fmt.Sprintf("%s at line %d", pending, line))
+				if race.Line == 0 {
+					race.Line = line
+				}
+				pending = ""
+			}
+			continue
+		}
+
+		if trimmed == "" || trimmed == "==================" ||
+			strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- PASS") ||
+			strings.HasPrefix(trimmed, "--- FAIL") || strings.HasPrefix(trimmed, "--- SKIP") ||
+			strings.HasPrefix(trimmed, "testing.go:") && strings.Contains(trimmed, "race detected") {
+			continue
+		}
+		kept = append(kept, trimmed)
+	}
+	return strings.Join(kept, "\n"), races
+}
+
+// solutionLines returns the distinct solution file lines mentioned in the output
+func solutionLines(output string) []int {
+	seen := make(map[int]bool)
+	var lines []int
+	for _, m := range solutionLinePattern.FindAllStringSubmatch(output, -1) {
+		line, err := strconv.Atoi(m[1])
+		if err == nil && !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// parseBuildOutput reads the build output of `go test`, where compiler errors follow a
+// "# pkg [pkg.test]" header and errors from its vet pass follow "# [pkg]"
+func parseBuildOutput(output string) []CodeFinding {
+	findings := []CodeFinding{}
+	source := SourceCompiler
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			source = SourceCompiler
+			if strings.HasPrefix(line, "# [") {
+				source = SourceVet
+			}
+			continue
+		}
+		findings = append(findings, parseLocations(line, source)...)
+	}
+	return findings
+}
+
+func hasFindings(findings []CodeFinding, source string) bool {
+	for _, finding := range findings {
+		if finding.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLocations turns "file.go:line:col: message" lines into findings
+func parseLocations(output, source string) []CodeFinding {
+	findings := []CodeFinding{}
+	for _, line := range strings.Split(output, "\n") {
+		m := locationPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		findings = append(findings, CodeFinding{
+			Source:  source,
+			File:    filepath.Base(m[1]),
+			Line:    lineNumber,
+			Column:  column,
+			Message: strings.TrimSpace(m[4]),
+		})
+	}
+	return findings
+}
+
+func truncateMessage(message string) string {
+	if len(message) > maxTestMessage {
+		return message[:maxTestMessage] + "\n..."
+	}
+	return message
+}
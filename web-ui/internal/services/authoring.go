@@ -0,0 +1,504 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+const (
+	// draftMaxTokens is the output limit for a draft: seven files of a challenge
+	draftMaxTokens = 16000
+	// maxExampleSize limits how much of an existing challenge goes into the prompt
+	maxExampleSize = 5000
+)
+
+// ChallengeDraft is the model's draft of a new challenge: the files of a challenge
+// directory plus a reference solution and metadata
+type ChallengeDraft struct {
+	Slug      string        `json:"slug"`              // e.g. "rate-limiter", names package challenge directories
+	Readme    string        `json:"readme"`            // README.md
+	Template  string        `json:"solution_template"` // solution-template.go
+	Tests     string        `json:"solution_test"`     // solution-template_test.go
+	Reference string        `json:"reference_solution"`
+	Hints     string        `json:"hints"`    // hints.md
+	Learning  string        `json:"learning"` // learning.md
+	Metadata  DraftMetadata `json:"metadata"`
+}
+
+// DraftMetadata is the metadata.json of a drafted challenge
+type DraftMetadata struct {
+	Title               string   `json:"title"`
+	Description         string   `json:"description"`
+	ShortDescription    string   `json:"short_description"`
+	Difficulty          string   `json:"difficulty" schema:"enum=Beginner|Intermediate|Advanced"`
+	EstimatedTime       string   `json:"estimated_time"`
+	LearningObjectives  []string `json:"learning_objectives"`
+	Prerequisites       []string `json:"prerequisites"`
+	Tags                []string `json:"tags"`
+	RealWorldConnection string   `json:"real_world_connection"`
+	Requirements        []string `json:"requirements"`
+	BonusPoints         []string `json:"bonus_points" schema:"optional"`
+}
+
+// draftSchema is the shape of ChallengeDraft for structured output
+var draftSchema = SchemaFor(ChallengeDraft{})
+
+// AuthoringRequest describes the challenge a maintainer wants drafted
+type AuthoringRequest struct {
+	Topic      string `json:"topic"`
+	Package    string `json:"package,omitempty"` // draft a challenge for packages/<package> instead of a classic one
+	Difficulty string `json:"difficulty,omitempty"`
+	Notes      string `json:"notes,omitempty"`   // anything else the draft should cover
+	DryRun     bool   `json:"dry_run,omitempty"` // verify the draft but do not write it
+}
+
+// AuthoringCheck is one verification step of a draft
+type AuthoringCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// AuthoringReport is the outcome of drafting a challenge
+type AuthoringReport struct {
+	Checks        []AuthoringCheck `json:"checks"`
+	Passed        bool             `json:"passed"`              // every check succeeded
+	Directory     string           `json:"directory,omitempty"` // where the challenge was written, relative to the repository
+	Draft         *ChallengeDraft  `json:"draft,omitempty"`
+	PromptVersion string           `json:"prompt_version,omitempty"`
+}
+
+func (r *AuthoringReport) check(name string, passed bool, detail string) {
+	r.Checks = append(r.Checks, AuthoringCheck{Name: name, Passed: passed, Detail: detail})
+}
+
+// AuthoringService drafts new challenges with the AI, verifies them by running the tests
+// against the template and the reference solution, and writes the ones that pass as a
+// challenge-N or packages/<pkg>/challenge-N-<slug> directory
+type AuthoringService struct {
+	workspaceService *WorkspaceService
+	ai               *AIService
+	executor         *ExecutionService
+	mu               sync.Mutex // serializes numbering and writing new directories
+}
+
+// NewAuthoringService creates an authoring service that writes to the repository through
+// the workspace
+func NewAuthoringService(workspaceService *WorkspaceService, ai *AIService, executor *ExecutionService) *AuthoringService {
+	return &AuthoringService{workspaceService: workspaceService, ai: ai, executor: executor}
+}
+
+// Author drafts, verifies and (unless the request is a dry run) writes a new challenge.
+// A draft that fails a check is reported but never written.
+func (as *AuthoringService) Author(user string, request AuthoringRequest) (*AuthoringReport, error) {
+	request.Topic = strings.TrimSpace(request.Topic)
+	if request.Topic == "" {
+		return nil, errors.New("topic is required")
+	}
+	if request.Difficulty != "" && !IsDifficulty(request.Difficulty) {
+		return nil, fmt.Errorf("difficulty must be one of %s", strings.Join(Difficulties, ", "))
+	}
+	if !as.ai.providers[FeatureReview].Ready() {
+		return nil, ErrAINotConfigured
+	}
+
+	stub := &models.Challenge{Title: request.Topic, Difficulty: request.Difficulty}
+	var pkg *models.Package
+	if request.Package != "" {
+		var err error
+		if pkg, err = as.loadPackage(request.Package); err != nil {
+			return nil, err
+		}
+		stub.Package = &models.ChallengePackage{Name: pkg.Name, DisplayName: pkg.DisplayName, Version: pkg.Version}
+	}
+
+	llmRequest, version, err := as.ai.newRequest(PromptAuthorChallenge, PromptData{
+		Challenge: stub,
+		Context:   request.Notes,
+		Example:   as.example(pkg),
+	}, true /* expectJSON */)
+	if err != nil {
+		return nil, err
+	}
+	llmRequest.Schema = draftSchema
+	llmRequest.SchemaName = "challenge_draft"
+	llmRequest.MaxTokens = draftMaxTokens
+	llmRequest.NoCache = true // asking again should give a new draft
+
+	response, err := as.ai.callLLM(context.Background(), user, FeatureReview, llmRequest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AuthoringReport{Checks: []AuthoringCheck{}, PromptVersion: version}
+	draft, problems := decodeDraft(response)
+	report.check("draft matches the schema", len(problems) == 0, strings.Join(problems, "; "))
+	if draft == nil || len(problems) > 0 {
+		return report, nil
+	}
+	report.Draft = draft
+
+	as.verify(draft, report)
+	report.Passed = true
+	for _, check := range report.Checks {
+		report.Passed = report.Passed && check.Passed
+	}
+	if !report.Passed || request.DryRun {
+		return report, nil
+	}
+
+	directory, err := as.write(draft, pkg)
+	if err != nil {
+		return nil, err
+	}
+	report.Directory = directory
+	return report, nil
+}
+
+// decodeDraft parses and validates the model's draft
+func decodeDraft(response string) (*ChallengeDraft, []string) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return nil, []string{"the AI response contains no JSON object"}
+	}
+	var raw interface{}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &raw); err != nil {
+		return nil, []string{fmt.Sprintf("the AI response is not valid JSON: %v", err)}
+	}
+	problems := draftSchema.Validate(raw)
+	var draft ChallengeDraft
+	if err := json.Unmarshal([]byte(response[start:end+1]), &draft); err != nil {
+		return nil, append(problems, err.Error())
+	}
+	return &draft, problems
+}
+
+// verify checks the files of a draft and runs its tests against the template (which must
+// compile and fail) and the reference solution (which must pass)
+func (as *AuthoringService) verify(draft *ChallengeDraft, report *AuthoringReport) {
+	var missing []string
+	for _, file := range []struct{ name, content string }{
+		{"README.md", draft.Readme},
+		{SolutionFile, draft.Template},
+		{"solution-template_test.go", draft.Tests},
+		{"reference solution", draft.Reference},
+		{"hints.md", draft.Hints},
+		{"learning.md", draft.Learning},
+	} {
+		if strings.TrimSpace(file.content) == "" {
+			missing = append(missing, file.name)
+		}
+	}
+	report.check("all files drafted", len(missing) == 0, strings.Join(missing, ", "))
+	if len(missing) > 0 {
+		return
+	}
+
+	packages := map[string]string{}
+	for name, source := range map[string]string{"template": draft.Template, "tests": draft.Tests, "reference": draft.Reference} {
+		file, err := parser.ParseFile(token.NewFileSet(), name+".go", source, parser.PackageClauseOnly)
+		if err != nil {
+			report.check("files are in one package", false, fmt.Sprintf("%s: %v", name, err))
+			return
+		}
+		packages[name] = file.Name.Name
+	}
+	samePackage := packages["template"] == packages["tests"] && packages["template"] == packages["reference"]
+	report.check("files are in one package", samePackage,
+		fmt.Sprintf("template: %s, tests: %s, reference: %s", packages["template"], packages["tests"], packages["reference"]))
+	if !samePackage {
+		return
+	}
+
+	challenge := &models.Challenge{Title: draft.Metadata.Title, Template: draft.Template, TestFile: draft.Tests}
+	var templateRun, referenceRun *CodeAnalysis
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		templateRun = as.executor.AnalyzeCode(draft.Template, challenge)
+	}()
+	go func() {
+		defer wg.Done()
+		referenceRun = as.executor.AnalyzeCode(draft.Reference, challenge)
+	}()
+	wg.Wait()
+
+	report.check("template compiles with the tests", templateRun.Compiled, strings.Join(buildErrors(templateRun), "; "))
+	if templateRun.Compiled {
+		passed, failed, _ := templateRun.Counts()
+		report.check("tests fail on the template", !templateRun.Passed && failed > 0,
+			fmt.Sprintf("%d passed, %d failed", passed, failed))
+	}
+	detail := strings.Join(buildErrors(referenceRun), "; ")
+	if referenceRun.Compiled {
+		var failures []string
+		for _, test := range referenceRun.FailedTests() {
+			failures = append(failures, test.Name+": "+test.Message)
+		}
+		passed, failed, _ := referenceRun.Counts()
+		detail = fmt.Sprintf("%d passed, %d failed", passed, failed)
+		if len(failures) > 0 {
+			detail += ": " + strings.Join(failures, "; ")
+		}
+	}
+	report.check("reference solution passes the tests", referenceRun.Passed, detail)
+}
+
+// loadPackage reads packages/<name>/package.json
+func (as *AuthoringService) loadPackage(name string) (*models.Package, error) {
+	if !isPlainName(name) {
+		return nil, fmt.Errorf("invalid package name %q", name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(as.workspaceService.Root(), "packages", name, "package.json"))
+	if err != nil {
+		return nil, fmt.Errorf("package %s not found", name)
+	}
+	var pkg models.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("invalid package.json for %s: %v", name, err)
+	}
+	if pkg.Name == "" {
+		pkg.Name = name
+	}
+	return &pkg, nil
+}
+
+// example returns the README and tests of an existing challenge, so drafts follow the
+// layout of the others: challenge-1, or the first challenge of the package
+func (as *AuthoringService) example(pkg *models.Package) string {
+	dir := filepath.Join(as.workspaceService.Root(), "challenge-1")
+	if pkg != nil {
+		if len(pkg.LearningPath) == 0 {
+			return ""
+		}
+		dir = filepath.Join(as.workspaceService.Root(), "packages", pkg.Name, pkg.LearningPath[0])
+	}
+
+	var b strings.Builder
+	for _, name := range []string{"README.md", "solution-template.go", "solution-template_test.go"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "--- %s ---\n%s\n", name, strings.TrimSpace(string(data)))
+	}
+	example := b.String()
+	if len(example) > maxExampleSize {
+		example = example[:maxExampleSize] + "\n..."
+	}
+	return example
+}
+
+var (
+	challengeDirPattern = regexp.MustCompile(`^challenge-(\d+)(-|$)`)
+	slugPattern         = regexp.MustCompile(`[^a-z0-9]+`)
+	headingPattern      = regexp.MustCompile(`(?m)^#\s+.*$`)
+)
+
+// write creates the challenge directory of a verified draft. The files are written to a
+// temporary directory first and renamed into place, so a failed write leaves nothing behind.
+func (as *AuthoringService) write(draft *ChallengeDraft, pkg *models.Package) (string, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	parent := as.workspaceService.Root()
+	if pkg != nil {
+		parent = filepath.Join(as.workspaceService.Root(), "packages", pkg.Name)
+	}
+	number := nextChallengeNumber(parent)
+
+	name := fmt.Sprintf("challenge-%d", number)
+	solutionName := SolutionFile
+	scoreboardTitle := name
+	readme := fmt.Sprintf("[View the Scoreboard](SCOREBOARD.md)\n\n# Challenge %d: %s\n", number, draft.Metadata.Title)
+	if pkg != nil {
+		slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(draft.Slug), "-"), "-")
+		if slug == "" {
+			slug = strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(draft.Metadata.Title), "-"), "-")
+		}
+		name += "-" + slug
+		solutionName = "solution.go"
+		scoreboardTitle = pkg.Name + " " + name + "\n"
+		readme = fmt.Sprintf("# Challenge %d: %s\n", number, draft.Metadata.Title)
+	}
+	// The heading carries the challenge number, which the model could not know
+	body := draft.Readme
+	if location := headingPattern.FindStringIndex(body); location != nil {
+		body = body[location[1]:]
+	}
+	readme += "\n" + strings.TrimLeft(body, "\n")
+
+	metadata := models.ChallengeMetadata{
+		Title:               draft.Metadata.Title,
+		Description:         draft.Metadata.Description,
+		ShortDescription:    draft.Metadata.ShortDescription,
+		Difficulty:          draft.Metadata.Difficulty,
+		EstimatedTime:       draft.Metadata.EstimatedTime,
+		LearningObjectives:  draft.Metadata.LearningObjectives,
+		Prerequisites:       draft.Metadata.Prerequisites,
+		Tags:                draft.Metadata.Tags,
+		RealWorldConnection: draft.Metadata.RealWorldConnection,
+		Requirements:        draft.Metadata.Requirements,
+		BonusPoints:         draft.Metadata.BonusPoints,
+		Order:               number,
+	}
+	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	files := map[string]string{
+		"README.md":                              strings.TrimRight(readme, "\n") + "\n",
+		"solution-template.go":                   draft.Template,
+		"solution-template_test.go":              draft.Tests,
+		"hints.md":                               draft.Hints,
+		"learning.md":                            draft.Learning,
+		"metadata.json":                          string(metadataJSON) + "\n",
+		"SCOREBOARD.md":                          fmt.Sprintf("# Scoreboard for %s\n| Username   | Passed Tests | Total Tests | Suite        |\n|------------|--------------|-------------|--------------|\n", scoreboardTitle),
+		filepath.Join("reference", solutionName): draft.Reference,
+	}
+	// go.mod, go.sum and run_tests.sh come from an existing challenge
+	sibling := filepath.Join(as.workspaceService.Root(), "challenge-1")
+	module := fmt.Sprintf("challenge%d", number)
+	if pkg != nil {
+		sibling = filepath.Join(parent, lastChallenge(parent, pkg))
+		module = fmt.Sprintf("%s-challenge-%d", pkg.Name, number)
+	}
+	files["go.mod"] = goModFrom(filepath.Join(sibling, "go.mod"), module)
+	for _, shared := range []string{"go.sum", "run_tests.sh"} {
+		if data, err := ioutil.ReadFile(filepath.Join(sibling, shared)); err == nil {
+			files[shared] = string(data)
+		}
+	}
+
+	target := filepath.Join(parent, name)
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	}
+	staging, err := ioutil.TempDir(parent, "."+name+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create challenge directory: %v", err)
+	}
+	defer os.RemoveAll(staging)
+	stagingRel, err := filepath.Rel(as.workspaceService.Root(), staging)
+	if err != nil {
+		return "", err
+	}
+	for file, content := range files {
+		path, err := as.workspaceService.WriteFile(filepath.Join(stagingRel, file), []byte(content))
+		if err != nil {
+			return "", fmt.Errorf("failed to write %s: %v", file, err)
+		}
+		if strings.HasSuffix(file, ".sh") {
+			if err := os.Chmod(path, 0755); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := os.Chmod(staging, 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(staging, target); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", target, err)
+	}
+
+	if pkg != nil {
+		if err := as.appendLearningPath(filepath.Join("packages", pkg.Name, "package.json"), name); err != nil {
+			return "", fmt.Errorf("wrote %s but could not add it to the learning path: %v", target, err)
+		}
+	}
+
+	relative, err := filepath.Rel(as.workspaceService.Root(), target)
+	if err != nil {
+		relative = target
+	}
+	return filepath.ToSlash(relative), nil
+}
+
+// nextChallengeNumber returns one more than the highest challenge-N in dir
+func nextChallengeNumber(dir string) int {
+	highest := 0
+	entries, _ := ioutil.ReadDir(dir)
+	for _, entry := range entries {
+		if match := challengeDirPattern.FindStringSubmatch(entry.Name()); match != nil && entry.IsDir() {
+			if n, err := strconv.Atoi(match[1]); err == nil && n > highest {
+				highest = n
+			}
+		}
+	}
+	return highest + 1
+}
+
+// lastChallenge returns the last challenge of the package's learning path that exists
+func lastChallenge(dir string, pkg *models.Package) string {
+	for i := len(pkg.LearningPath) - 1; i >= 0; i-- {
+		if info, err := os.Stat(filepath.Join(dir, pkg.LearningPath[i])); err == nil && info.IsDir() {
+			return pkg.LearningPath[i]
+		}
+	}
+	return ""
+}
+
+// goModFrom copies a go.mod under a new module path
+func goModFrom(path, module string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("module %s\n\ngo 1.21\n", module)
+	}
+	return regexp.MustCompile(`(?m)^module\s+\S+`).ReplaceAllString(string(data), "module "+module)
+}
+
+// appendLearningPath adds a challenge to the end of the learning_path in package.json (rel
+// to the repository root), editing the text so the rest of the file keeps its layout. The
+// file is replaced atomically, so a failed write leaves the old manifest in place.
+func (as *AuthoringService) appendLearningPath(rel, challenge string) error {
+	data, err := ioutil.ReadFile(filepath.Join(as.workspaceService.Root(), rel))
+	if err != nil {
+		return err
+	}
+	text := string(data)
+	location := regexp.MustCompile(`"learning_path"\s*:\s*\[`).FindStringIndex(text)
+	if location == nil {
+		return errors.New("package.json has no learning_path")
+	}
+	end := strings.Index(text[location[1]:], "]")
+	if end == -1 {
+		return errors.New("package.json has an unterminated learning_path")
+	}
+	end += location[1]
+
+	body := strings.TrimRight(text[location[1]:end], " \t\n")
+	entry := strconv.Quote(challenge)
+	if strings.TrimSpace(body) != "" {
+		// Indent like the previous entry
+		indent := "\n    "
+		if i := strings.LastIndex(body, "\n"); i != -1 {
+			line := body[i:]
+			indent = line[:len(line)-len(strings.TrimLeft(line, "\n \t"))]
+		}
+		entry = "," + indent + entry
+	}
+	updated := text[:location[1]] + body + entry + "\n  " + text[end:]
+
+	var check models.Package
+	if err := json.Unmarshal([]byte(updated), &check); err != nil {
+		return fmt.Errorf("updated package.json would be invalid: %v", err)
+	}
+	_, err = as.workspaceService.WriteFile(rel, []byte(updated))
+	return err
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"web-ui/internal/models"
+)
+
+func TestAppendLearningPath(t *testing.T) {
+	ws := newTestWorkspace(t)
+	as := NewAuthoringService(ws, nil, nil)
+
+	rel := filepath.Join("packages", "gin", "package.json")
+	manifest := "{\n  \"name\": \"gin\",\n  \"learning_path\": [\n    \"challenge-1-basic-routing\"\n  ],\n  \"version\": \"v1\"\n}\n"
+	if err := os.WriteFile(filepath.Join(ws.Root(), rel), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := as.appendLearningPath(rel, "challenge-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(ws.Root(), rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pkg models.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		t.Fatalf("package.json is no longer valid: %v\n%s", err, data)
+	}
+	if len(pkg.LearningPath) != 2 || pkg.LearningPath[1] != "challenge-2" || pkg.Version != "v1" {
+		t.Fatalf("package = %+v", pkg)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(filepath.Join(ws.Root(), rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "package.json" && entry.Name() != "challenge-1-basic-routing" {
+			t.Fatalf("left %s behind", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// benchmarkTime is how long each benchmark runs; shorter than go test's default
+// second so a challenge with several benchmarks answers quickly
+const benchmarkTime = "500ms"
+
+// gomaxprocsSuffix is the "-8" go test appends to benchmark names
+var gomaxprocsSuffix = regexp.MustCompile(`-\d+$`)
+
+// Benchmark is one line of `go test -bench` output
+type Benchmark struct {
+	Name        string  `json:"name"`
+	Iterations  int64   `json:"iterations"`
+	NsPerOp     float64 `json:"nsPerOp"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+}
+
+// RunBenchmarks runs the benchmarks of the challenge's test file against code. A test
+// file without benchmarks gives an empty result.
+func (es *ExecutionService) RunBenchmarks(code string, challenge *models.Challenge) ([]Benchmark, error) {
+	if !strings.Contains(challenge.TestFile, "func Benchmark") {
+		return []Benchmark{}, nil
+	}
+
+	es.mutex.Lock()
+	es.running++
+	es.mutex.Unlock()
+	defer func() {
+		es.mutex.Lock()
+		es.running--
+		es.mutex.Unlock()
+	}()
+
+	tempDir, err := es.prepareWorkspace(code, challenge)
+	if tempDir != "" {
+		defer os.RemoveAll(tempDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), analysisTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", "test", "-run", "^$", "-bench", ".", "-benchmem",
+		"-benchtime="+benchmarkTime, "-count=1", fmt.Sprintf("-timeout=%s", analysisTimeout))
+	cmd.Dir = tempDir
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("benchmarks did not finish within %s", analysisTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("benchmarks failed: %s", strings.TrimSpace(string(output)))
+	}
+	return parseBenchmarks(output), nil
+}
+
+// parseBenchmarks reads "BenchmarkX-8  1000  1234 ns/op  56 B/op  2 allocs/op" lines
+func parseBenchmarks(output []byte) []Benchmark {
+	benchmarks := []Benchmark{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		iterations, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		benchmark := Benchmark{Name: gomaxprocsSuffix.ReplaceAllString(fields[0], ""), Iterations: iterations}
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				benchmark.NsPerOp = value
+			case "B/op":
+				benchmark.BytesPerOp = int64(value)
+			case "allocs/op":
+				benchmark.AllocsPerOp = int64(value)
+			}
+		}
+		benchmarks = append(benchmarks, benchmark)
+	}
+	return benchmarks
+}
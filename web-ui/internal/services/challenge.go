@@ -1,20 +1,46 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/url"
+	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"web-ui/internal/models"
 )
 
+// Difficulties are the difficulty levels a challenge can have
+var Difficulties = []string{"Beginner", "Intermediate", "Advanced"}
+
+// IsDifficulty reports whether difficulty is one of Difficulties
+func IsDifficulty(difficulty string) bool {
+	return containsString(Difficulties, difficulty)
+}
+
+// defaultDifficulty is used for challenges without a valid difficulty in metadata.json
+const defaultDifficulty = "Intermediate"
+
+// Shown in place of a missing learning.md or hints.md
+const (
+	noLearningMaterials = "*No learning materials available for this challenge yet.*"
+	noHints             = "*No hints available for this challenge yet.*"
+)
+
+// titlePattern matches the first heading of a README, e.g. "# Challenge 3: Employee Data Management"
+var titlePattern = regexp.MustCompile(`(?m)^\s*#\s+(?:Challenge\s+\d+:\s*)?(.+?)\s*$`)
+
 // ChallengeService handles challenge-related operations
 type ChallengeService struct {
 	challenges models.ChallengeMap
+	mutex      sync.RWMutex
 }
 
 // NewChallengeService creates a new challenge service
@@ -24,8 +50,10 @@ func NewChallengeService() *ChallengeService {
 	}
 }
 
-// LoadChallenges loads all challenges from the filesystem
+// LoadChallenges loads all challenges from the filesystem, replacing any previously loaded set
 func (cs *ChallengeService) LoadChallenges() error {
+	challenges := make(models.ChallengeMap)
+
 	// Find challenge directories (challenge-1, challenge-2, etc.)
 	challengeDirs, err := filepath.Glob("../challenge-*")
 	if err != nil {
@@ -51,10 +79,14 @@ func (cs *ChallengeService) LoadChallenges() error {
 			continue
 		}
 
-		cs.challenges[id] = challenge
+		challenges[id] = challenge
 	}
 
-	log.Printf("Loaded %d challenges", len(cs.challenges))
+	cs.mutex.Lock()
+	cs.challenges = challenges
+	cs.mutex.Unlock()
+
+	log.Printf("Loaded %d challenges", len(challenges))
 	return nil
 }
 
@@ -67,11 +99,26 @@ func (cs *ChallengeService) loadSingleChallenge(id int, dir string) (*models.Cha
 		return nil, fmt.Errorf("could not read README: %v", err)
 	}
 
-	// Extract title from README (first heading)
-	title := cs.extractTitle(string(readmeContent), id)
-
-	// Determine difficulty level
-	difficulty := cs.determineDifficulty(id)
+	// Title, difficulty and tags come from metadata.json, the title falling back to the
+	// README's first heading
+	metadata, err := readChallengeMetadata(dir)
+	if err != nil {
+		log.Printf("Warning: Could not read metadata for challenge %d: %v", id, err)
+	}
+	if metadata == nil {
+		metadata = &models.ChallengeMetadata{}
+	}
+	title := strings.TrimSpace(metadata.Title)
+	if title == "" {
+		title = cs.extractTitle(string(readmeContent), id)
+	}
+	difficulty := metadata.Difficulty
+	if !IsDifficulty(difficulty) {
+		if difficulty != "" {
+			log.Printf("Warning: Challenge %d has unknown difficulty %q", id, difficulty)
+		}
+		difficulty = defaultDifficulty
+	}
 
 	// Read solution template
 	templatePath := filepath.Join(dir, "solution-template.go")
@@ -89,28 +136,35 @@ func (cs *ChallengeService) loadSingleChallenge(id int, dir string) (*models.Cha
 
 	// Read learning materials if available
 	learningPath := filepath.Join(dir, "learning.md")
-	learningContent := []byte("*No learning materials available for this challenge yet.*")
+	learningContent := []byte(noLearningMaterials)
 	if learningFileContent, err := ioutil.ReadFile(learningPath); err == nil {
 		learningContent = learningFileContent
 	}
 
 	// Read hints if available
 	hintsPath := filepath.Join(dir, "hints.md")
-	hintsContent := []byte("*No hints available for this challenge yet.*")
+	hintsContent := []byte(noHints)
 	if hintsFileContent, err := ioutil.ReadFile(hintsPath); err == nil {
 		hintsContent = hintsFileContent
 	}
 
 	// Create challenge
 	challenge := &models.Challenge{
-		ID:                id,
-		Title:             title,
-		Description:       cs.filterWebUIDescription(string(readmeContent)),
-		Difficulty:        difficulty,
-		Template:          string(templateContent),
-		TestFile:          string(testContent),
-		LearningMaterials: string(learningContent),
-		Hints:             string(hintsContent),
+		ID:                 id,
+		Title:              title,
+		Description:        filterWebUIDescription(string(readmeContent)),
+		Difficulty:         difficulty,
+		Template:           string(templateContent),
+		TestFile:           string(testContent),
+		SuiteVersion:       SuiteVersion(string(testContent)),
+		LearningMaterials:  string(learningContent),
+		Hints:              string(hintsContent),
+		ShortDescription:   metadata.ShortDescription,
+		EstimatedTime:      metadata.EstimatedTime,
+		LearningObjectives: metadata.LearningObjectives,
+		Prerequisites:      metadata.Prerequisites,
+		Tags:               metadata.Tags,
+		Translations:       loadTranslations(dir, metadata),
 	}
 
 	return challenge, nil
@@ -118,33 +172,31 @@ func (cs *ChallengeService) loadSingleChallenge(id int, dir string) (*models.Cha
 
 // extractTitle extracts the title from README content
 func (cs *ChallengeService) extractTitle(readmeContent string, id int) string {
-	titleRe := regexp.MustCompile(`#\s+(.+)`)
-	titleMatch := titleRe.FindStringSubmatch(readmeContent)
-
-	if len(titleMatch) >= 2 {
-		title := titleMatch[1]
-		// Clean up the title - remove "Challenge X: " prefix if present
-		cleanTitle := regexp.MustCompile(`^Challenge\s+\d+:\s+`).ReplaceAllString(title, "")
-		return cleanTitle
+	if match := titlePattern.FindStringSubmatch(readmeContent); match != nil {
+		return match[1]
 	}
-
 	return fmt.Sprintf("Challenge %d", id)
 }
 
-// determineDifficulty determines the difficulty level based on challenge ID
-func (cs *ChallengeService) determineDifficulty(id int) string {
-	switch {
-	case id <= 3 || id == 6 || id == 18 || id == 21 || id == 22:
-		return "Beginner"
-	case id == 4 || id == 5 || id == 7 || id == 10 || id == 13 || id == 14 || id == 16 || id == 17 || id == 19 || id == 20 || id == 23 || id == 27 || id == 30:
-		return "Intermediate"
-	default:
-		return "Advanced"
+// readChallengeMetadata reads a challenge directory's metadata.json. It returns nil
+// without an error when the file does not exist.
+func readChallengeMetadata(dir string) (*models.ChallengeMetadata, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "metadata.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	var metadata models.ChallengeMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata.json: %v", err)
+	}
+	return &metadata, nil
 }
 
 // filterWebUIDescription removes manual instructions that are not relevant for web-ui users
-func (cs *ChallengeService) filterWebUIDescription(content string) string {
+func filterWebUIDescription(content string) string {
 	lines := strings.Split(content, "\n")
 	var filteredLines []string
 	skipSection := false
@@ -181,11 +233,88 @@ func (cs *ChallengeService) filterWebUIDescription(content string) string {
 
 // GetChallenges returns all challenges
 func (cs *ChallengeService) GetChallenges() models.ChallengeMap {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
 	return cs.challenges
 }
 
+// ChallengeFilter selects challenges by difficulty and tag; empty fields match everything
+type ChallengeFilter struct {
+	Difficulty string
+	Tag        string
+}
+
+// ParseChallengeFilter reads the difficulty and tag query parameters
+func ParseChallengeFilter(query url.Values) ChallengeFilter {
+	return ChallengeFilter{
+		Difficulty: strings.TrimSpace(query.Get("difficulty")),
+		Tag:        strings.TrimSpace(query.Get("tag")),
+	}
+}
+
+// Matches reports whether a challenge passes the filter
+func (f ChallengeFilter) Matches(challenge *models.Challenge) bool {
+	if f.Difficulty != "" && !strings.EqualFold(challenge.Difficulty, f.Difficulty) {
+		return false
+	}
+	if f.Tag == "" {
+		return true
+	}
+	for _, tag := range challenge.Tags {
+		if strings.EqualFold(tag, f.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterChallenges returns the challenges that pass the filter, ordered by ID
+func (cs *ChallengeService) FilterChallenges(filter ChallengeFilter) []*models.Challenge {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	var challenges []*models.Challenge
+	for _, challenge := range cs.challenges {
+		if filter.Matches(challenge) {
+			challenges = append(challenges, challenge)
+		}
+	}
+	sort.Slice(challenges, func(i, j int) bool { return challenges[i].ID < challenges[j].ID })
+	return challenges
+}
+
+// Tags returns every tag used by a challenge, sorted
+func (cs *ChallengeService) Tags() []string {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	seen := make(map[string]bool)
+	var tags []string
+	for _, challenge := range cs.challenges {
+		for _, tag := range challenge.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
 // GetChallenge returns a specific challenge by ID
 func (cs *ChallengeService) GetChallenge(id int) (*models.Challenge, bool) {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
 	challenge, exists := cs.challenges[id]
 	return challenge, exists
 }
+
+// Languages returns the languages classic challenges are translated into, sorted
+func (cs *ChallengeService) Languages() []string {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	var lists [][]string
+	for _, challenge := range cs.challenges {
+		lists = append(lists, translationLanguages(challenge.Translations))
+	}
+	return mergeLanguages(lists...)
+}
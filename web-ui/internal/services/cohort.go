@@ -0,0 +1,476 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/utils"
+)
+
+// CohortsFileName is the cohorts definition file at the repository root
+const CohortsFileName = "cohorts.json"
+
+// cohortDateLayout is the due date format used in cohorts.json
+const cohortDateLayout = "2006-01-02"
+
+// ErrCohortNotFound is returned when a cohort ID does not exist
+var ErrCohortNotFound = errors.New("cohort not found")
+
+// CohortService manages cohort definitions and computes cohort progress
+type CohortService struct {
+	cohorts            map[string]*models.Cohort
+	mutex              sync.RWMutex
+	workspaceService   *WorkspaceService
+	challengeService   *ChallengeService
+	userService        *UserService
+	packageService     *PackageService
+	leaderboardService *LeaderboardService
+}
+
+// NewCohortService creates a new cohort service
+func NewCohortService(
+	workspaceService *WorkspaceService,
+	challengeService *ChallengeService,
+	userService *UserService,
+	packageService *PackageService,
+	leaderboardService *LeaderboardService,
+) *CohortService {
+	return &CohortService{
+		cohorts:            make(map[string]*models.Cohort),
+		workspaceService:   workspaceService,
+		challengeService:   challengeService,
+		userService:        userService,
+		packageService:     packageService,
+		leaderboardService: leaderboardService,
+	}
+}
+
+// LoadCohorts reads cohorts.json from the repository root. A missing file means no cohorts.
+func (cs *CohortService) LoadCohorts() error {
+	content, err := os.ReadFile(filepath.Join(cs.workspaceService.Root(), CohortsFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", CohortsFileName, err)
+	}
+
+	var file models.CohortsFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", CohortsFileName, err)
+	}
+
+	cohorts := make(map[string]*models.Cohort)
+	for _, cohort := range file.Cohorts {
+		if err := cs.normalizeCohort(cohort); err != nil {
+			log.Printf("Warning: skipping cohort %q: %v", cohort.ID, err)
+			continue
+		}
+		cohorts[cohort.ID] = cohort
+	}
+
+	cs.mutex.Lock()
+	cs.cohorts = cohorts
+	cs.mutex.Unlock()
+
+	log.Printf("Loaded %d cohorts", len(cohorts))
+	return nil
+}
+
+// normalizeCohort validates a cohort against the loaded challenges and cleans up its members
+func (cs *CohortService) normalizeCohort(cohort *models.Cohort) error {
+	if cohort == nil {
+		return errors.New("empty cohort")
+	}
+
+	// Cohorts share the ID and member rules of teams
+	team := &models.Team{ID: cohort.ID, Name: cohort.Name, Members: cohort.Members}
+	if err := normalizeTeam(team); err != nil {
+		return err
+	}
+	cohort.ID, cohort.Name, cohort.Members = team.ID, team.Name, team.Members
+
+	if len(cohort.Assignments) == 0 {
+		return errors.New("cohort has no assignments")
+	}
+
+	challenges := cs.challengeService.GetChallenges()
+	packages := cs.packageService.GetPackages()
+	for i := range cohort.Assignments {
+		assignment := &cohort.Assignments[i]
+		if _, err := parseDueDate(assignment.DueDate); err != nil {
+			return fmt.Errorf("assignment %d: invalid dueDate %q (use YYYY-MM-DD)", i+1, assignment.DueDate)
+		}
+
+		for _, id := range assignment.Challenges {
+			if _, ok := challenges[id]; !ok {
+				return fmt.Errorf("assignment %d: unknown challenge %d", i+1, id)
+			}
+		}
+
+		if assignment.Package == "" {
+			if len(assignment.PackageChallenges) > 0 {
+				return fmt.Errorf("assignment %d: packageChallenges requires package", i+1)
+			}
+			if len(assignment.Challenges) == 0 {
+				return fmt.Errorf("assignment %d: assigns no challenges", i+1)
+			}
+			continue
+		}
+
+		if _, ok := packages[assignment.Package]; !ok {
+			return fmt.Errorf("assignment %d: unknown package %q", i+1, assignment.Package)
+		}
+		_, pkgChallenges, err := cs.leaderboardService.PackageChallenges(assignment.Package)
+		if err != nil {
+			return fmt.Errorf("assignment %d: %v", i+1, err)
+		}
+		known := make(map[string]bool)
+		for _, challenge := range pkgChallenges {
+			known[challenge.ID] = true
+		}
+		for _, id := range assignment.PackageChallenges {
+			if !known[id] {
+				return fmt.Errorf("assignment %d: unknown %s challenge %q", i+1, assignment.Package, id)
+			}
+		}
+	}
+	return nil
+}
+
+// parseDueDate parses a YYYY-MM-DD due date and returns the end of that day in local time
+func parseDueDate(value string) (time.Time, error) {
+	day, err := time.ParseInLocation(cohortDateLayout, strings.TrimSpace(value), time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// GetCohorts returns all cohorts sorted by name
+func (cs *CohortService) GetCohorts() []*models.Cohort {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	cohorts := make([]*models.Cohort, 0, len(cs.cohorts))
+	for _, cohort := range cs.cohorts {
+		cohorts = append(cohorts, cohort)
+	}
+	sort.Slice(cohorts, func(i, j int) bool {
+		return cohorts[i].Name < cohorts[j].Name
+	})
+	return cohorts
+}
+
+// GetCohort returns a cohort by ID
+func (cs *CohortService) GetCohort(id string) (*models.Cohort, bool) {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	cohort, ok := cs.cohorts[id]
+	return cohort, ok
+}
+
+// SaveCohort creates or replaces a cohort and persists cohorts.json
+func (cs *CohortService) SaveCohort(cohort *models.Cohort) error {
+	if err := cs.normalizeCohort(cohort); err != nil {
+		return err
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	previous, existed := cs.cohorts[cohort.ID]
+	cs.cohorts[cohort.ID] = cohort
+	if err := cs.persistLocked(); err != nil {
+		// Roll back so memory matches disk
+		if existed {
+			cs.cohorts[cohort.ID] = previous
+		} else {
+			delete(cs.cohorts, cohort.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteCohort removes a cohort and persists cohorts.json
+func (cs *CohortService) DeleteCohort(id string) error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	previous, ok := cs.cohorts[id]
+	if !ok {
+		return ErrCohortNotFound
+	}
+	delete(cs.cohorts, id)
+	if err := cs.persistLocked(); err != nil {
+		cs.cohorts[id] = previous
+		return err
+	}
+	return nil
+}
+
+// persistLocked writes cohorts.json; callers must hold the write lock
+func (cs *CohortService) persistLocked() error {
+	file := models.CohortsFile{Cohorts: make([]*models.Cohort, 0, len(cs.cohorts))}
+	for _, cohort := range cs.cohorts {
+		file.Cohorts = append(file.Cohorts, cohort)
+	}
+	sort.Slice(file.Cohorts, func(i, j int) bool {
+		return file.Cohorts[i].ID < file.Cohorts[j].ID
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = cs.workspaceService.WriteFile(CohortsFileName, append(data, '\n'))
+	return err
+}
+
+// items expands a cohort's assignments into matrix columns. A challenge assigned
+// more than once keeps its earliest due date.
+func (cs *CohortService) items(cohort *models.Cohort, now time.Time) []models.CohortItem {
+	challenges := cs.challengeService.GetChallenges()
+	byKey := make(map[string]models.CohortItem)
+
+	add := func(item models.CohortItem, dueDate string) {
+		due, err := parseDueDate(dueDate)
+		if err != nil {
+			return
+		}
+		if existing, ok := byKey[item.Key]; ok && !due.Before(existing.Due) {
+			return
+		}
+		item.DueDate = dueDate
+		item.Due = due
+		item.Overdue = now.After(due)
+		byKey[item.Key] = item
+	}
+
+	for _, assignment := range cohort.Assignments {
+		for _, id := range assignment.Challenges {
+			item := models.CohortItem{
+				Key:         fmt.Sprintf("challenge-%d", id),
+				Title:       fmt.Sprintf("Challenge %d", id),
+				ChallengeID: id,
+			}
+			if challenge, ok := challenges[id]; ok && challenge.Title != "" {
+				item.Title = challenge.Title
+			}
+			add(item, assignment.DueDate)
+		}
+
+		if assignment.Package == "" {
+			continue
+		}
+		pkg, pkgChallenges, err := cs.leaderboardService.PackageChallenges(assignment.Package)
+		if err != nil {
+			continue
+		}
+		wanted := make(map[string]bool)
+		for _, id := range assignment.PackageChallenges {
+			wanted[id] = true
+		}
+		for _, challenge := range pkgChallenges {
+			if len(wanted) > 0 && !wanted[challenge.ID] {
+				continue
+			}
+			item := models.CohortItem{
+				Key:       assignment.Package + "/" + challenge.ID,
+				Title:     challenge.Title,
+				Package:   assignment.Package,
+				PackageID: challenge.ID,
+			}
+			if info, ok := pkg.ChallengeDetails[challenge.ID]; ok && info.Title != "" {
+				item.Title = info.Title
+			}
+			add(item, assignment.DueDate)
+		}
+	}
+
+	result := make([]models.CohortItem, 0, len(byKey))
+	for _, item := range byKey {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Due.Equal(result[j].Due) {
+			return result[i].Due.Before(result[j].Due)
+		}
+		// Classic challenges before packages, then by number/name
+		if (result[i].Package == "") != (result[j].Package == "") {
+			return result[i].Package == ""
+		}
+		if result[i].Package == "" {
+			return result[i].ChallengeID < result[j].ChallengeID
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// scoreboardRows indexes an item's scoreboard by lowercase username
+func scoreboardRows(item models.CohortItem) map[string]ScoreboardRow {
+	var rows []ScoreboardRow
+	if item.Package == "" {
+		rows = ReadClassicScoreboard(item.ChallengeID)
+	} else {
+		rows = ReadPackageScoreboard(item.Package, item.PackageID)
+	}
+
+	result := make(map[string]ScoreboardRow, len(rows))
+	for _, row := range rows {
+		result[strings.ToLower(row.Username)] = row
+	}
+	return result
+}
+
+// Progress computes the member x assigned-challenge matrix for a cohort.
+// An item counts as completed only when every test passed, the same criterion as the main leaderboard.
+func (cs *CohortService) Progress(id string) (*models.CohortProgress, error) {
+	cohort, ok := cs.GetCohort(id)
+	if !ok {
+		return nil, ErrCohortNotFound
+	}
+
+	now := time.Now()
+	items := cs.items(cohort, now)
+	rows := make([]map[string]ScoreboardRow, len(items))
+	for i, item := range items {
+		rows[i] = scoreboardRows(item)
+	}
+
+	repoRoot := cs.workspaceService.Root()
+	var pathspecs []string
+	for _, member := range cohort.Members {
+		pathspecs = append(pathspecs,
+			fmt.Sprintf(":(glob)challenge-*/submissions/%s/*", member),
+			fmt.Sprintf(":(glob)packages/*/*/submissions/%s/*", member),
+		)
+	}
+	commitDates := utils.GetFileCommitDates(repoRoot, pathspecs...)
+
+	challenges := cs.challengeService.GetChallenges()
+	progress := &models.CohortProgress{
+		Cohort:      cohort,
+		Items:       items,
+		GeneratedAt: now,
+	}
+
+	for _, member := range cohort.Members {
+		key := strings.ToLower(member)
+		attempts := cs.userService.GetUserAttempts(member, challenges)
+		memberProgress := models.CohortMemberProgress{
+			Username: member,
+			Cells:    make([]models.CohortCell, len(items)),
+		}
+
+		for i, item := range items {
+			row, hasRow := rows[i][key]
+			cell := models.CohortCell{}
+			if hasRow && row.Total > 0 {
+				cell.Score = (row.Passed * 100) / row.Total
+			}
+
+			attempted := hasRow
+			if item.Package == "" {
+				attempted = attempted || attempts.AttemptedIDs[item.ChallengeID]
+			}
+
+			if hasRow && row.Solved() {
+				if item.Package == "" {
+					rel := path.Join(item.Key, "submissions", member, "solution-template.go")
+					cell.CompletedAt = submissionDate(repoRoot, rel, commitDates)
+				} else {
+					cell.CompletedAt = packageSubmissionDate(repoRoot, item.Package, item.PackageID, member, commitDates)
+				}
+				cell.Status = models.CohortStatusCompleted
+				if cell.CompletedAt != nil && cell.CompletedAt.After(item.Due) {
+					cell.Status = models.CohortStatusLate
+				}
+			} else if item.Overdue {
+				cell.Status = models.CohortStatusMissing
+			} else if attempted {
+				cell.Status = models.CohortStatusInProgress
+			} else {
+				cell.Status = models.CohortStatusPending
+			}
+
+			switch cell.Status {
+			case models.CohortStatusCompleted:
+				memberProgress.Completed++
+			case models.CohortStatusLate:
+				memberProgress.Late++
+			case models.CohortStatusMissing:
+				memberProgress.Missing++
+			case models.CohortStatusInProgress:
+				memberProgress.InProgress++
+			}
+			memberProgress.Cells[i] = cell
+		}
+
+		if len(items) > 0 {
+			memberProgress.Progress = (memberProgress.Completed + memberProgress.Late) * 100 / len(items)
+		}
+		progress.Members = append(progress.Members, memberProgress)
+	}
+
+	return progress, nil
+}
+
+// WriteProgressCSV writes the progress matrix as CSV: one row per member, one column per assigned challenge
+func WriteProgressCSV(w io.Writer, progress *models.CohortProgress) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"username"}
+	due := []string{"due"}
+	for _, item := range progress.Items {
+		header = append(header, item.Key)
+		due = append(due, item.DueDate)
+	}
+	header = append(header, "completed", "late", "missing", "in_progress", "progress_percent")
+	due = append(due, "", "", "", "", "")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.Write(due); err != nil {
+		return err
+	}
+
+	for _, member := range progress.Members {
+		record := []string{member.Username}
+		for _, cell := range member.Cells {
+			value := cell.Status
+			if cell.CompletedAt != nil {
+				value += " " + cell.CompletedAt.Format(cohortDateLayout)
+			}
+			record = append(record, value)
+		}
+		record = append(record,
+			strconv.Itoa(member.Completed),
+			strconv.Itoa(member.Late),
+			strconv.Itoa(member.Missing),
+			strconv.Itoa(member.InProgress),
+			strconv.Itoa(member.Progress),
+		)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,110 @@
+package services
+
+import "strings"
+
+// maxDiffLines bounds the inputs of DiffLines, whose table grows with the product of their lengths
+const maxDiffLines = 3000
+
+// Diff row kinds
+const (
+	DiffEqual   = "equal"
+	DiffRemoved = "removed" // only on the left
+	DiffAdded   = "added"   // only on the right
+	DiffChanged = "changed" // a removed line shown next to the added line that replaced it
+)
+
+// DiffRow is one row of a side-by-side diff. Line numbers are 1-based and 0 on the side
+// the row does not exist on.
+type DiffRow struct {
+	Kind      string `json:"kind"`
+	Left      string `json:"left"`
+	Right     string `json:"right"`
+	LeftLine  int    `json:"leftLine"`
+	RightLine int    `json:"rightLine"`
+}
+
+// CodeDiff is a side-by-side line diff
+type CodeDiff struct {
+	Rows    []DiffRow `json:"rows"`
+	Added   int       `json:"added"`   // lines only on the right
+	Removed int       `json:"removed"` // lines only on the left
+}
+
+// DiffLines computes a side-by-side diff from a longest common subsequence of lines.
+// Runs of removed lines followed by added lines are paired up into changed rows.
+func DiffLines(left, right string) *CodeDiff {
+	a := splitLines(left)
+	b := splitLines(right)
+	if len(a) > maxDiffLines {
+		a = a[:maxDiffLines]
+	}
+	if len(b) > maxDiffLines {
+		b = b[:maxDiffLines]
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := &CodeDiff{Rows: []DiffRow{}}
+	var removed, added []DiffRow
+	flush := func() {
+		for k := 0; k < len(removed) || k < len(added); k++ {
+			switch {
+			case k < len(removed) && k < len(added):
+				diff.Rows = append(diff.Rows, DiffRow{
+					Kind: DiffChanged, Left: removed[k].Left, LeftLine: removed[k].LeftLine,
+					Right: added[k].Right, RightLine: added[k].RightLine,
+				})
+			case k < len(removed):
+				diff.Rows = append(diff.Rows, removed[k])
+			default:
+				diff.Rows = append(diff.Rows, added[k])
+			}
+		}
+		diff.Removed += len(removed)
+		diff.Added += len(added)
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			diff.Rows = append(diff.Rows, DiffRow{Kind: DiffEqual, Left: a[i], Right: b[j], LeftLine: i + 1, RightLine: j + 1})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, DiffRow{Kind: DiffRemoved, Left: a[i], LeftLine: i + 1})
+			i++
+		default:
+			added = append(added, DiffRow{Kind: DiffAdded, Right: b[j], RightLine: j + 1})
+			j++
+		}
+	}
+	flush()
+	return diff
+}
+
+// splitLines splits text into lines without line terminators or a trailing empty line
+func splitLines(text string) []string {
+	text = strings.TrimRight(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// maxPromptTests limits how much of the challenge's own test file goes into the edge-case prompt
+const maxPromptTests = 6000
+
+// EdgeCaseProposal is the model's answer to the edge-case prompt
+type EdgeCaseProposal struct {
+	TestCode string         `json:"test_code"` // a complete Go test file
+	Cases    []ProposedCase `json:"cases"`
+}
+
+// ProposedCase explains one generated subtest
+type ProposedCase struct {
+	Name      string `json:"name"`      // the subtest name passed to t.Run
+	Rationale string `json:"rationale"` // what the case probes
+}
+
+// edgeCaseSchema is the shape of EdgeCaseProposal for structured output
+var edgeCaseSchema = SchemaFor(EdgeCaseProposal{})
+
+// EdgeCaseResult is one generated test case and what happened when it ran
+type EdgeCaseResult struct {
+	Name      string `json:"name"` // full subtest name, e.g. TestEdgeCases/empty_input
+	Rationale string `json:"rationale,omitempty"`
+	// "counterexample" (the reference passes, your code fails), "passed", "invalid" (the
+	// reference fails it, so the expectation is wrong) or "not_run" (your code did not build)
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"` // the failure output for counterexamples and invalid cases
+}
+
+// EdgeCaseReport is the outcome of challenging a solution with generated tests
+type EdgeCaseReport struct {
+	Cases           []EdgeCaseResult `json:"cases"`
+	Counterexamples int              `json:"counterexamples"`
+	Discarded       int              `json:"discarded"`          // cases that failed against the reference
+	Rejected        []string         `json:"rejected,omitempty"` // why the generated tests could not be used at all
+	CodeError       string           `json:"code_error,omitempty"`
+	Reference       string           `json:"reference"` // where the reference solution came from
+	TestCode        string           `json:"test_code"`
+	PromptVersion   string           `json:"prompt_version,omitempty"`
+}
+
+// ChallengeSolution asks the model for table-driven edge-case tests for the challenge,
+// checks that they compile against the template's exported API and pass against a
+// reference solution, and runs the survivors against the user's code. Cases the user's
+// code fails are concrete counterexamples.
+func (ai *AIService) ChallengeSolution(user, code string, challenge *models.Challenge) (*EdgeCaseReport, error) {
+	if !ai.providers[FeatureReview].Ready() {
+		return nil, ErrAINotConfigured
+	}
+	if ai.executor == nil || ai.references == nil || challenge.TestFile == "" || challenge.Template == "" {
+		return nil, errors.New("this challenge cannot run generated tests")
+	}
+
+	reference, err := ai.references.Get(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	request, version, err := ai.newRequest(PromptEdgeCases, PromptData{
+		Challenge:     challenge,
+		Header:        challengeHeader(challenge),
+		Description:   truncateDescription(challenge.Description),
+		Code:          code,
+		API:           exportedAPI(challenge.Template),
+		ExistingTests: truncateTests(challenge.TestFile),
+	}, true /* expectJSON */)
+	if err != nil {
+		return nil, err
+	}
+	request.Schema = edgeCaseSchema
+	request.SchemaName = "edge_cases"
+
+	response, err := ai.callLLM(context.Background(), user, FeatureReview, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EdgeCaseReport{Cases: []EdgeCaseResult{}, Reference: reference.Source, PromptVersion: version}
+	proposal, problems := decodeEdgeCases(response)
+	if len(problems) > 0 {
+		report.Rejected = problems
+		return report, nil
+	}
+	report.TestCode = proposal.TestCode
+	if problems := checkGeneratedTests(proposal.TestCode, challenge.Template); len(problems) > 0 {
+		report.Rejected = problems
+		return report, nil
+	}
+
+	// The generated file replaces the challenge tests for these runs
+	generated := *challenge
+	generated.TestFile = proposal.TestCode
+
+	var apiRun, referenceRun, userRun *CodeAnalysis
+	var wg sync.WaitGroup
+	for _, run := range []struct {
+		code   string
+		result **CodeAnalysis
+	}{{challenge.Template, &apiRun}, {reference.Code, &referenceRun}, {code, &userRun}} {
+		wg.Add(1)
+		go func(code string, result **CodeAnalysis) {
+			defer wg.Done()
+			*result = ai.executor.AnalyzeCode(code, &generated)
+		}(run.code, run.result)
+	}
+	wg.Wait()
+
+	if !apiRun.Compiled || !referenceRun.Compiled {
+		against, run := "the challenge template", apiRun
+		if apiRun.Compiled {
+			against, run = "the reference solution", referenceRun
+		}
+		report.Rejected = append(report.Rejected, fmt.Sprintf("the generated tests do not compile against %s", against))
+		report.Rejected = append(report.Rejected, buildErrors(run)...)
+		return report, nil
+	}
+	if !userRun.Compiled || userRun.Error != "" {
+		report.CodeError = "your code could not be tested"
+		if errs := buildErrors(userRun); len(errs) > 0 {
+			report.CodeError = "your code does not compile with the generated tests: " + strings.Join(errs, "; ")
+		} else if userRun.Error != "" {
+			report.CodeError = userRun.Error
+		}
+	}
+
+	rationales := make(map[string]string)
+	for _, proposed := range proposal.Cases {
+		rationales[strings.ReplaceAll(strings.TrimSpace(proposed.Name), " ", "_")] = proposed.Rationale
+	}
+	userOutcomes := make(map[string]TestOutcome)
+	for _, test := range userRun.Tests {
+		userOutcomes[test.Name] = test
+	}
+
+	for _, test := range leafTests(referenceRun) {
+		if test.Status == "skip" {
+			continue
+		}
+		result := EdgeCaseResult{Name: test.Name, Rationale: rationales[test.Name[strings.LastIndex(test.Name, "/")+1:]]}
+		outcome, ran := userOutcomes[test.Name]
+		switch {
+		case test.Status == "fail":
+			result.Status = "invalid"
+			result.Message = "fails against the reference solution: " + test.Message
+			report.Discarded++
+		case !ran:
+			result.Status = "not_run"
+		case outcome.Status == "fail":
+			result.Status = "counterexample"
+			result.Message = outcome.Message
+			report.Counterexamples++
+		default:
+			result.Status = "passed"
+		}
+		report.Cases = append(report.Cases, result)
+	}
+	if len(report.Cases) == 0 {
+		report.Rejected = append(report.Rejected, "the generated tests did not run any test cases")
+	}
+	return report, nil
+}
+
+// decodeEdgeCases parses and validates the model's proposal
+func decodeEdgeCases(response string) (*EdgeCaseProposal, []string) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return nil, []string{"the AI response contains no JSON object"}
+	}
+	var raw interface{}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &raw); err != nil {
+		return nil, []string{fmt.Sprintf("the AI response is not valid JSON: %v", err)}
+	}
+	if problems := edgeCaseSchema.Validate(raw); len(problems) > 0 {
+		return nil, problems
+	}
+	var proposal EdgeCaseProposal
+	if err := json.Unmarshal([]byte(response[start:end+1]), &proposal); err != nil {
+		return nil, []string{err.Error()}
+	}
+	return &proposal, nil
+}
+
+// exportedAPI renders the exported declarations of the template without function bodies,
+// as a Go file, so the model sees exactly what tests may call
+func exportedAPI(template string) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, SolutionFile, template, parser.ParseComments)
+	if err != nil {
+		return template
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "package %s\n", file.Name.Name)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() || (d.Recv != nil && !exportedReceiver(d.Recv)) {
+				continue
+			}
+			stripped := *d
+			stripped.Body = nil
+			b.WriteString("\n")
+			printer.Fprint(&b, fset, &stripped)
+			b.WriteString("\n")
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			var specs []ast.Spec
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						specs = append(specs, s)
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							specs = append(specs, s)
+							break
+						}
+					}
+				}
+			}
+			if len(specs) == 0 {
+				continue
+			}
+			exported := *d
+			exported.Specs = specs
+			b.WriteString("\n")
+			printer.Fprint(&b, fset, &exported)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func exportedReceiver(recv *ast.FieldList) bool {
+	if len(recv.List) == 0 {
+		return false
+	}
+	typ := recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if index, ok := typ.(*ast.IndexExpr); ok {
+		typ = index.X
+	}
+	ident, ok := typ.(*ast.Ident)
+	return ok && ident.IsExported()
+}
+
+// checkGeneratedTests rejects test files that could not be run alongside a solution:
+// the wrong package, no tests, a main function, or uses of the template's unexported
+// identifiers (which a user's solution need not have)
+func checkGeneratedTests(testCode, template string) []string {
+	fset := token.NewFileSet()
+	test, err := parser.ParseFile(fset, TestFileName, testCode, 0)
+	if err != nil {
+		return []string{fmt.Sprintf("the generated tests do not parse: %v", err)}
+	}
+	tmpl, err := parser.ParseFile(token.NewFileSet(), SolutionFile, template, 0)
+	if err != nil {
+		return []string{fmt.Sprintf("the challenge template does not parse: %v", err)}
+	}
+
+	var problems []string
+	if test.Name.Name != tmpl.Name.Name {
+		problems = append(problems, fmt.Sprintf("the generated tests are in package %s, not %s", test.Name.Name, tmpl.Name.Name))
+	}
+
+	hasTest := false
+	for _, decl := range test.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(fn.Name.Name, "Test"):
+			hasTest = true
+		case fn.Name.Name == "main" || fn.Name.Name == "init":
+			problems = append(problems, fmt.Sprintf("the generated tests declare %s", fn.Name.Name))
+		}
+	}
+	if !hasTest {
+		problems = append(problems, "the generated file has no Test functions")
+	}
+
+	unexported := make(map[string]bool)
+	for name, object := range tmpl.Scope.Objects {
+		if !ast.IsExported(name) && object.Kind != ast.Bad {
+			unexported[name] = true
+		}
+	}
+	delete(unexported, "main")
+	used := make(map[string]bool)
+	ast.Inspect(test, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, func(inner ast.Node) bool {
+				if ident, ok := inner.(*ast.Ident); ok && ident.Obj == nil && unexported[ident.Name] {
+					used[ident.Name] = true
+				}
+				return true
+			})
+			return false // the selected name is a field or method, not a package-level identifier
+		case *ast.KeyValueExpr:
+			if _, isIdent := n.Key.(*ast.Ident); isIdent {
+				ast.Inspect(n.Value, func(inner ast.Node) bool {
+					if ident, ok := inner.(*ast.Ident); ok && ident.Obj == nil && unexported[ident.Name] {
+						used[ident.Name] = true
+					}
+					return true
+				})
+				return false
+			}
+		case *ast.Ident:
+			// Identifiers declared in the test file resolve to their declaration
+			if n.Obj == nil && unexported[n.Name] {
+				used[n.Name] = true
+			}
+		}
+		return true
+	})
+	for name := range used {
+		problems = append(problems, fmt.Sprintf("the generated tests use %s, which is not part of the exported API", name))
+	}
+	return problems
+}
+
+// leafTests returns the tests that have no subtests
+func leafTests(analysis *CodeAnalysis) []TestOutcome {
+	var leaves []TestOutcome
+	for _, test := range analysis.Tests {
+		isParent := false
+		for _, other := range analysis.Tests {
+			if strings.HasPrefix(other.Name, test.Name+"/") {
+				isParent = true
+				break
+			}
+		}
+		if !isParent {
+			leaves = append(leaves, test)
+		}
+	}
+	return leaves
+}
+
+// buildErrors formats the compiler errors of a run
+func buildErrors(analysis *CodeAnalysis) []string {
+	var errs []string
+	for _, finding := range analysis.Findings {
+		if finding.Source == SourceCompiler {
+			errs = append(errs, fmt.Sprintf("%s:%d: %s", finding.File, finding.Line, finding.Message))
+		}
+	}
+	if len(errs) == 0 && analysis.Error != "" {
+		errs = append(errs, analysis.Error)
+	}
+	return errs
+}
+
+func truncateTests(tests string) string {
+	if len(tests) > maxPromptTests {
+		return tests[:maxPromptTests] + "\n// ..."
+	}
+	return tests
+}
@@ -3,21 +3,36 @@ package services
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"web-ui/internal/models"
 )
 
+// maxRecentExecutions is how many finished executions are kept for the admin console
+const maxRecentExecutions = 100
+
 // ExecutionService handles code execution and testing
-type ExecutionService struct{}
+type ExecutionService struct {
+	mutex     sync.Mutex
+	startedAt time.Time
+	running   int
+	completed int
+	passed    int
+	errors    int
+	totalMs   int64
+	recent    []ExecutionRecord // newest last, capped at maxRecentExecutions
+}
 
 // NewExecutionService creates a new execution service
 func NewExecutionService() *ExecutionService {
-	return &ExecutionService{}
+	return &ExecutionService{startedAt: time.Now()}
 }
 
 // ExecutionResult represents the result of code execution
@@ -27,56 +42,132 @@ type ExecutionResult struct {
 	ExecutionMs int64  `json:"executionMs"`
 }
 
+// ExecutionRecord is a finished execution as shown in the admin console
+type ExecutionRecord struct {
+	Challenge   string    `json:"challenge"`
+	Passed      bool      `json:"passed"`
+	Error       bool      `json:"error"` // the tests could not be run at all
+	ExecutionMs int64     `json:"executionMs"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	OutputTail  string    `json:"outputTail,omitempty"`
+}
+
+// ExecutorStatus summarizes execution activity since the process started.
+// Executions run synchronously on the request goroutine, so there is no queue:
+// Running is the number of `go test` processes in flight.
+type ExecutorStatus struct {
+	Running   int       `json:"running"`
+	Completed int       `json:"completed"`
+	Passed    int       `json:"passed"`
+	Failed    int       `json:"failed"`
+	Errors    int       `json:"errors"`
+	AverageMs int64     `json:"averageMs"`
+	StartedAt time.Time `json:"startedAt"`
+	GoVersion string    `json:"goVersion"`
+	NumCPU    int       `json:"numCpu"`
+	TempDir   string    `json:"tempDir"`
+}
+
 // RunCode executes the provided code against a challenge's tests
 func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) ExecutionResult {
-	start := time.Now()
+	es.mutex.Lock()
+	es.running++
+	es.mutex.Unlock()
 
-	// Create temporary directory for execution
-	tempDir, err := ioutil.TempDir("", "challenge-exec")
-	if err != nil {
-		return ExecutionResult{
-			Passed: false,
-			Output: fmt.Sprintf("Failed to create temporary directory: %v", err),
-		}
+	result, runErr := es.runCode(code, challenge)
+
+	label := challenge.Title
+	if challenge.ID > 0 {
+		label = fmt.Sprintf("challenge-%d", challenge.ID)
+	}
+	record := ExecutionRecord{
+		Challenge:   label,
+		Passed:      result.Passed,
+		Error:       runErr,
+		ExecutionMs: result.ExecutionMs,
+		FinishedAt:  time.Now(),
+	}
+	if !result.Passed {
+		record.OutputTail = tail(result.Output, 2000)
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Write the submitted code to temporary file
-	codePath := filepath.Join(tempDir, "solution-template.go")
-	err = ioutil.WriteFile(codePath, []byte(code), 0644)
-	if err != nil {
-		return ExecutionResult{
-			Passed: false,
-			Output: fmt.Sprintf("Failed to write code file: %v", err),
-		}
+	es.mutex.Lock()
+	es.running--
+	es.completed++
+	es.totalMs += result.ExecutionMs
+	if result.Passed {
+		es.passed++
+	}
+	if runErr {
+		es.errors++
 	}
+	es.recent = append(es.recent, record)
+	if len(es.recent) > maxRecentExecutions {
+		es.recent = es.recent[len(es.recent)-maxRecentExecutions:]
+	}
+	es.mutex.Unlock()
 
-	// Write the test file to temporary directory
-	testPath := filepath.Join(tempDir, "solution_test.go")
-	err = ioutil.WriteFile(testPath, []byte(challenge.TestFile), 0644)
-	if err != nil {
-		return ExecutionResult{
-			Passed: false,
-			Output: fmt.Sprintf("Failed to write test file: %v", err),
-		}
+	return result
+}
+
+// tail returns the last n bytes of s
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
 	}
+	return "..." + s[len(s)-n:]
+}
 
-	// Initialize Go module
-	err = es.initGoModule(tempDir, challenge.ID)
-	if err != nil {
-		return ExecutionResult{
-			Passed: false,
-			Output: fmt.Sprintf("Failed to initialize Go module: %v", err),
+// RecentExecutions returns up to limit finished executions, newest first
+func (es *ExecutionService) RecentExecutions(limit int, failuresOnly bool) []ExecutionRecord {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	result := []ExecutionRecord{}
+	for i := len(es.recent) - 1; i >= 0 && (limit <= 0 || len(result) < limit); i-- {
+		if failuresOnly && es.recent[i].Passed {
+			continue
 		}
+		result = append(result, es.recent[i])
 	}
+	return result
+}
 
-	// Automatically detect and install dependencies based on imports
-	err = es.installDependencies(tempDir, code, challenge.ID)
+// Status reports execution counters and the runtime executions use
+func (es *ExecutionService) Status() ExecutorStatus {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	status := ExecutorStatus{
+		Running:   es.running,
+		Completed: es.completed,
+		Passed:    es.passed,
+		Failed:    es.completed - es.passed,
+		Errors:    es.errors,
+		StartedAt: es.startedAt,
+		GoVersion: runtime.Version(),
+		NumCPU:    runtime.NumCPU(),
+		TempDir:   os.TempDir(),
+	}
+	if es.completed > 0 {
+		status.AverageMs = es.totalMs / int64(es.completed)
+	}
+	return status
+}
+
+// runCode does the work for RunCode. The bool reports whether the tests could not be run at all.
+func (es *ExecutionService) runCode(code string, challenge *models.Challenge) (ExecutionResult, bool) {
+	start := time.Now()
+
+	tempDir, err := es.prepareWorkspace(code, challenge)
+	if tempDir != "" {
+		defer os.RemoveAll(tempDir)
+	}
 	if err != nil {
 		return ExecutionResult{
 			Passed: false,
-			Output: fmt.Sprintf("Failed to install dependencies: %v", err),
-		}
+			Output: err.Error(),
+		}, true
 	}
 
 	// Run tests
@@ -91,6 +182,7 @@ func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) Ex
 		Output:      outputStr,
 		ExecutionMs: executionTime,
 	}
+	runErr := false
 
 	if err == nil {
 		result.Passed = true
@@ -103,10 +195,46 @@ func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) Ex
 			// Command couldn't be run - this is a real error
 			result.Passed = false
 			result.Output = fmt.Sprintf("Failed to run tests: %v\n%s", err, outputStr)
+			runErr = true
 		}
 	}
 
-	return result
+	return result, runErr
+}
+
+// prepareWorkspace writes the code and the challenge tests into a fresh module in a
+// temporary directory and installs their dependencies. The caller removes the directory,
+// which is returned even when a later step fails.
+func (es *ExecutionService) prepareWorkspace(code string, challenge *models.Challenge) (string, error) {
+	// Create temporary directory for execution
+	tempDir, err := ioutil.TempDir("", "challenge-exec")
+	if err != nil {
+		return "", fmt.Errorf("Failed to create temporary directory: %v", err)
+	}
+
+	// Write the submitted code to temporary file
+	codePath := filepath.Join(tempDir, "solution-template.go")
+	if err := ioutil.WriteFile(codePath, []byte(code), 0644); err != nil {
+		return tempDir, fmt.Errorf("Failed to write code file: %v", err)
+	}
+
+	// Write the test file to temporary directory
+	testPath := filepath.Join(tempDir, "solution_test.go")
+	if err := ioutil.WriteFile(testPath, []byte(challenge.TestFile), 0644); err != nil {
+		return tempDir, fmt.Errorf("Failed to write test file: %v", err)
+	}
+
+	// Initialize Go module
+	if err := es.initGoModule(tempDir, challenge.ID); err != nil {
+		return tempDir, fmt.Errorf("Failed to initialize Go module: %v", err)
+	}
+
+	// Automatically detect and install dependencies based on imports
+	if err := es.installDependencies(tempDir, code, challenge.ID); err != nil {
+		return tempDir, fmt.Errorf("Failed to install dependencies: %v", err)
+	}
+
+	return tempDir, nil
 }
 
 // initGoModule initializes a Go module in the temporary directory
@@ -128,7 +256,7 @@ func (es *ExecutionService) installDependencies(tempDir string, code string, cha
 
 	// Install each required package
 	for _, pkg := range requiredPackages {
-		fmt.Printf("Installing dependency: %s\n", pkg)
+		log.Printf("Installing dependency: %s", pkg)
 		cmd := exec.Command("go", "get", pkg)
 		cmd.Dir = tempDir
 
@@ -289,60 +417,3 @@ type SaveSubmissionResponse struct {
 	FilePath    string   `json:"filePath"`
 	GitCommands []string `json:"gitCommands"`
 }
-
-// SaveSubmissionToFilesystem saves a user's submission to the filesystem
-func (es *ExecutionService) SaveSubmissionToFilesystem(request SaveSubmissionRequest) SaveSubmissionResponse {
-	// Get working directory for correct relative paths
-	workDir, _ := os.Getwd()
-
-	// Try different path approaches to handle potential path issues
-	var submissionDir string
-	var fileSaved bool
-
-	// Try multiple path options to ensure it works in different environments
-	pathOptions := []string{
-		// Option 1: From web-ui directory (standard case)
-		filepath.Join("..", fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-		// Option 2: From root workspace
-		filepath.Join(fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-		// Option 3: Absolute path from detected workspace root
-		filepath.Join(workDir, "..", fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-	}
-
-	for _, dirPath := range pathOptions {
-		err := os.MkdirAll(dirPath, 0755)
-		if err != nil {
-			continue
-		}
-
-		solutionFile := filepath.Join(dirPath, "solution-template.go")
-		err = ioutil.WriteFile(solutionFile, []byte(request.Code), 0644)
-		if err != nil {
-			continue
-		}
-
-		submissionDir = dirPath
-		fileSaved = true
-		break
-	}
-
-	if !fileSaved {
-		return SaveSubmissionResponse{
-			Success: false,
-			Message: "Failed to save solution to any available path",
-		}
-	}
-
-	// Return success response with git commands
-	return SaveSubmissionResponse{
-		Success:  true,
-		Message:  "Solution saved to filesystem",
-		FilePath: filepath.Join(submissionDir, "solution-template.go"),
-		GitCommands: []string{
-			"cd " + filepath.Join(workDir, ".."),
-			fmt.Sprintf("git add %s", filepath.Join(fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username, "solution-template.go")),
-			fmt.Sprintf("git commit -m \"Add solution for Challenge %d\"", request.ChallengeID),
-			"git push origin main",
-		},
-	}
-}
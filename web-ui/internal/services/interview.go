@@ -0,0 +1,412 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// InterviewsDir holds one JSON file per interview session, relative to the repository root
+const InterviewsDir = "interviews"
+
+const (
+	maxInterviewTurns  = 40
+	maxInterviewAnswer = 8000
+)
+
+var (
+	// ErrInterviewNotFound is returned for unknown sessions and sessions owned by someone else
+	ErrInterviewNotFound = errors.New("interview session not found")
+	// ErrInterviewClosed is returned when answering or finishing a completed session
+	ErrInterviewClosed = errors.New("interview session is already completed")
+	// ErrInvalidAnswer is returned for empty or oversized answers
+	ErrInvalidAnswer = errors.New("invalid answer")
+	// ErrInterviewTooLong is returned once a session reaches the turn limit
+	ErrInterviewTooLong = fmt.Errorf("interview sessions are limited to %d turns; finish this one to get your evaluation", maxInterviewTurns)
+)
+
+var interviewIDRe = regexp.MustCompile(`^[a-f0-9]{24}$`)
+
+// interviewEntry serialises requests to one session while its AI call is in flight
+type interviewEntry struct {
+	owner   string // immutable, so it can be checked without mu
+	mu      sync.Mutex
+	session *models.InterviewSession
+}
+
+// InterviewService runs server-side, multi-turn AI interviews. Sessions are saved
+// under interviews/ so they survive restarts. Each belongs to the browser session that
+// started it, which can resume it from any network.
+type InterviewService struct {
+	sessions         map[string]*interviewEntry
+	mutex            sync.RWMutex
+	workspaceService *WorkspaceService
+	challengeService *ChallengeService
+	aiService        *AIService
+}
+
+// NewInterviewService creates a new interview service
+func NewInterviewService(workspaceService *WorkspaceService, challengeService *ChallengeService, aiService *AIService) *InterviewService {
+	return &InterviewService{
+		sessions:         make(map[string]*interviewEntry),
+		workspaceService: workspaceService,
+		challengeService: challengeService,
+		aiService:        aiService,
+	}
+}
+
+// LoadInterviews reads saved sessions. A missing directory means no sessions.
+func (is *InterviewService) LoadInterviews() error {
+	dir := filepath.Join(is.workspaceService.Root(), InterviewsDir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	sessions := make(map[string]*interviewEntry)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			log.Printf("Warning: failed to read %s: %v", file, err)
+			continue
+		}
+		var session models.InterviewSession
+		if err := json.Unmarshal(content, &session); err != nil || !interviewIDRe.MatchString(session.ID) {
+			log.Printf("Warning: skipping invalid interview session %s", filepath.Base(file))
+			continue
+		}
+		sessions[session.ID] = &interviewEntry{owner: session.Owner, session: &session}
+	}
+
+	is.mutex.Lock()
+	is.sessions = sessions
+	is.mutex.Unlock()
+
+	log.Printf("Loaded %d interview sessions", len(sessions))
+	return nil
+}
+
+func newInterviewID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// copySession returns a snapshot that is safe to encode while the session keeps changing
+func copySession(session *models.InterviewSession) *models.InterviewSession {
+	c := *session
+	c.Turns = append([]models.InterviewTurn{}, session.Turns...)
+	if session.Evaluation != nil {
+		evaluation := *session.Evaluation
+		c.Evaluation = &evaluation
+	}
+	return &c
+}
+
+// publicSession returns a snapshot of session for its owner, without the owner key
+func publicSession(session *models.InterviewSession) *models.InterviewSession {
+	c := copySession(session)
+	c.Owner = ""
+	return c
+}
+
+// persist writes a session to interviews/<id>.json
+func (is *InterviewService) persist(session *models.InterviewSession) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = is.workspaceService.WriteFile(filepath.Join(InterviewsDir, session.ID+".json"), append(data, '\n'))
+	return err
+}
+
+// entry looks up a session owned by user
+func (is *InterviewService) entry(user, id string) (*interviewEntry, error) {
+	is.mutex.RLock()
+	entry, ok := is.sessions[id]
+	is.mutex.RUnlock()
+	if !ok || user == "" || entry.owner != user {
+		return nil, ErrInterviewNotFound
+	}
+	return entry, nil
+}
+
+// Start opens a new session for user on a challenge; the interviewer asks the first
+// question. candidate is the GitHub username shown in the transcript, or "".
+func (is *InterviewService) Start(user, candidate string, challengeID int, code string) (*models.InterviewSession, error) {
+	challenge, ok := is.challengeService.GetChallenge(challengeID)
+	if !ok {
+		return nil, fmt.Errorf("%w: challenge %d", ErrUnknownChallenge, challengeID)
+	}
+	id, err := newInterviewID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.InterviewSession{
+		ID:             id,
+		Owner:          user,
+		Username:       candidate,
+		ChallengeID:    challenge.ID,
+		ChallengeTitle: challenge.Title,
+		Status:         models.InterviewActive,
+		Code:           code,
+		Turns:          []models.InterviewTurn{},
+		StartedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	question, promptVersion, err := is.aiService.InterviewerTurn(user, challenge, session)
+	if err != nil {
+		return nil, err
+	}
+	session.Turns = append(session.Turns, models.InterviewTurn{Role: models.InterviewerRole, Content: question, CreatedAt: time.Now(), PromptVersion: promptVersion})
+
+	if err := is.persist(session); err != nil {
+		return nil, err
+	}
+
+	is.mutex.Lock()
+	is.sessions[id] = &interviewEntry{owner: user, session: session}
+	is.mutex.Unlock()
+	return publicSession(session), nil
+}
+
+// Adopt gives user the sessions saved before sessions had an owner, when they were started
+// under one of the legacy keys: the username cookie or client address the caller was then
+// identified by. Each is adopted once, by the first browser session that claims it.
+func (is *InterviewService) Adopt(user string, legacy ...string) {
+	if user == "" {
+		return
+	}
+	var adopted []*models.InterviewSession
+	is.mutex.Lock()
+	for id, entry := range is.sessions {
+		if entry.owner != "" || entry.session.Username == "" || !containsString(legacy, entry.session.Username) {
+			continue
+		}
+		session := copySession(entry.session)
+		session.Owner = user
+		if strings.HasPrefix(session.Username, "ip:") {
+			session.Username = "" // an address, not a name to show in the transcript
+		}
+		// Nobody can reach an entry without an owner, so it can simply be replaced
+		is.sessions[id] = &interviewEntry{owner: user, session: session}
+		adopted = append(adopted, session)
+	}
+	is.mutex.Unlock()
+
+	for _, session := range adopted {
+		if err := is.persist(session); err != nil {
+			log.Printf("Warning: failed to save adopted interview session %s: %v", session.ID, err)
+		}
+	}
+}
+
+// Get returns one of user's sessions
+func (is *InterviewService) Get(user, id string) (*models.InterviewSession, error) {
+	entry, err := is.entry(user, id)
+	if err != nil {
+		return nil, err
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	return publicSession(entry.session), nil
+}
+
+// List returns user's sessions, most recently active first
+func (is *InterviewService) List(user string) []models.InterviewSummary {
+	is.mutex.RLock()
+	entries := make([]*interviewEntry, 0)
+	for _, entry := range is.sessions {
+		if entry.owner == user {
+			entries = append(entries, entry)
+		}
+	}
+	is.mutex.RUnlock()
+
+	summaries := []models.InterviewSummary{}
+	for _, entry := range entries {
+		entry.mu.Lock()
+		session := entry.session
+		summary := models.InterviewSummary{
+			ID:             session.ID,
+			ChallengeID:    session.ChallengeID,
+			ChallengeTitle: session.ChallengeTitle,
+			Status:         session.Status,
+			Turns:          len(session.Turns),
+			StartedAt:      session.StartedAt,
+			UpdatedAt:      session.UpdatedAt,
+			CompletedAt:    session.CompletedAt,
+		}
+		if session.Evaluation != nil {
+			score := session.Evaluation.OverallScore
+			summary.OverallScore = &score
+		}
+		entry.mu.Unlock()
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries
+}
+
+// Answer records the candidate's answer and the interviewer's follow-up. If the AI call
+// fails the answer is not kept, so the candidate can simply send it again.
+func (is *InterviewService) Answer(user, id, answer, code string) (*models.InterviewSession, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, fmt.Errorf("%w: answer is required", ErrInvalidAnswer)
+	}
+	if len(answer) > maxInterviewAnswer {
+		return nil, fmt.Errorf("%w: answers are limited to %d characters", ErrInvalidAnswer, maxInterviewAnswer)
+	}
+
+	entry, err := is.entry(user, id)
+	if err != nil {
+		return nil, err
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	session := entry.session
+	if session.Status != models.InterviewActive {
+		return nil, ErrInterviewClosed
+	}
+	if len(session.Turns)+2 > maxInterviewTurns {
+		return nil, ErrInterviewTooLong
+	}
+	challenge, ok := is.challengeService.GetChallenge(session.ChallengeID)
+	if !ok {
+		return nil, fmt.Errorf("%w: challenge %d", ErrUnknownChallenge, session.ChallengeID)
+	}
+
+	draft := copySession(session)
+	if code != "" {
+		draft.Code = code
+	}
+	draft.Turns = append(draft.Turns, models.InterviewTurn{Role: models.CandidateRole, Content: answer, Code: code, CreatedAt: time.Now()})
+
+	question, promptVersion, err := is.aiService.InterviewerTurn(user, challenge, draft)
+	if err != nil {
+		return nil, err
+	}
+	draft.Turns = append(draft.Turns, models.InterviewTurn{Role: models.InterviewerRole, Content: question, CreatedAt: time.Now(), PromptVersion: promptVersion})
+	draft.UpdatedAt = time.Now()
+
+	if err := is.persist(draft); err != nil {
+		return nil, err
+	}
+	entry.session = draft
+	return publicSession(draft), nil
+}
+
+// Finish ends the session with a structured evaluation of the transcript and final code
+func (is *InterviewService) Finish(user, id, code string) (*models.InterviewSession, error) {
+	entry, err := is.entry(user, id)
+	if err != nil {
+		return nil, err
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	session := entry.session
+	if session.Status != models.InterviewActive {
+		return nil, ErrInterviewClosed
+	}
+	challenge, ok := is.challengeService.GetChallenge(session.ChallengeID)
+	if !ok {
+		return nil, fmt.Errorf("%w: challenge %d", ErrUnknownChallenge, session.ChallengeID)
+	}
+
+	draft := copySession(session)
+	if code != "" {
+		draft.Code = code
+	}
+
+	evaluation, err := is.aiService.EvaluateInterview(user, challenge, draft)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	draft.Evaluation = evaluation
+	draft.Status = models.InterviewCompleted
+	draft.CompletedAt = &now
+	draft.UpdatedAt = now
+
+	if err := is.persist(draft); err != nil {
+		return nil, err
+	}
+	entry.session = draft
+	return publicSession(draft), nil
+}
+
+// WriteTranscriptMarkdown writes a readable transcript with the final code and evaluation
+func WriteTranscriptMarkdown(w io.Writer, session *models.InterviewSession) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Interview: %s\n\n", session.ChallengeTitle)
+	if session.Username != "" {
+		fmt.Fprintf(&b, "- Candidate: %s\n", session.Username)
+	}
+	fmt.Fprintf(&b, "- Challenge: #%d\n", session.ChallengeID)
+	fmt.Fprintf(&b, "- Started: %s\n", session.StartedAt.Format(time.RFC1123))
+	if session.CompletedAt != nil {
+		fmt.Fprintf(&b, "- Completed: %s\n", session.CompletedAt.Format(time.RFC1123))
+	}
+	fmt.Fprintf(&b, "- Status: %s\n\n## Transcript\n\n", session.Status)
+
+	for _, turn := range session.Turns {
+		speaker := "Interviewer"
+		if turn.Role == models.CandidateRole {
+			speaker = "Candidate"
+		}
+		fmt.Fprintf(&b, "**%s** (%s):\n\n%s\n\n", speaker, turn.CreatedAt.Format("15:04:05"), strings.TrimSpace(turn.Content))
+	}
+
+	if strings.TrimSpace(session.Code) != "" {
+		fmt.Fprintf(&b, "## Final Code\n\n```go\n%s\n```\n\n", strings.TrimRight(session.Code, "\n"))
+	}
+
+	if e := session.Evaluation; e != nil {
+		fmt.Fprintf(&b, "## Evaluation\n\n")
+		fmt.Fprintf(&b, "- Overall score: %.0f/100\n", e.OverallScore)
+		fmt.Fprintf(&b, "- Recommendation: %s\n", strings.ReplaceAll(e.Recommendation, "_", " "))
+		fmt.Fprintf(&b, "- Problem solving: %d/5, Communication: %d/5, Go knowledge: %d/5, Code quality: %d/5, Testing: %d/5\n\n",
+			e.Scores.ProblemSolving, e.Scores.Communication, e.Scores.GoKnowledge, e.Scores.CodeQuality, e.Scores.Testing)
+		fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(e.Summary))
+		writeList := func(title string, items []string) {
+			if len(items) == 0 {
+				return
+			}
+			fmt.Fprintf(&b, "### %s\n\n", title)
+			for _, item := range items {
+				fmt.Fprintf(&b, "- %s\n", item)
+			}
+			b.WriteString("\n")
+		}
+		writeList("Strengths", e.Strengths)
+		writeList("Weaknesses", e.Weaknesses)
+		writeList("Next Steps", e.NextSteps)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// LeaderboardService computes leaderboards from SCOREBOARD.md files and package submissions
+type LeaderboardService struct {
+	challengeService *ChallengeService
+	packageService   *PackageService
+}
+
+// NewLeaderboardService creates a new leaderboard service
+func NewLeaderboardService(challengeService *ChallengeService, packageService *PackageService) *LeaderboardService {
+	return &LeaderboardService{
+		challengeService: challengeService,
+		packageService:   packageService,
+	}
+}
+
+// PackageUserStats holds one user's completions within a package learning path
+type PackageUserStats struct {
+	Username            string
+	CompletedCount      int
+	LastSubmission      time.Time
+	ChallengesCompleted map[string]bool
+	StaleCount          int // completions graded against an older test suite
+}
+
+// ClassicCompletions returns, per username, the classic challenges where ALL tests passed
+func (ls *LeaderboardService) ClassicCompletions() map[string]map[int]bool {
+	userCompletions, _ := ls.classicResults()
+	return userCompletions
+}
+
+// classicResults returns the completions of ClassicCompletions along with, per username,
+// how many of them were graded against an older test suite
+func (ls *LeaderboardService) classicResults() (map[string]map[int]bool, map[string]int) {
+	userCompletions := make(map[string]map[int]bool)
+	staleCounts := make(map[string]int)
+
+	for challengeID := range ls.challengeService.GetChallenges() {
+		for _, row := range ReadClassicScoreboard(challengeID) {
+			// Only count as completed if ALL tests passed
+			if row.Solved() {
+				if userCompletions[row.Username] == nil {
+					userCompletions[row.Username] = make(map[int]bool)
+				}
+				userCompletions[row.Username][challengeID] = true
+				if row.Stale {
+					staleCounts[row.Username]++
+				}
+			}
+		}
+	}
+
+	return userCompletions, staleCounts
+}
+
+// ScoreboardRow is a parsed "| Username | Passed Tests | Total Tests | Suite |" row. Suite
+// is the SuiteVersion the row was graded against, "" in rows written before it was recorded.
+type ScoreboardRow struct {
+	Username string
+	Passed   int
+	Total    int
+	Suite    string
+	Stale    bool // graded against an older version of the test suite
+}
+
+// Solved reports whether all tests passed. Stale rows do not count when
+// ExcludeStaleResults is set.
+func (r ScoreboardRow) Solved() bool {
+	return r.Passed > 0 && r.Passed == r.Total && !(r.Stale && ExcludeStaleResults())
+}
+
+// ReadClassicScoreboard parses challenge-N/SCOREBOARD.md into rows, with rows from older
+// test suites marked stale
+func ReadClassicScoreboard(challengeID int) []ScoreboardRow {
+	// Read scoreboard file directly to check test results
+	dir := filepath.Join("..", "challenge-"+strconv.Itoa(challengeID))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "SCOREBOARD.md"))
+	if err != nil {
+		// Try alternative path
+		dir = "challenge-" + strconv.Itoa(challengeID)
+		content, err = ioutil.ReadFile(filepath.Join(dir, "SCOREBOARD.md"))
+		if err != nil {
+			return nil
+		}
+	}
+	rows := parseScoreboardRows(string(content))
+	markStaleRows(rows, testFileIn(dir))
+	return rows
+}
+
+// ReadPackageScoreboard parses packages/<pkg>/<challenge>/SCOREBOARD.md into rows, with
+// rows from older test suites marked stale
+func ReadPackageScoreboard(packageName, challengeID string) []ScoreboardRow {
+	dir := filepath.Join("..", "packages", packageName, challengeID)
+	content, err := ioutil.ReadFile(filepath.Join(dir, "SCOREBOARD.md"))
+	if err != nil {
+		return nil
+	}
+	rows := parseScoreboardRows(string(content))
+	markStaleRows(rows, testFileIn(dir))
+	return rows
+}
+
+// parseScoreboardRows extracts username and test counts from a scoreboard table
+func parseScoreboardRows(content string) []ScoreboardRow {
+	var rows []ScoreboardRow
+
+	lines := strings.Split(content, "\n")
+	for _, line := range lines {
+		// Skip header and separator lines
+		if !strings.Contains(line, "|") || strings.Contains(line, "Username") || strings.Contains(line, "---") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if len(parts) < 4 {
+			continue
+		}
+
+		username := strings.TrimSpace(parts[1])
+
+		// Skip empty usernames or placeholders
+		if username == "" || username == "------" {
+			continue
+		}
+
+		// Parse test counts
+		passed, err1 := strconv.Atoi(strings.TrimSpace(parts[2]))
+		total, err2 := strconv.Atoi(strings.TrimSpace(parts[3]))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		row := ScoreboardRow{Username: username, Passed: passed, Total: total}
+		if len(parts) > 4 {
+			if suite := strings.TrimSpace(parts[4]); suiteVersionPattern.MatchString(suite) {
+				row.Suite = suite
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// AchievementFor returns the main leaderboard achievement for a completion count
+func AchievementFor(completedCount int) string {
+	switch {
+	case completedCount >= 20:
+		return "🔥 Master"
+	case completedCount >= 15:
+		return "⭐ Expert"
+	case completedCount >= 10:
+		return "💪 Advanced"
+	case completedCount >= 5:
+		return "🚀 Intermediate"
+	default:
+		return "🌱 Beginner"
+	}
+}
+
+// MainLeaderboard builds the classic challenge leaderboard, sorted and ranked
+func (ls *LeaderboardService) MainLeaderboard(sponsors map[string]bool) []models.LeaderboardUser {
+	totalChallenges := len(ls.challengeService.GetChallenges())
+	userCompletions, staleCounts := ls.classicResults()
+
+	// Convert to leaderboard format
+	var leaderboard []models.LeaderboardUser
+	for username, completions := range userCompletions {
+		completedCount := len(completions)
+		completionRate := float64(completedCount) / float64(totalChallenges) * 100
+
+		leaderboard = append(leaderboard, models.LeaderboardUser{
+			Username:            username,
+			CompletedCount:      completedCount,
+			CompletionRate:      completionRate,
+			CompletedChallenges: completions,
+			Achievement:         AchievementFor(completedCount),
+			IsSponsor:           sponsors[username],
+			StaleCount:          staleCounts[username],
+		})
+	}
+
+	// Sort by completion count (descending), then by username
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].CompletedCount != leaderboard[j].CompletedCount {
+			return leaderboard[i].CompletedCount > leaderboard[j].CompletedCount
+		}
+		return leaderboard[i].Username < leaderboard[j].Username
+	})
+
+	// Assign ranks
+	for i := range leaderboard {
+		leaderboard[i].Rank = i + 1
+	}
+
+	return leaderboard
+}
+
+// MainRank returns the user's rank on the main scoreboard, or 0 if unranked.
+// Users with the same completion count share a rank.
+func (ls *LeaderboardService) MainRank(username string) int {
+	return rankIn(ls.ClassicCompletions(), username)
+}
+
+// rankIn ranks username by completion count among all users in completions
+func rankIn(completions map[string]map[int]bool, username string) int {
+	targetCompletions := len(completions[username])
+	if targetCompletions == 0 {
+		return 0 // User is unranked
+	}
+
+	// Count how many users have more completions (following Python script logic)
+	rank := 1
+	for user, done := range completions {
+		if user != username && len(done) > targetCompletions {
+			rank++
+		}
+	}
+
+	return rank
+}
+
+// PackageChallenges returns a package and its challenges in learning path order
+func (ls *LeaderboardService) PackageChallenges(packageName string) (*models.Package, []*models.PackageChallenge, error) {
+	pkg, err := ls.packageService.GetPackage(packageName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	challengesMap, err := ls.packageService.GetPackageChallenges(packageName)
+	if err != nil {
+		challengesMap = make(map[string]*models.PackageChallenge)
+	}
+
+	var challenges []*models.PackageChallenge
+	for _, id := range pkg.LearningPath {
+		if ch, ok := challengesMap[id]; ok {
+			challenges = append(challenges, ch)
+		}
+	}
+
+	return pkg, challenges, nil
+}
+
+// PackageStats collects per-user completions from the package submissions directories.
+// Submissions whose scoreboard row comes from an older test suite are counted as stale,
+// or skipped when ExcludeStaleResults is set.
+func (ls *LeaderboardService) PackageStats(packageName string, challenges []*models.PackageChallenge) map[string]*PackageUserStats {
+	userStats := make(map[string]*PackageUserStats)
+
+	for _, challenge := range challenges {
+		stale := make(map[string]bool)
+		for _, row := range ReadPackageScoreboard(packageName, challenge.ID) {
+			stale[row.Username] = row.Stale
+		}
+
+		submissionsDir := filepath.Join("..", "packages", packageName, challenge.ID, "submissions")
+		if _, err := os.Stat(submissionsDir); os.IsNotExist(err) {
+			continue
+		}
+		entries, err := ioutil.ReadDir(submissionsDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			username := entry.Name()
+			modTime, ok := PackageSolutionModTime(packageName, challenge.ID, username)
+			if !ok || (stale[username] && ExcludeStaleResults()) {
+				continue
+			}
+
+			if userStats[username] == nil {
+				userStats[username] = &PackageUserStats{
+					Username:            username,
+					LastSubmission:      modTime,
+					ChallengesCompleted: make(map[string]bool),
+				}
+			}
+			if !userStats[username].ChallengesCompleted[challenge.ID] {
+				userStats[username].CompletedCount++
+				userStats[username].ChallengesCompleted[challenge.ID] = true
+				if stale[username] {
+					userStats[username].StaleCount++
+				}
+				if modTime.After(userStats[username].LastSubmission) {
+					userStats[username].LastSubmission = modTime
+				}
+			}
+		}
+	}
+
+	return userStats
+}
+
+// PackageSolutionModTime returns the modification time of a user's package solution file
+func PackageSolutionModTime(packageName, challengeID, username string) (time.Time, bool) {
+	userDir := filepath.Join("..", "packages", packageName, challengeID, "submissions", username)
+
+	// Either solution.go or solution-template.go counts as a submission
+	for _, name := range []string{"solution.go", "solution-template.go"} {
+		if stat, err := os.Stat(filepath.Join(userDir, name)); err == nil {
+			return stat.ModTime(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// PackageLeaderboard builds the package leaderboard, sorted by completions then earliest submission
+func (ls *LeaderboardService) PackageLeaderboard(packageName string, challenges []*models.PackageChallenge, sponsors map[string]bool) []models.PackageScoreboardEntry {
+	var leaderboard []models.PackageScoreboardEntry
+
+	for username, stats := range ls.PackageStats(packageName, challenges) {
+		if stats.CompletedCount > 0 {
+			leaderboard = append(leaderboard, models.PackageScoreboardEntry{
+				Username:    username,
+				PackageName: packageName,
+				ChallengeID: "", // Not specific to one challenge
+				SubmittedAt: stats.LastSubmission,
+				TestsPassed: stats.CompletedCount,
+				TestsTotal:  len(challenges),
+				IsSponsor:   sponsors[username],
+				StaleCount:  stats.StaleCount,
+			})
+		}
+	}
+
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].TestsPassed != leaderboard[j].TestsPassed {
+			return leaderboard[i].TestsPassed > leaderboard[j].TestsPassed
+		}
+		return leaderboard[i].SubmittedAt.Before(leaderboard[j].SubmittedAt)
+	})
+
+	return leaderboard
+}
+
+// PackageRank returns the user's position on a package leaderboard, or 0 if absent
+func PackageRank(leaderboard []models.PackageScoreboardEntry, username string) int {
+	for i, entry := range leaderboard {
+		if entry.Username == username {
+			return i + 1
+		}
+	}
+	return 0
+}
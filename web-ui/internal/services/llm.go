@@ -0,0 +1,994 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ProviderName identifies an LLM backend
+type ProviderName string
+
+const (
+	ProviderGemini           ProviderName = "gemini"
+	ProviderOpenAI           ProviderName = "openai"
+	ProviderClaude           ProviderName = "claude"
+	ProviderOpenAICompatible ProviderName = "openai-compatible" // self-hosted servers: Ollama, vLLM, llama.cpp
+	ProviderMock             ProviderName = "mock"              // offline: static checks or recorded responses
+)
+
+// AIFeature names a feature that can be routed to its own provider and model
+type AIFeature string
+
+const (
+	FeatureReview    AIFeature = "review"
+	FeatureHint      AIFeature = "hint"
+	FeatureQuestions AIFeature = "questions"
+)
+
+// AIFeatures lists every configurable feature
+var AIFeatures = []AIFeature{FeatureReview, FeatureHint, FeatureQuestions}
+
+// LLMConfig holds configuration for one LLM provider
+type LLMConfig struct {
+	Provider    ProviderName
+	APIKey      string
+	Model       string
+	BaseURL     string
+	MaxTokens   int
+	Temperature float64
+	MockDir     string // AI_MOCK_DIR: recorded responses for the mock provider to replay
+	RecordDir   string // AI_RECORD_DIR: save every response here in the layout MockDir expects
+}
+
+// LLMRequest is a single prompt sent to a provider
+type LLMRequest struct {
+	Prompt     string
+	System     string
+	ExpectJSON bool
+	// Schema constrains the reply with the provider's structured output mode: a response
+	// schema for Gemini, json_schema for OpenAI-compatible servers and a forced tool call
+	// for Claude. SchemaName names it (and the tool).
+	Schema     *JSONSchema
+	SchemaName string
+	// Task is the prompt template the request was rendered from, e.g. "review", and Data
+	// the values it was rendered with. Remote providers only send the text; the mock
+	// provider answers from these.
+	Task string
+	Data *PromptData
+	// MaxTokens raises the provider's output limit for requests with long replies
+	MaxTokens int
+	// NoCache always asks the provider, for requests that should get a new answer when
+	// they are repeated
+	NoCache bool
+	// Accept, when set, reports whether a reply is usable as it is. Other replies are not
+	// cached, so an identical request is not answered with them again.
+	Accept func(text string) bool
+}
+
+// LLMResponse is a provider's reply
+type LLMResponse struct {
+	Text  string
+	Usage LLMUsage
+}
+
+// LLMUsage is the token usage a provider reported for one request
+type LLMUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Total returns the tokens used by the request. Providers that report no usage
+// (some self-hosted servers) are estimated at four characters per token.
+func (u LLMUsage) Total(request LLMRequest, text string) int {
+	if total := u.PromptTokens + u.CompletionTokens; total > 0 {
+		return total
+	}
+	return (len(request.System) + len(request.Prompt) + len(text) + 3) / 4
+}
+
+// LLMProvider is implemented by every LLM backend
+type LLMProvider interface {
+	// Name returns the provider identifier
+	Name() ProviderName
+	// Model returns the model requests are sent to
+	Model() string
+	// BaseURL returns the endpoint requests are sent to
+	BaseURL() string
+	// Ready reports whether the provider has what it needs to make requests, e.g. an API key
+	Ready() bool
+	// Complete sends the request and returns the model's reply
+	Complete(request LLMRequest) (*LLMResponse, error)
+	// Stream sends the request and calls onChunk with each piece of text as it arrives.
+	// It returns the complete reply once the stream ends; an error from onChunk aborts the stream.
+	Stream(ctx context.Context, request LLMRequest, onChunk func(string) error) (*LLMResponse, error)
+	// Probe checks that the endpoint is reachable and accepts the credentials, with a
+	// request that generates no tokens (such as listing models)
+	Probe(ctx context.Context) error
+}
+
+// StreamTimeout caps how long a streaming request may run. Streams are not bound by the
+// per-provider request timeout because tokens keep arriving while the model works.
+const StreamTimeout = 5 * time.Minute
+
+// Default models and endpoints per provider
+var providerDefaults = map[ProviderName]struct {
+	model   string
+	baseURL string
+	timeout time.Duration
+	keyEnv  string // variable holding the API key
+	keyURL  string // where to get one
+}{
+	ProviderGemini:           {"gemini-2.5-flash", "https://generativelanguage.googleapis.com/v1beta/models", 30 * time.Second, "GEMINI_API_KEY", "https://aistudio.google.com/app/apikey"},
+	ProviderOpenAI:           {"gpt-4o-mini", "https://api.openai.com/v1", 30 * time.Second, "OPENAI_API_KEY", "https://platform.openai.com/api-keys"},
+	ProviderClaude:           {"claude-3-sonnet-20240229", "https://api.anthropic.com/v1/messages", 30 * time.Second, "CLAUDE_API_KEY", "https://console.anthropic.com/settings/keys"},
+	ProviderOpenAICompatible: {"llama3.1", "http://localhost:11434/v1", 2 * time.Minute, "OPENAI_COMPATIBLE_API_KEY", ""}, // Ollama; local models are slower
+	ProviderMock:             {"heuristic", "", 0, "", ""},
+}
+
+// missingKeyMessage tells the user how to configure the API key a feature's provider needs
+func missingKeyMessage(feature AIFeature, config LLMConfig) string {
+	defaults := providerDefaults[config.Provider]
+	message := fmt.Sprintf("⚠️ AI %s uses %s, which requires an API key. Please add %s (or AI_%s_API_KEY) to your .env file.",
+		feature, config.Provider, defaults.keyEnv, strings.ToUpper(string(feature)))
+	if defaults.keyURL != "" {
+		message += " Get a key at: " + defaults.keyURL
+	}
+	return message
+}
+
+// parseProviderName maps an AI_PROVIDER value to a provider, defaulting to Gemini
+func parseProviderName(value string) ProviderName {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "openai":
+		return ProviderOpenAI
+	case "claude":
+		return ProviderClaude
+	case "openai-compatible", "local", "ollama", "vllm", "llamacpp", "llama.cpp":
+		return ProviderOpenAICompatible
+	case "mock":
+		return ProviderMock
+	default:
+		return ProviderGemini
+	}
+}
+
+// featureEnv reads AI_<FEATURE>_<name>, e.g. AI_HINT_MODEL
+func featureEnv(feature AIFeature, name string) string {
+	return strings.TrimSpace(os.Getenv("AI_" + strings.ToUpper(string(feature)) + "_" + name))
+}
+
+// maxTokens is the output limit for a request: the configured one, unless the request
+// needs more
+func (c LLMConfig) maxTokens(request LLMRequest) int {
+	if request.MaxTokens > c.MaxTokens {
+		return request.MaxTokens
+	}
+	return c.MaxTokens
+}
+
+// LoadLLMConfig resolves the provider configuration for a feature.
+// Per-feature variables (AI_REVIEW_PROVIDER, AI_HINT_MODEL, AI_QUESTIONS_BASE_URL, ...)
+// override the global AI_PROVIDER, AI_MODEL and AI_BASE_URL. The global model and base URL
+// only apply when the feature uses the global provider.
+func LoadLLMConfig(feature AIFeature) LLMConfig {
+	globalProvider := parseProviderName(os.Getenv("AI_PROVIDER"))
+	provider := globalProvider
+	if value := featureEnv(feature, "PROVIDER"); value != "" {
+		provider = parseProviderName(value)
+	}
+
+	config := LLMConfig{
+		Provider:    provider,
+		Model:       featureEnv(feature, "MODEL"),
+		BaseURL:     featureEnv(feature, "BASE_URL"),
+		APIKey:      featureEnv(feature, "API_KEY"),
+		MaxTokens:   4000, // Increased for longer responses
+		Temperature: 0.3,
+		MockDir:     strings.TrimSpace(os.Getenv("AI_MOCK_DIR")),
+		RecordDir:   strings.TrimSpace(os.Getenv("AI_RECORD_DIR")),
+	}
+
+	if provider == globalProvider {
+		if config.Model == "" {
+			config.Model = strings.TrimSpace(os.Getenv("AI_MODEL"))
+		}
+		if config.BaseURL == "" {
+			config.BaseURL = strings.TrimSpace(os.Getenv("AI_BASE_URL"))
+		}
+	}
+	if config.APIKey == "" {
+		config.APIKey = getAPIKeyFromEnvFor(provider)
+	}
+
+	defaults := providerDefaults[provider]
+	if config.Model == "" {
+		config.Model = defaults.model
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaults.baseURL
+	}
+	return config
+}
+
+func getAPIKeyFromEnvFor(provider ProviderName) string {
+	switch provider {
+	case ProviderGemini:
+		if key := os.Getenv("GEMINI_API_KEY"); key != "" {
+			return key
+		}
+	case ProviderOpenAI:
+		if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+			return key
+		}
+	case ProviderClaude:
+		if key := os.Getenv("CLAUDE_API_KEY"); key != "" {
+			return key
+		}
+	case ProviderOpenAICompatible:
+		// Most local servers need no key; some proxies expect one
+		return os.Getenv("OPENAI_COMPATIBLE_API_KEY")
+	case ProviderMock:
+		return ""
+	}
+	// Fall back to generic AI_API_KEY
+	return os.Getenv("AI_API_KEY")
+}
+
+// NewLLMProvider creates the provider described by config. With RecordDir set, the
+// responses of a remote provider are saved for the mock provider to replay.
+func NewLLMProvider(config LLMConfig) LLMProvider {
+	if config.Provider == ProviderMock {
+		return newMockProvider(config)
+	}
+	provider := newRemoteProvider(config)
+	if config.RecordDir != "" {
+		return &recordingProvider{LLMProvider: provider, dir: config.RecordDir, counter: newCallCounter()}
+	}
+	return provider
+}
+
+func newRemoteProvider(config LLMConfig) LLMProvider {
+	timeout := providerDefaults[config.Provider].timeout
+	client := &http.Client{Timeout: timeout}
+	// The per-request timeout still bounds the wait for the first byte of a stream
+	streamClient := &http.Client{
+		Timeout: StreamTimeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: timeout,
+		},
+	}
+
+	switch config.Provider {
+	case ProviderOpenAI:
+		return &openAIProvider{config: config, client: client, streamClient: streamClient, requireKey: true}
+	case ProviderClaude:
+		return &claudeProvider{config: config, client: client, streamClient: streamClient}
+	case ProviderOpenAICompatible:
+		return &openAIProvider{config: config, client: client, streamClient: streamClient}
+	default:
+		return &geminiProvider{config: config, client: client, streamClient: streamClient}
+	}
+}
+
+// postJSON sends body to url and returns the raw response body
+func postJSON(client *http.Client, url string, body interface{}, headers map[string]string) ([]byte, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// postStream sends body to url and returns the open response for a streaming reply.
+// Non-2xx responses are read and returned as an error.
+func postStream(ctx context.Context, client *http.Client, url string, body interface{}, headers map[string]string) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+// probeGet sends a GET request and fails unless the response is 2xx
+func probeGet(ctx context.Context, client *http.Client, url string, headers map[string]string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// readSSE parses a server-sent event stream and calls fn for each event.
+// Multi-line data fields are joined with newlines as the SSE spec describes.
+func readSSE(r io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := fn(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
+
+// Universal request/response structures for different LLM providers
+
+// GeminiRequest represents the request structure for Gemini API
+type GeminiRequest struct {
+	Contents         []GeminiContent         `json:"contents"`
+	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GeminiContent struct {
+	Parts []GeminiPart `json:"parts"`
+}
+
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+type GeminiGenerationConfig struct {
+	Temperature     *float64               `json:"temperature,omitempty"`
+	MaxOutputTokens *int                   `json:"maxOutputTokens,omitempty"`
+	ResponseMIME    string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema  map[string]interface{} `json:"responseSchema,omitempty"`
+}
+
+// GeminiResponse represents the response from Gemini API
+type GeminiResponse struct {
+	Candidates    []GeminiCandidate    `json:"candidates"`
+	UsageMetadata *GeminiUsageMetadata `json:"usageMetadata,omitempty"`
+	Error         *GeminiError         `json:"error,omitempty"`
+}
+
+// GeminiUsageMetadata reports token counts; streamed chunks carry running totals
+type GeminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+}
+
+func (m *GeminiUsageMetadata) usage() LLMUsage {
+	if m == nil {
+		return LLMUsage{}
+	}
+	return LLMUsage{PromptTokens: m.PromptTokenCount, CompletionTokens: m.CandidatesTokenCount}
+}
+
+type GeminiCandidate struct {
+	Content GeminiContent `json:"content"`
+}
+
+type GeminiError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+// geminiProvider calls the Gemini generateContent API
+type geminiProvider struct {
+	config       LLMConfig
+	client       *http.Client
+	streamClient *http.Client
+}
+
+func (p *geminiProvider) Name() ProviderName { return ProviderGemini }
+func (p *geminiProvider) Model() string      { return p.config.Model }
+func (p *geminiProvider) BaseURL() string    { return p.config.BaseURL }
+func (p *geminiProvider) Ready() bool        { return p.config.APIKey != "" }
+
+func (p *geminiProvider) Complete(request LLMRequest) (*LLMResponse, error) {
+	url := fmt.Sprintf("%s/%s:generateContent", strings.TrimSuffix(p.config.BaseURL, "/"), p.config.Model)
+
+	body, err := postJSON(p.client, url, p.buildRequest(request), p.headers())
+	if err != nil {
+		return nil, err
+	}
+
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return nil, err
+	}
+
+	if geminiResp.Error != nil {
+		return nil, fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
+	}
+
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no response from Gemini")
+	}
+
+	return &LLMResponse{Text: geminiResp.Candidates[0].Content.Parts[0].Text, Usage: geminiResp.UsageMetadata.usage()}, nil
+}
+
+// Stream uses streamGenerateContent with alt=sse; each event is a partial GeminiResponse
+func (p *geminiProvider) Stream(ctx context.Context, request LLMRequest, onChunk func(string) error) (*LLMResponse, error) {
+	url := fmt.Sprintf("%s/%s:streamGenerateContent?alt=sse", strings.TrimSuffix(p.config.BaseURL, "/"), p.config.Model)
+
+	resp, err := postStream(ctx, p.streamClient, url, p.buildRequest(request), p.headers())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var usage LLMUsage
+	err = readSSE(resp.Body, func(event, data string) error {
+		var chunk GeminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("Gemini API error: %s", chunk.Error.Message)
+		}
+		if chunk.UsageMetadata != nil {
+			usage = chunk.UsageMetadata.usage()
+		}
+		for _, candidate := range chunk.Candidates {
+			for _, part := range candidate.Content.Parts {
+				if part.Text == "" {
+					continue
+				}
+				text.WriteString(part.Text)
+				if err := onChunk(part.Text); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no response from Gemini")
+	}
+	return &LLMResponse{Text: text.String(), Usage: usage}, nil
+}
+
+// headers authenticate with a header rather than the key query parameter, so the key
+// cannot appear in the URLs that transport errors quote
+func (p *geminiProvider) headers() map[string]string {
+	return map[string]string{"x-goog-api-key": p.config.APIKey}
+}
+
+// Probe fetches the model's metadata
+func (p *geminiProvider) Probe(ctx context.Context) error {
+	return probeGet(ctx, p.client, strings.TrimSuffix(p.config.BaseURL, "/")+"/"+p.config.Model, p.headers())
+}
+
+func (p *geminiProvider) buildRequest(request LLMRequest) GeminiRequest {
+	prompt := request.Prompt
+	if request.System != "" {
+		prompt = request.System + "\n\n" + prompt
+	}
+
+	maxTokens := p.config.maxTokens(request)
+	requestBody := GeminiRequest{
+		Contents: []GeminiContent{
+			{
+				Parts: []GeminiPart{
+					{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: &GeminiGenerationConfig{
+			Temperature:     &p.config.Temperature,
+			MaxOutputTokens: &maxTokens,
+		},
+	}
+	if request.ExpectJSON || request.Schema != nil {
+		requestBody.GenerationConfig.ResponseMIME = "application/json"
+	}
+	if request.Schema != nil {
+		requestBody.GenerationConfig.ResponseSchema = request.Schema.Gemini()
+	}
+	return requestBody
+}
+
+// ClaudeResponse represents the response from Claude API
+type ClaudeResponse struct {
+	Content []ClaudeContent `json:"content"`
+	Usage   ClaudeUsage     `json:"usage"`
+	Error   *ClaudeError    `json:"error,omitempty"`
+}
+
+// ClaudeUsage reports input and output token counts
+type ClaudeUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type ClaudeContent struct {
+	Text  string          `json:"text"`
+	Type  string          `json:"type"`
+	Input json.RawMessage `json:"input,omitempty"` // tool_use arguments
+}
+
+type ClaudeError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// Claude Messages API requires content blocks and takes the system prompt as a top-level field
+type claudeContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type claudeMessage struct {
+	Role    string               `json:"role"`
+	Content []claudeContentBlock `json:"content"`
+}
+
+// claudeProvider calls the Anthropic Messages API
+type claudeProvider struct {
+	config       LLMConfig
+	client       *http.Client
+	streamClient *http.Client
+}
+
+// claudeRequest is the Messages API request body
+type claudeRequest struct {
+	Model       string          `json:"model"`
+	System      string          `json:"system,omitempty"`
+	Messages    []claudeMessage `json:"messages"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature float64         `json:"temperature"`
+	Stream      bool            `json:"stream,omitempty"`
+	Tools       []claudeTool    `json:"tools,omitempty"`
+	ToolChoice  *claudeToolPick `json:"tool_choice,omitempty"`
+}
+
+// claudeTool describes a tool the model can call. Structured output is a forced call
+// of a tool whose input schema is the response schema.
+type claudeTool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema *JSONSchema `json:"input_schema"`
+}
+
+type claudeToolPick struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// claudeStreamEvent covers the fields used from Messages API stream events
+type claudeStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"` // input_json_delta of a tool call
+	} `json:"delta"`
+	Message struct {
+		Usage ClaudeUsage `json:"usage"`
+	} `json:"message"`
+	Usage ClaudeUsage  `json:"usage"`
+	Error *ClaudeError `json:"error,omitempty"`
+}
+
+func (p *claudeProvider) Name() ProviderName { return ProviderClaude }
+func (p *claudeProvider) Model() string      { return p.config.Model }
+func (p *claudeProvider) BaseURL() string    { return p.config.BaseURL }
+func (p *claudeProvider) Ready() bool        { return p.config.APIKey != "" }
+
+func (p *claudeProvider) buildRequest(request LLMRequest, stream bool) claudeRequest {
+	requestBody := claudeRequest{
+		Model:  p.config.Model,
+		System: request.System,
+		Messages: []claudeMessage{
+			{Role: "user", Content: []claudeContentBlock{{Type: "text", Text: request.Prompt}}},
+		},
+		MaxTokens:   p.config.maxTokens(request),
+		Temperature: p.config.Temperature,
+		Stream:      stream,
+	}
+	if request.Schema != nil {
+		requestBody.Tools = []claudeTool{{
+			Name:        request.SchemaName,
+			Description: "Submit the response. Its input is the complete answer.",
+			InputSchema: request.Schema,
+		}}
+		requestBody.ToolChoice = &claudeToolPick{Type: "tool", Name: request.SchemaName}
+	}
+	return requestBody
+}
+
+func (p *claudeProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.config.APIKey,
+		"anthropic-version": "2023-06-01",
+	}
+}
+
+// Probe lists the models, at the models endpoint next to the messages endpoint
+func (p *claudeProvider) Probe(ctx context.Context) error {
+	url := strings.TrimSuffix(p.config.BaseURL, "/")
+	url = strings.TrimSuffix(url, "/messages") + "/models"
+	return probeGet(ctx, p.client, url, p.headers())
+}
+
+func (p *claudeProvider) Complete(request LLMRequest) (*LLMResponse, error) {
+	body, err := postJSON(p.client, p.config.BaseURL, p.buildRequest(request, false), p.headers())
+	if err != nil {
+		return nil, err
+	}
+
+	var claudeResp ClaudeResponse
+	if err := json.Unmarshal(body, &claudeResp); err != nil {
+		return nil, err
+	}
+
+	if claudeResp.Error != nil {
+		return nil, fmt.Errorf("Claude API error: %s", claudeResp.Error.Message)
+	}
+
+	if len(claudeResp.Content) == 0 {
+		return nil, fmt.Errorf("no response from Claude")
+	}
+
+	// A forced tool call carries the structured reply as the tool's input
+	text := claudeResp.Content[0].Text
+	for _, block := range claudeResp.Content {
+		if block.Type == "tool_use" && len(block.Input) > 0 {
+			text = string(block.Input)
+			break
+		}
+	}
+
+	return &LLMResponse{
+		Text:  text,
+		Usage: LLMUsage{PromptTokens: claudeResp.Usage.InputTokens, CompletionTokens: claudeResp.Usage.OutputTokens},
+	}, nil
+}
+
+// Stream reads text deltas from content_block_delta events until message_stop
+func (p *claudeProvider) Stream(ctx context.Context, request LLMRequest, onChunk func(string) error) (*LLMResponse, error) {
+	resp, err := postStream(ctx, p.streamClient, p.config.BaseURL, p.buildRequest(request, true), p.headers())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var usage LLMUsage
+	err = readSSE(resp.Body, func(event, data string) error {
+		var chunk claudeStreamEvent
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		switch chunk.Type {
+		case "error":
+			message := "unknown error"
+			if chunk.Error != nil {
+				message = chunk.Error.Message
+			}
+			return fmt.Errorf("Claude API error: %s", message)
+		case "message_start":
+			usage.PromptTokens = chunk.Message.Usage.InputTokens
+		case "message_delta":
+			// output_tokens is cumulative
+			usage.CompletionTokens = chunk.Usage.OutputTokens
+		case "content_block_delta":
+			delta := chunk.Delta.Text
+			if chunk.Delta.Type == "input_json_delta" {
+				delta = chunk.Delta.PartialJSON
+			}
+			if delta == "" {
+				return nil
+			}
+			text.WriteString(delta)
+			return onChunk(delta)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no response from Claude")
+	}
+	return &LLMResponse{Text: text.String(), Usage: usage}, nil
+}
+
+// OpenAIRequest represents the request structure for OpenAI API
+type OpenAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []Message             `json:"messages"`
+	MaxTokens      int                   `json:"max_tokens"`
+	Temperature    float64               `json:"temperature"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	StreamOptions  *OpenAIStreamOptions  `json:"stream_options,omitempty"`
+}
+
+// OpenAIStreamOptions asks for a final chunk carrying token usage
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// OpenAIUsage reports token counts
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *OpenAIUsage) usage() LLMUsage {
+	if u == nil {
+		return LLMUsage{}
+	}
+	return LLMUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+// OpenAIResponseFormat selects JSON mode, or structured output with a JSON schema
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// OpenAIJSONSchema is the json_schema response format
+type OpenAIJSONSchema struct {
+	Name   string      `json:"name"`
+	Strict bool        `json:"strict"`
+	Schema *JSONSchema `json:"schema"`
+}
+
+// Message represents a message in the OpenAI chat
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OpenAIResponse represents the response from OpenAI API
+type OpenAIResponse struct {
+	Choices []Choice     `json:"choices"`
+	Usage   *OpenAIUsage `json:"usage,omitempty"`
+	Error   *OpenAIError `json:"error,omitempty"`
+}
+
+// Choice represents a choice in OpenAI response
+type Choice struct {
+	Message Message `json:"message"`
+}
+
+// openAIStreamChunk is one chat.completion.chunk from a streaming response
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta Message `json:"delta"`
+	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage,omitempty"`
+	Error *OpenAIError `json:"error,omitempty"`
+}
+
+// OpenAIError represents an error from OpenAI API
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// openAIProvider calls an OpenAI chat completions endpoint: OpenAI itself, or any
+// server that speaks the same API (Ollama, vLLM, llama.cpp) at a configurable base URL
+type openAIProvider struct {
+	config       LLMConfig
+	client       *http.Client
+	streamClient *http.Client
+	requireKey   bool
+}
+
+func (p *openAIProvider) Name() ProviderName {
+	if p.requireKey {
+		return ProviderOpenAI
+	}
+	return ProviderOpenAICompatible
+}
+func (p *openAIProvider) Model() string   { return p.config.Model }
+func (p *openAIProvider) BaseURL() string { return p.config.BaseURL }
+func (p *openAIProvider) Ready() bool     { return !p.requireKey || p.config.APIKey != "" }
+
+// endpoint accepts either a base URL (".../v1") or the full chat completions URL
+func (p *openAIProvider) endpoint() string {
+	url := strings.TrimSuffix(p.config.BaseURL, "/")
+	if strings.HasSuffix(url, "/chat/completions") {
+		return url
+	}
+	return url + "/chat/completions"
+}
+
+func (p *openAIProvider) Complete(request LLMRequest) (*LLMResponse, error) {
+	body, err := postJSON(p.client, p.endpoint(), p.buildRequest(request, false), p.headers())
+	if err != nil {
+		return nil, err
+	}
+
+	var openAIResp OpenAIResponse
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		return nil, err
+	}
+
+	if openAIResp.Error != nil {
+		return nil, fmt.Errorf("%s API error: %s", p.Name(), openAIResp.Error.Message)
+	}
+
+	if len(openAIResp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from %s", p.Name())
+	}
+
+	return &LLMResponse{Text: openAIResp.Choices[0].Message.Content, Usage: openAIResp.Usage.usage()}, nil
+}
+
+// Stream reads delta content from chat.completion.chunk events until [DONE]
+func (p *openAIProvider) Stream(ctx context.Context, request LLMRequest, onChunk func(string) error) (*LLMResponse, error) {
+	resp, err := postStream(ctx, p.streamClient, p.endpoint(), p.buildRequest(request, true), p.headers())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var usage LLMUsage
+	err = readSSE(resp.Body, func(event, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("%s API error: %s", p.Name(), chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			text.WriteString(choice.Delta.Content)
+			if err := onChunk(choice.Delta.Content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no response from %s", p.Name())
+	}
+	return &LLMResponse{Text: text.String(), Usage: usage}, nil
+}
+
+// Probe lists the models, which OpenAI and the common self-hosted servers all support
+func (p *openAIProvider) Probe(ctx context.Context) error {
+	url := strings.TrimSuffix(strings.TrimSuffix(p.endpoint(), "/chat/completions"), "/")
+	return probeGet(ctx, p.client, url+"/models", p.headers())
+}
+
+func (p *openAIProvider) headers() map[string]string {
+	headers := map[string]string{}
+	if p.config.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.config.APIKey
+	}
+	return headers
+}
+
+func (p *openAIProvider) buildRequest(request LLMRequest, stream bool) OpenAIRequest {
+	var messages []Message
+	if request.System != "" {
+		messages = append(messages, Message{Role: "system", Content: request.System})
+	}
+	messages = append(messages, Message{Role: "user", Content: request.Prompt})
+
+	requestBody := OpenAIRequest{
+		Model:       p.config.Model,
+		Messages:    messages,
+		MaxTokens:   p.config.maxTokens(request),
+		Temperature: p.config.Temperature,
+		Stream:      stream,
+	}
+	if stream {
+		requestBody.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
+	if request.Schema != nil {
+		requestBody.ResponseFormat = &OpenAIResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &OpenAIJSONSchema{Name: request.SchemaName, Strict: true, Schema: request.Schema.Strict()},
+		}
+	} else if request.ExpectJSON {
+		// Only force json_object when the prompt expects a single JSON object, not an array
+		if strings.Contains(strings.ToLower(request.Prompt), "single json object") {
+			requestBody.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+		}
+	}
+	return requestBody
+}
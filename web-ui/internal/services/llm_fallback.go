@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBreakerFailures consecutive failures open a provider's circuit
+	defaultBreakerFailures = 3
+	// defaultBreakerCooldown is how long an open circuit skips the provider before
+	// letting a trial request through
+	defaultBreakerCooldown = 30 * time.Second
+	// probeTimeout bounds each status probe
+	probeTimeout = 5 * time.Second
+	// probeTTL is how long probe results are reused by the status endpoint
+	probeTTL = 30 * time.Second
+)
+
+// LoadLLMConfigs resolves the providers of a feature in the order they are tried: the
+// provider LoadLLMConfig resolves, then AI_<FEATURE>_FALLBACK_PROVIDERS or
+// AI_FALLBACK_PROVIDERS. Fallbacks are comma-separated entries of the form
+// provider[:model][@base-url], e.g. "openai:gpt-4o-mini, ollama:llama3.1@http://gpu:11434/v1".
+// Their API keys come from the provider's usual variable.
+func LoadLLMConfigs(feature AIFeature) []LLMConfig {
+	primary := LoadLLMConfig(feature)
+	configs := []LLMConfig{primary}
+
+	list := featureEnv(feature, "FALLBACK_PROVIDERS")
+	if list == "" {
+		list = strings.TrimSpace(os.Getenv("AI_FALLBACK_PROVIDERS"))
+	}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var model, baseURL string
+		if at := strings.Index(entry, "@"); at != -1 {
+			entry, baseURL = entry[:at], strings.TrimSpace(entry[at+1:])
+		}
+		// Model names may contain colons themselves (qwen2.5-coder:7b)
+		name, model, _ := strings.Cut(entry, ":")
+
+		provider := parseProviderName(name)
+		defaults := providerDefaults[provider]
+		config := LLMConfig{
+			Provider:    provider,
+			Model:       strings.TrimSpace(model),
+			BaseURL:     baseURL,
+			APIKey:      getAPIKeyFromEnvFor(provider),
+			MaxTokens:   primary.MaxTokens,
+			Temperature: primary.Temperature,
+			MockDir:     primary.MockDir,
+			RecordDir:   primary.RecordDir,
+		}
+		if config.Model == "" {
+			config.Model = defaults.model
+		}
+		if config.BaseURL == "" {
+			config.BaseURL = defaults.baseURL
+		}
+
+		duplicate := false
+		for _, existing := range configs {
+			duplicate = duplicate || (existing.Provider == config.Provider && existing.Model == config.Model && existing.BaseURL == config.BaseURL)
+		}
+		if !duplicate {
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
+
+// circuitBreaker stops requests to a provider after repeated failures. After the
+// cooldown a single trial request is let through (half-open): success closes the
+// circuit again, failure keeps it open for another cooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int // consecutive failures
+	openUntil time.Time
+	trial     bool // a half-open trial request is in flight
+	lastError string
+	lastFail  time.Time
+}
+
+// allow reports whether a request may be sent
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.failures < b.threshold:
+		return true
+	case time.Now().Before(b.openUntil), b.trial:
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.trial = 0, false
+}
+
+func (b *circuitBreaker) failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trial = false
+	b.lastError, b.lastFail = err.Error(), time.Now()
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// release ends a request that says nothing about the provider's health, such as one
+// the client abandoned
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// state returns "closed", "open" or "half-open"
+func (b *circuitBreaker) state() (string, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.failures < b.threshold:
+		return "closed", 0
+	case time.Now().Before(b.openUntil):
+		return "open", time.Until(b.openUntil)
+	}
+	return "half-open", 0
+}
+
+// breakerSet hands out one breaker per provider endpoint, so features that use the
+// same provider share its circuit
+type breakerSet struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// newBreakerSet reads AI_BREAKER_FAILURES and AI_BREAKER_COOLDOWN
+func newBreakerSet() *breakerSet {
+	set := &breakerSet{threshold: defaultBreakerFailures, cooldown: defaultBreakerCooldown, breakers: make(map[string]*circuitBreaker)}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("AI_BREAKER_FAILURES"))); err == nil && n > 0 {
+		set.threshold = n
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("AI_BREAKER_COOLDOWN"))); err == nil && d > 0 {
+		set.cooldown = d
+	}
+	return set
+}
+
+func (s *breakerSet) get(key string) *circuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.breakers[key]; ok {
+		return b
+	}
+	b := &circuitBreaker{threshold: s.threshold, cooldown: s.cooldown}
+	s.breakers[key] = b
+	return b
+}
+
+// providerKey identifies a provider endpoint
+func providerKey(provider LLMProvider) string {
+	return string(provider.Name()) + "|" + provider.Model() + "|" + provider.BaseURL()
+}
+
+// providerChain tries its providers in order and fails over to the next one when a
+// provider errors, times out or has an open circuit
+type providerChain struct {
+	links []chainLink
+}
+
+type chainLink struct {
+	provider LLMProvider
+	breaker  *circuitBreaker
+}
+
+// newProviderChain creates the providers of configs with their shared breakers
+func newProviderChain(configs []LLMConfig, breakers *breakerSet) *providerChain {
+	chain := &providerChain{}
+	for _, config := range configs {
+		provider := NewLLMProvider(config)
+		chain.links = append(chain.links, chainLink{provider: provider, breaker: breakers.get(providerKey(provider))})
+	}
+	return chain
+}
+
+// active is the provider the next request goes to: the first ready one with a closed circuit
+func (c *providerChain) active() LLMProvider {
+	for _, link := range c.links {
+		if state, _ := link.breaker.state(); link.provider.Ready() && state != "open" {
+			return link.provider
+		}
+	}
+	return c.links[0].provider
+}
+
+func (c *providerChain) Name() ProviderName { return c.active().Name() }
+func (c *providerChain) Model() string      { return c.active().Model() }
+func (c *providerChain) BaseURL() string    { return c.active().BaseURL() }
+
+func (c *providerChain) Ready() bool {
+	for _, link := range c.links {
+		if link.provider.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *providerChain) Probe(ctx context.Context) error {
+	return c.active().Probe(ctx)
+}
+
+func (c *providerChain) Complete(request LLMRequest) (*LLMResponse, error) {
+	return c.try(func(provider LLMProvider) (*LLMResponse, bool, error) {
+		response, err := provider.Complete(request)
+		return response, true, err
+	})
+}
+
+// Stream fails over only until the first chunk has been passed on: after that the
+// client has part of an answer, so an error ends the stream
+func (c *providerChain) Stream(ctx context.Context, request LLMRequest, onChunk func(string) error) (*LLMResponse, error) {
+	return c.try(func(provider LLMProvider) (*LLMResponse, bool, error) {
+		started := false
+		var chunkErr error
+		response, err := provider.Stream(ctx, request, func(text string) error {
+			started = true
+			chunkErr = onChunk(text)
+			return chunkErr
+		})
+		if err != nil && (chunkErr != nil || ctx.Err() != nil) {
+			// The client went away; that says nothing about the provider
+			return nil, false, errClientGone{err}
+		}
+		return response, !started, err
+	})
+}
+
+// errClientGone wraps the error of a request the client abandoned
+type errClientGone struct{ error }
+
+func (e errClientGone) Unwrap() error { return e.error }
+
+// try calls each usable provider in turn until one succeeds. call reports whether a
+// failure may be retried with the next provider.
+func (c *providerChain) try(call func(LLMProvider) (*LLMResponse, bool, error)) (*LLMResponse, error) {
+	var failures []string
+	var lastErr error
+	for i, link := range c.links {
+		name := link.provider.Name()
+		if !link.provider.Ready() {
+			continue
+		}
+		if !link.breaker.allow() {
+			_, wait := link.breaker.state()
+			lastErr = fmt.Errorf("%s is unavailable after repeated failures; retrying in %s", name, wait.Round(time.Second))
+			failures = append(failures, lastErr.Error())
+			continue
+		}
+
+		response, retryable, err := call(link.provider)
+		if err == nil {
+			link.breaker.success()
+			return response, nil
+		}
+		var gone errClientGone
+		if errors.As(err, &gone) {
+			link.breaker.release()
+			return nil, gone.error
+		}
+		link.breaker.failure(err)
+		lastErr = err
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		if !retryable {
+			return nil, err
+		}
+		if i < len(c.links)-1 {
+			log.Printf("AI provider %s (%s) failed, trying the next one: %v", name, link.provider.Model(), err)
+		}
+	}
+
+	switch {
+	case lastErr == nil:
+		return nil, ErrAINotConfigured
+	case len(failures) == 1:
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("all AI providers failed: %s", strings.Join(failures, "; "))
+}
+
+// ProviderStatus is the health of one provider of a feature
+type ProviderStatus struct {
+	Provider  ProviderName `json:"provider"`
+	Model     string       `json:"model"`
+	BaseURL   string       `json:"base_url"`
+	Ready     bool         `json:"ready"`   // configured, e.g. an API key is set
+	Circuit   string       `json:"circuit"` // closed, open or half-open
+	Failures  int          `json:"consecutive_failures"`
+	LastError string       `json:"last_error,omitempty"`
+	// Reachable and LatencyMs come from the last probe
+	Reachable  bool      `json:"reachable"`
+	LatencyMs  int64     `json:"latency_ms"`
+	ProbeError string    `json:"probe_error,omitempty"`
+	ProbedAt   time.Time `json:"probed_at"`
+}
+
+// FeatureStatus lists a feature's providers in the order they are tried
+type FeatureStatus struct {
+	Feature   AIFeature        `json:"feature"`
+	Active    ProviderName     `json:"active"` // where the next request goes
+	Available bool             `json:"available"`
+	Providers []ProviderStatus `json:"providers"`
+}
+
+// probeResult is a cached probe of one provider endpoint
+type probeResult struct {
+	err     string
+	latency time.Duration
+	at      time.Time
+}
+
+// Status probes every provider (reusing results younger than probeTTL unless refresh is
+// set) and reports reachability, latency and circuit state per feature. API keys and
+// other secrets are never included.
+func (ai *AIService) Status(ctx context.Context, refresh bool) []FeatureStatus {
+	unique := make(map[string]LLMProvider)
+	for _, feature := range AIFeatures {
+		for _, link := range ai.chain(feature).links {
+			unique[providerKey(link.provider)] = link.provider
+		}
+	}
+
+	var wg sync.WaitGroup
+	for key, provider := range unique {
+		ai.probeMu.Lock()
+		cached, ok := ai.probes[key]
+		ai.probeMu.Unlock()
+		if ok && !refresh && time.Since(cached.at) < probeTTL {
+			continue
+		}
+		if !provider.Ready() {
+			ai.storeProbe(key, probeResult{err: "not configured (missing API key)", at: time.Now()})
+			continue
+		}
+
+		wg.Add(1)
+		go func(key string, provider LLMProvider) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+			start := time.Now()
+			result := probeResult{at: start}
+			if err := provider.Probe(probeCtx); err != nil {
+				result.err = truncateMessage(err.Error())
+			}
+			result.latency = time.Since(start)
+			ai.storeProbe(key, result)
+		}(key, provider)
+	}
+	wg.Wait()
+
+	ai.probeMu.Lock()
+	defer ai.probeMu.Unlock()
+	var statuses []FeatureStatus
+	for _, feature := range AIFeatures {
+		chain := ai.chain(feature)
+		status := FeatureStatus{Feature: feature, Active: chain.active().Name()}
+		for _, link := range chain.links {
+			circuit, _ := link.breaker.state()
+			link.breaker.mu.Lock()
+			failures, lastError := link.breaker.failures, link.breaker.lastError
+			link.breaker.mu.Unlock()
+
+			probe := ai.probes[providerKey(link.provider)]
+			provider := ProviderStatus{
+				Provider:   link.provider.Name(),
+				Model:      link.provider.Model(),
+				BaseURL:    link.provider.BaseURL(),
+				Ready:      link.provider.Ready(),
+				Circuit:    circuit,
+				Failures:   failures,
+				LastError:  truncateMessage(lastError),
+				Reachable:  probe.err == "" && !probe.at.IsZero(),
+				LatencyMs:  probe.latency.Milliseconds(),
+				ProbeError: probe.err,
+				ProbedAt:   probe.at,
+			}
+			status.Available = status.Available || (provider.Reachable && circuit != "open")
+			status.Providers = append(status.Providers, provider)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (ai *AIService) storeProbe(key string, result probeResult) {
+	ai.probeMu.Lock()
+	defer ai.probeMu.Unlock()
+	ai.probes[key] = result
+}
+
+// chain returns the provider chain of a feature
+func (ai *AIService) chain(feature AIFeature) *providerChain {
+	if chain, ok := ai.providers[feature].(*providerChain); ok {
+		return chain
+	}
+	provider := ai.providers[feature]
+	return &providerChain{links: []chainLink{{provider: provider, breaker: &circuitBreaker{threshold: defaultBreakerFailures}}}}
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// DefaultLanguage is the language of README.md, learning.md and hints.md
+const DefaultLanguage = "en"
+
+// Localized parts of a challenge, as reported in Untranslated and the coverage report
+const (
+	PartReadme   = "README"
+	PartLearning = "learning"
+	PartHints    = "hints"
+	PartMetadata = "metadata"
+)
+
+var (
+	// localizedFilePattern matches README.es.md, learning.pt-BR.md, hints.zh_CN.md, ...
+	localizedFilePattern = regexp.MustCompile(`^(README|learning|hints)\.([A-Za-z]{2,3}(?:[-_][A-Za-z0-9]{2,8})?)\.md$`)
+	languagePattern      = regexp.MustCompile(`^[a-z]{2,3}(?:-[a-z0-9]{2,8})?$`)
+)
+
+// NormalizeLanguage returns a language tag in the form used for file names and
+// lookups ("pt_BR" becomes "pt-br"), or "" if it is not a language tag
+func NormalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.Replace(strings.TrimSpace(tag), "_", "-", -1))
+	if !languagePattern.MatchString(tag) {
+		return ""
+	}
+	return tag
+}
+
+// ParseAcceptLanguage returns the languages of an Accept-Language header, most
+// preferred first. Languages with q=0 and the "*" wildcard are left out.
+func ParseAcceptLanguage(header string) []string {
+	type preference struct {
+		language string
+		quality  float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		language := NormalizeLanguage(fields[0])
+		if language == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && key == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{language, quality})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].quality > preferences[j].quality })
+
+	languages := make([]string, len(preferences))
+	for i, p := range preferences {
+		languages[i] = p.language
+	}
+	return languages
+}
+
+// MatchLanguage picks the first preferred language that is available: exactly, by its
+// base language ("pt-br" matches "pt") or as a regional variant ("pt" matches "pt-br").
+// English, or nothing matching, gives DefaultLanguage.
+func MatchLanguage(preferences []string, available []string) string {
+	for _, preferred := range preferences {
+		base, _, _ := strings.Cut(preferred, "-")
+		if base == DefaultLanguage {
+			return DefaultLanguage
+		}
+		if containsString(available, preferred) {
+			return preferred
+		}
+		if containsString(available, base) {
+			return base
+		}
+		for _, language := range available {
+			if strings.HasPrefix(language, base+"-") {
+				return language
+			}
+		}
+	}
+	return DefaultLanguage
+}
+
+// ContentLanguages returns every language challenge content is translated into, besides
+// English, sorted
+func ContentLanguages(cs *ChallengeService, ps *PackageService) []string {
+	return mergeLanguages(cs.Languages(), ps.Languages())
+}
+
+// mergeLanguages returns the sorted union of language lists
+func mergeLanguages(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var languages []string
+	for _, list := range lists {
+		for _, language := range list {
+			if !seen[language] {
+				seen[language] = true
+				languages = append(languages, language)
+			}
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// loadTranslations reads the localized files of a challenge directory and the
+// translations in its metadata. It returns nil when there are none.
+func loadTranslations(dir string, metadata *models.ChallengeMetadata) map[string]*models.ChallengeTranslation {
+	translations := make(map[string]*models.ChallengeTranslation)
+	translation := func(language string) *models.ChallengeTranslation {
+		if translations[language] == nil {
+			translations[language] = &models.ChallengeTranslation{}
+		}
+		return translations[language]
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		m := localizedFilePattern.FindStringSubmatch(entry.Name())
+		language := ""
+		if m != nil {
+			language = NormalizeLanguage(m[2])
+		}
+		if language == "" || language == DefaultLanguage || entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil || strings.TrimSpace(string(content)) == "" {
+			continue
+		}
+		switch m[1] {
+		case PartReadme:
+			translation(language).Description = string(content)
+		case PartLearning:
+			translation(language).LearningMaterials = string(content)
+		case PartHints:
+			translation(language).Hints = string(content)
+		}
+	}
+
+	if metadata != nil {
+		for tag, fields := range metadata.Translations {
+			if language := NormalizeLanguage(tag); language != "" && language != DefaultLanguage && fields != nil {
+				translation(language).Metadata = fields
+			}
+		}
+	}
+
+	if len(translations) == 0 {
+		return nil
+	}
+	return translations
+}
+
+// translationLanguages returns the languages of a set of translations, sorted
+func translationLanguages(translations map[string]*models.ChallengeTranslation) []string {
+	languages := make([]string, 0, len(translations))
+	for language := range translations {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// missingParts lists the parts of a challenge that exist in English but have no translation
+func missingParts(translation *models.ChallengeTranslation, hasLearning, hasHints, hasMetadata bool) []string {
+	if translation == nil {
+		translation = &models.ChallengeTranslation{}
+	}
+	var missing []string
+	if translation.Description == "" {
+		missing = append(missing, PartReadme)
+	}
+	if hasLearning && translation.LearningMaterials == "" {
+		missing = append(missing, PartLearning)
+	}
+	if hasHints && translation.Hints == "" {
+		missing = append(missing, PartHints)
+	}
+	if hasMetadata && translation.Metadata == nil {
+		missing = append(missing, PartMetadata)
+	}
+	return missing
+}
+
+// LocalizeChallenge returns a copy of a classic challenge with its content in language,
+// part by part: whatever is not translated stays in English and is listed in Untranslated
+func LocalizeChallenge(challenge *models.Challenge, language string) *models.Challenge {
+	translation := challenge.Translations[language]
+	if translation == nil {
+		return challenge
+	}
+
+	localized := *challenge
+	localized.Language = language
+	localized.Untranslated = missingParts(translation,
+		challenge.LearningMaterials != noLearningMaterials, challenge.Hints != noHints, false)
+	if translation.Description != "" {
+		localized.Description = filterWebUIDescription(translation.Description)
+	}
+	if translation.LearningMaterials != "" {
+		localized.LearningMaterials = translation.LearningMaterials
+	}
+	if translation.Hints != "" {
+		localized.Hints = translation.Hints
+	}
+	if m := translation.Metadata; m != nil {
+		localized.Title = pickString(m.Title, localized.Title)
+		localized.ShortDescription = pickString(m.ShortDescription, localized.ShortDescription)
+		localized.LearningObjectives = pickStrings(m.LearningObjectives, localized.LearningObjectives)
+	}
+	return &localized
+}
+
+// LocalizePackageChallenge is LocalizeChallenge for package challenges
+func LocalizePackageChallenge(challenge *models.PackageChallenge, language string) *models.PackageChallenge {
+	translation := challenge.Translations[language]
+	if translation == nil {
+		return challenge
+	}
+
+	localized := *challenge
+	localized.Language = language
+	localized.Untranslated = missingParts(translation,
+		challenge.LearningMaterials != noLearningMaterials, challenge.Hints != noHints, false)
+	if translation.Description != "" {
+		localized.Description = translation.Description
+	}
+	if translation.LearningMaterials != "" {
+		localized.LearningMaterials = translation.LearningMaterials
+	}
+	if translation.Hints != "" {
+		localized.Hints = translation.Hints
+	}
+	if m := translation.Metadata; m != nil {
+		localized.Title = pickString(m.Title, localized.Title)
+		localized.ShortDescription = pickString(m.ShortDescription, localized.ShortDescription)
+		localized.LearningObjectives = pickStrings(m.LearningObjectives, localized.LearningObjectives)
+		localized.RealWorldConnection = pickString(m.RealWorldConnection, localized.RealWorldConnection)
+		localized.Requirements = pickStrings(m.Requirements, localized.Requirements)
+		localized.BonusPoints = pickStrings(m.BonusPoints, localized.BonusPoints)
+	}
+	return &localized
+}
+
+func pickString(translated, english string) string {
+	if strings.TrimSpace(translated) != "" {
+		return translated
+	}
+	return english
+}
+
+func pickStrings(translated, english []string) []string {
+	if len(translated) > 0 {
+		return translated
+	}
+	return english
+}
+
+// PartCoverage counts the translated files of one kind
+type PartCoverage struct {
+	Translated int `json:"translated"`
+	Total      int `json:"total"`
+}
+
+// LanguageCoverage is how much of the content is translated into one language
+type LanguageCoverage struct {
+	Language   string                   `json:"language"`
+	Translated int                      `json:"translated"`
+	Total      int                      `json:"total"`
+	Percent    float64                  `json:"percent"`
+	Parts      map[string]*PartCoverage `json:"parts"`   // by PartReadme, PartLearning, ...
+	Missing    []string                 `json:"missing"` // e.g. "challenge-3/hints", "gin/challenge-1-basic-routing/README"
+}
+
+// TranslationCoverage reports, for every language with at least one translation, how
+// many READMEs, learning materials, hints and metadata files of the classic and package
+// challenges are translated. Only parts that exist in English count.
+func TranslationCoverage(cs *ChallengeService, ps *PackageService) []*LanguageCoverage {
+	type item struct {
+		name                               string
+		translations                       map[string]*models.ChallengeTranslation
+		hasLearning, hasHints, hasMetadata bool
+	}
+	var items []item
+
+	challenges := cs.GetChallenges()
+	ids := make([]int, 0, len(challenges))
+	for id := range challenges {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		dir := filepath.Join("..", "challenge-"+strconv.Itoa(id))
+		items = append(items, item{
+			name:         "challenge-" + strconv.Itoa(id),
+			translations: challenges[id].Translations,
+			hasLearning:  fileExists(filepath.Join(dir, "learning.md")),
+			hasHints:     fileExists(filepath.Join(dir, "hints.md")),
+			hasMetadata:  fileExists(filepath.Join(dir, "metadata.json")),
+		})
+	}
+
+	packages := ps.GetPackages()
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		packageChallenges, err := ps.GetPackageChallenges(name)
+		if err != nil {
+			continue
+		}
+		ids := make([]string, 0, len(packageChallenges))
+		for id := range packageChallenges {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			dir := filepath.Join(ps.packagesPath, name, id)
+			items = append(items, item{
+				name:         name + "/" + id,
+				translations: packageChallenges[id].Translations,
+				hasLearning:  fileExists(filepath.Join(dir, "learning.md")),
+				hasHints:     fileExists(filepath.Join(dir, "hints.md")),
+				hasMetadata:  fileExists(filepath.Join(dir, "metadata.json")),
+			})
+		}
+	}
+
+	var languageLists [][]string
+	for _, it := range items {
+		languageLists = append(languageLists, translationLanguages(it.translations))
+	}
+
+	var report []*LanguageCoverage
+	for _, language := range mergeLanguages(languageLists...) {
+		coverage := &LanguageCoverage{Language: language, Parts: make(map[string]*PartCoverage), Missing: []string{}}
+		for _, part := range []string{PartReadme, PartLearning, PartHints, PartMetadata} {
+			coverage.Parts[part] = &PartCoverage{}
+		}
+		for _, it := range items {
+			present := map[string]bool{PartReadme: true, PartLearning: it.hasLearning, PartHints: it.hasHints, PartMetadata: it.hasMetadata}
+			missing := missingParts(it.translations[language], it.hasLearning, it.hasHints, it.hasMetadata)
+			for part, exists := range present {
+				if !exists {
+					continue
+				}
+				coverage.Parts[part].Total++
+				if !containsString(missing, part) {
+					coverage.Parts[part].Translated++
+				}
+			}
+			for _, part := range missing {
+				coverage.Missing = append(coverage.Missing, it.name+"/"+part)
+			}
+		}
+		for _, part := range coverage.Parts {
+			coverage.Translated += part.Translated
+			coverage.Total += part.Total
+		}
+		if coverage.Total > 0 {
+			coverage.Percent = float64(coverage.Translated*1000/coverage.Total) / 10
+		}
+		report = append(report, coverage)
+	}
+	return report
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"web-ui/internal/models"
@@ -18,6 +19,8 @@ type PackageService struct {
 	packagesPath string
 	// In-memory cache to avoid repeated GitHub API calls (no TTL; load once per process)
 	cachedPackages map[string]*models.Package
+	languages      []string // languages of package challenge translations, nil until scanned
+	mutex          sync.RWMutex
 }
 
 func NewPackageService() *PackageService {
@@ -57,19 +60,69 @@ func (s *PackageService) LoadPackages() error {
 
 func (s *PackageService) GetPackages() map[string]*models.Package {
 	// Serve from cache if already populated
-	if s.cachedPackages != nil {
-		return s.cachedPackages
+	s.mutex.RLock()
+	cached := s.cachedPackages
+	s.mutex.RUnlock()
+	if cached != nil {
+		return cached
 	}
 
+	// Populate cache once per process lifetime (or until RefreshPackages)
+	packages := s.readPackages()
+	s.mutex.Lock()
+	s.cachedPackages = packages
+	s.mutex.Unlock()
+	return packages
+}
+
+// RefreshPackages reloads package metadata and re-fetches GitHub stars
+func (s *PackageService) RefreshPackages() map[string]*models.Package {
+	packages := s.readPackages()
+	s.mutex.Lock()
+	s.cachedPackages = packages
+	s.languages = nil
+	s.mutex.Unlock()
+	return packages
+}
+
+// Languages returns the languages package challenges are translated into, sorted.
+// The challenges are scanned once, and again after RefreshPackages.
+func (s *PackageService) Languages() []string {
+	s.mutex.RLock()
+	languages := s.languages
+	s.mutex.RUnlock()
+	if languages != nil {
+		return languages
+	}
+
+	var lists [][]string
+	for name := range s.GetPackages() {
+		challenges, err := s.GetPackageChallenges(name)
+		if err != nil {
+			continue
+		}
+		for _, challenge := range challenges {
+			lists = append(lists, translationLanguages(challenge.Translations))
+		}
+	}
+	languages = append([]string{}, mergeLanguages(lists...)...)
+
+	s.mutex.Lock()
+	s.languages = languages
+	s.mutex.Unlock()
+	return languages
+}
+
+// readPackages loads every package under packagesPath. A read error yields an
+// empty map so callers do not retry on every request.
+func (s *PackageService) readPackages() map[string]*models.Package {
 	packages := make(map[string]*models.Package)
 
 	// Read packages directory
 	entries, err := os.ReadDir(s.packagesPath)
 	if err != nil {
 		fmt.Printf("Error reading packages directory: %v\n", err)
-		// Populate cache (empty) to prevent repeated attempts this run
-		s.cachedPackages = packages
-		return s.cachedPackages
+		return packages
 	}
 
 	for _, entry := range entries {
@@ -81,9 +134,7 @@ func (s *PackageService) GetPackages() map[string]*models.Package {
 		}
 	}
 
-	// Populate cache once per process lifetime
-	s.cachedPackages = packages
-	return s.cachedPackages
+	return packages
 }
 
 func (s *PackageService) loadPackage(packagePath, packageName string) *models.Package {
@@ -196,24 +247,11 @@ func (s *PackageService) loadChallengeDetails(packagePath string, learningPath [
 
 // loadChallengeMetadata loads metadata from challenge directory
 func (s *PackageService) loadChallengeMetadata(challengePath string) *models.ChallengeMetadata {
-	metadataPath := filepath.Join(challengePath, "metadata.json")
-
-	// Check if metadata.json exists
-	if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
-		return nil
-	}
-
-	metadataBytes, err := os.ReadFile(metadataPath)
+	metadata, err := readChallengeMetadata(challengePath)
 	if err != nil {
 		return nil
 	}
-
-	var metadata models.ChallengeMetadata
-	if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
-		return nil
-	}
-
-	return &metadata
+	return metadata
 }
 
 // Helper functions for generating metadata when not available
@@ -354,13 +392,13 @@ func (s *PackageService) loadChallenge(challengePath, challengeName string) *mod
 	// Load hints
 	hints := s.readFileContent(filepath.Join(challengePath, "hints.md"))
 	if hints == "" {
-		hints = "No hints available for this challenge."
+		hints = noHints
 	}
 
 	// Load learning materials from learning.md (same as classic challenges)
 	learningMaterials := s.readFileContent(filepath.Join(challengePath, "learning.md"))
 	if learningMaterials == "" {
-		learningMaterials = "*No learning materials available for this challenge yet.*"
+		learningMaterials = noLearningMaterials
 	}
 
 	// Determine difficulty - try to load from metadata first, then infer from challenge name
@@ -380,16 +418,34 @@ func (s *PackageService) loadChallenge(challengePath, challengeName string) *mod
 		}
 	}
 
-	return &models.PackageChallenge{
+	challenge := &models.PackageChallenge{
 		ID:                challengeName,
 		Title:             title,
 		Description:       readmeContent, // Use README content for description
 		Difficulty:        difficulty,
 		Template:          template,
 		TestFile:          testFile,
+		SuiteVersion:      SuiteVersion(testFile),
 		Hints:             hints,
 		LearningMaterials: learningMaterials, // Use learning.md for learning materials tab
 	}
+
+	// Carry over the rest of metadata.json (objectives, requirements, ...)
+	if metadata != nil {
+		challenge.ShortDescription = metadata.ShortDescription
+		challenge.LearningObjectives = metadata.LearningObjectives
+		challenge.Requirements = metadata.Requirements
+		challenge.BonusPoints = metadata.BonusPoints
+		challenge.RealWorldConnection = metadata.RealWorldConnection
+		challenge.EstimatedTime = metadata.EstimatedTime
+		challenge.Tags = metadata.Tags
+		challenge.Prerequisites = metadata.Prerequisites
+		challenge.Icon = metadata.Icon
+		challenge.Order = metadata.Order
+	}
+	challenge.Translations = loadTranslations(challengePath, metadata)
+
+	return challenge
 }
 
 func (s *PackageService) readFileContent(filePath string) string {
@@ -502,6 +558,11 @@ func (s *PackageService) GetPackageChallenges(packageID string) (map[string]*mod
 }
 
 func (s *PackageService) GetPackageChallenge(packageID, challengeID string) (*models.PackageChallenge, error) {
+	// IDs come from URLs and request bodies; they must name a directory, not a path
+	if !isPlainName(packageID) || !isPlainName(challengeID) {
+		return nil, fmt.Errorf("challenge %s not found in package %s", challengeID, packageID)
+	}
+
 	// Load challenge directly from filesystem
 	packagePath := filepath.Join(s.packagesPath, packageID)
 	challengePath := filepath.Join(packagePath, challengeID)
@@ -521,3 +582,48 @@ func (s *PackageService) GetPackageChallenge(packageID, challengeID string) (*mo
 
 	return challenge, nil
 }
+
+// AsChallenge converts a package challenge into a Challenge, with the package's name,
+// version and the challenge's objectives attached, so it can be run and used with AI
+// features that were written for classic challenges
+func (s *PackageService) AsChallenge(packageID, challengeID string) (*models.Challenge, error) {
+	challenge, err := s.GetPackageChallenge(packageID, challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.ChallengePackage{
+		Name:                packageID,
+		ChallengeID:         challengeID,
+		LearningObjectives:  challenge.LearningObjectives,
+		Requirements:        challenge.Requirements,
+		RealWorldConnection: challenge.RealWorldConnection,
+	}
+	if pkg, err := s.GetPackage(packageID); err == nil {
+		info.DisplayName = pkg.DisplayName
+		info.Version = pkg.Version
+	}
+
+	return &models.Challenge{
+		ID:                 0, // Package challenges don't use numeric IDs
+		Title:              challenge.Title,
+		Description:        challenge.Description,
+		Difficulty:         challenge.Difficulty,
+		Template:           challenge.Template,
+		TestFile:           challenge.TestFile,
+		SuiteVersion:       challenge.SuiteVersion,
+		LearningMaterials:  challenge.LearningMaterials,
+		Hints:              challenge.Hints,
+		ShortDescription:   challenge.ShortDescription,
+		EstimatedTime:      challenge.EstimatedTime,
+		LearningObjectives: challenge.LearningObjectives,
+		Prerequisites:      challenge.Prerequisites,
+		Tags:               challenge.Tags,
+		Package:            info,
+	}, nil
+}
+
+// isPlainName reports whether name is a single path element
+func isPlainName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/utils"
+)
+
+// ProfileService builds user profiles from the existing challenge, scoreboard and package data
+type ProfileService struct {
+	challengeService   *ChallengeService
+	userService        *UserService
+	packageService     *PackageService
+	leaderboardService *LeaderboardService
+	repoRoot           string
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(
+	challengeService *ChallengeService,
+	userService *UserService,
+	packageService *PackageService,
+	leaderboardService *LeaderboardService,
+	repoRoot string,
+) *ProfileService {
+	return &ProfileService{
+		challengeService:   challengeService,
+		userService:        userService,
+		packageService:     packageService,
+		leaderboardService: leaderboardService,
+		repoRoot:           repoRoot,
+	}
+}
+
+// BuildProfile assembles the profile for username. It returns false when the
+// user has no submissions and no scoreboard entries anywhere.
+func (ps *ProfileService) BuildProfile(username string, sponsors map[string]bool) (*models.UserProfile, bool) {
+	if !ValidGitHubUsername(username) {
+		return nil, false
+	}
+
+	// One git call gives the date each of the user's solution files was first committed
+	solvedDates := utils.GetFileCommitDates(ps.repoRoot,
+		fmt.Sprintf(":(glob)challenge-*/submissions/%s/*", username),
+		fmt.Sprintf(":(glob)packages/*/*/submissions/%s/*", username),
+	)
+
+	profile := &models.UserProfile{
+		Username:  username,
+		IsSponsor: sponsors[username],
+		MainRank:  ps.leaderboardService.MainRank(username),
+		Classic:   ps.classicProgress(username, solvedDates),
+		Packages:  ps.packageProgress(username, sponsors, solvedDates),
+	}
+
+	known := profile.Classic.Attempted > 0
+	for _, pkg := range profile.Packages {
+		if pkg.Completed > 0 {
+			known = true
+		}
+	}
+	if !known {
+		return nil, false
+	}
+
+	profile.Achievement = AchievementFor(profile.Classic.Solved)
+	profile.Achievements = ps.achievements(profile)
+	return profile, true
+}
+
+// classicProgress collects per-challenge status, scores and solve dates
+func (ps *ProfileService) classicProgress(username string, solvedDates map[string]time.Time) models.ClassicProgress {
+	challenges := ps.challengeService.GetChallenges()
+	attempts := ps.userService.GetUserAttempts(username, challenges)
+	completed := ps.leaderboardService.ClassicCompletions()[username]
+
+	progress := models.ClassicProgress{Total: len(challenges)}
+	scoreSum, scored := 0, 0
+
+	for id, challenge := range challenges {
+		entry := models.ChallengeProgress{
+			ID:         id,
+			Title:      challenge.Title,
+			Difficulty: challenge.Difficulty,
+			Attempted:  attempts.AttemptedIDs[id] || completed[id],
+			Solved:     completed[id],
+		}
+		entry.Score = scoreboardScore(ReadClassicScoreboard(id), username)
+
+		if entry.Attempted {
+			progress.Attempted++
+		}
+		if entry.Score != nil {
+			scoreSum += *entry.Score
+			scored++
+		}
+		if entry.Solved {
+			progress.Solved++
+			rel := path.Join(fmt.Sprintf("challenge-%d", id), "submissions", username, "solution-template.go")
+			entry.SolvedAt = ps.solvedAt(rel, solvedDates)
+		}
+
+		progress.Challenges = append(progress.Challenges, entry)
+	}
+
+	sort.Slice(progress.Challenges, func(i, j int) bool {
+		return progress.Challenges[i].ID < progress.Challenges[j].ID
+	})
+
+	if progress.Total > 0 {
+		progress.CompletionRate = float64(progress.Solved) / float64(progress.Total) * 100
+	}
+	if scored > 0 {
+		progress.AverageScore = scoreSum / scored
+	}
+
+	return progress
+}
+
+// packageProgress collects completions and rank for every package learning path
+func (ps *ProfileService) packageProgress(username string, sponsors map[string]bool, solvedDates map[string]time.Time) []models.PackageProfileProgress {
+	var names []string
+	for name := range ps.packageService.GetPackages() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []models.PackageProfileProgress
+	for _, name := range names {
+		pkg, challenges, err := ps.leaderboardService.PackageChallenges(name)
+		if err != nil {
+			continue
+		}
+
+		stats := ps.leaderboardService.PackageStats(name, challenges)[username]
+		leaderboard := ps.leaderboardService.PackageLeaderboard(name, challenges, sponsors)
+
+		progress := models.PackageProfileProgress{
+			Name:        name,
+			DisplayName: pkg.DisplayName,
+			Rank:        PackageRank(leaderboard, username),
+			Total:       len(challenges),
+		}
+
+		for _, challenge := range challenges {
+			entry := models.PackageChallengeProgress{
+				ID:    challenge.ID,
+				Title: challenge.Title,
+			}
+			if info, ok := pkg.ChallengeDetails[challenge.ID]; ok && info.Title != "" {
+				entry.Title = info.Title
+			}
+			if stats != nil && stats.ChallengesCompleted[challenge.ID] {
+				entry.Completed = true
+				progress.Completed++
+				entry.SolvedAt = ps.packageSolvedAt(name, challenge.ID, username, solvedDates)
+			}
+			entry.Score = scoreboardScore(ReadPackageScoreboard(name, challenge.ID), username)
+			progress.Challenges = append(progress.Challenges, entry)
+		}
+
+		result = append(result, progress)
+	}
+
+	return result
+}
+
+// scoreboardScore returns the percentage of tests passed in username's scoreboard row.
+// Scores come only from that row; without one there is no score.
+func scoreboardScore(rows []ScoreboardRow, username string) *int {
+	for _, row := range rows {
+		if row.Username == username && row.Total > 0 {
+			score := (row.Passed * 100) / row.Total
+			return &score
+		}
+	}
+	return nil
+}
+
+// solvedAt prefers the git commit date and falls back to the file modification time
+func (ps *ProfileService) solvedAt(rel string, solvedDates map[string]time.Time) *time.Time {
+	return submissionDate(ps.repoRoot, rel, solvedDates)
+}
+
+// packageSolvedAt resolves the solve date for either package solution file name
+func (ps *ProfileService) packageSolvedAt(packageName, challengeID, username string, solvedDates map[string]time.Time) *time.Time {
+	return packageSubmissionDate(ps.repoRoot, packageName, challengeID, username, solvedDates)
+}
+
+// submissionDate returns the git commit date of rel, falling back to its modification time
+func submissionDate(repoRoot, rel string, commitDates map[string]time.Time) *time.Time {
+	if t, ok := commitDates[rel]; ok {
+		return &t
+	}
+	if stat, err := os.Stat(filepath.Join(repoRoot, filepath.FromSlash(rel))); err == nil {
+		t := stat.ModTime()
+		return &t
+	}
+	return nil
+}
+
+// packageSubmissionDate resolves the submission date for either package solution file name
+func packageSubmissionDate(repoRoot, packageName, challengeID, username string, commitDates map[string]time.Time) *time.Time {
+	for _, name := range []string{"solution.go", "solution-template.go"} {
+		rel := path.Join("packages", packageName, challengeID, "submissions", username, name)
+		if t := submissionDate(repoRoot, rel, commitDates); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// achievements lists milestone badges earned across classic and package challenges
+func (ps *ProfileService) achievements(profile *models.UserProfile) []string {
+	var achievements []string
+
+	if profile.Classic.Solved > 0 {
+		achievements = append(achievements, profile.Achievement)
+	}
+	if profile.Classic.Total > 0 && profile.Classic.Solved == profile.Classic.Total {
+		achievements = append(achievements, "🏆 All classic challenges solved")
+	}
+	if profile.MainRank > 0 && profile.MainRank <= 10 {
+		achievements = append(achievements, "🥇 Top 10 on the main scoreboard")
+	}
+	for _, pkg := range profile.Packages {
+		if pkg.Total > 0 && pkg.Completed == pkg.Total {
+			achievements = append(achievements, fmt.Sprintf("🎓 %s path completed", pkg.DisplayName))
+		}
+	}
+
+	return achievements
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackageScores(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "packages", "gin", "challenge-1-basic-routing")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "web-ui"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	scoreboard := "| Username | Passed Tests | Total Tests |\n|---|---|---|\n| alice | 4 | 8 |\n| bob | 0 | 8 |\n| carol | 0 | 0 |\n"
+	if err := os.WriteFile(filepath.Join(dir, "SCOREBOARD.md"), []byte(scoreboard), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The scoreboards are read relative to web-ui, like the server does
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(root, "web-ui")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	rows := ReadPackageScoreboard("gin", "challenge-1-basic-routing")
+	tests := []struct {
+		username string
+		want     *int
+	}{
+		{"alice", intPointer(50)},
+		{"bob", intPointer(0)},
+		{"carol", nil}, // no tests recorded
+		{"dave", nil},  // no row
+	}
+	for _, tt := range tests {
+		got := scoreboardScore(rows, tt.username)
+		switch {
+		case tt.want == nil && got != nil:
+			t.Errorf("%s: score = %d, want none", tt.username, *got)
+		case tt.want != nil && (got == nil || *got != *tt.want):
+			t.Errorf("%s: score = %v, want %d", tt.username, got, *tt.want)
+		}
+	}
+
+	if rows := ReadPackageScoreboard("gin", "challenge-2-middleware"); scoreboardScore(rows, "alice") != nil {
+		t.Error("a challenge without a scoreboard has a score")
+	}
+}
+
+func intPointer(n int) *int {
+	return &n
+}
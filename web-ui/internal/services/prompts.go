@@ -0,0 +1,359 @@
+package services
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"web-ui/internal/models"
+)
+
+//go:embed prompts/*.tmpl
+var defaultPrompts embed.FS
+
+// Prompt template names
+const (
+	PromptReview              = "review"
+	PromptQuestions           = "questions"
+	PromptHint                = "hint"
+	PromptInterviewTurn       = "interview_turn"
+	PromptInterviewEvaluation = "interview_evaluation"
+	PromptReviewRepair        = "review_repair"
+	PromptEdgeCases           = "edge_cases"
+	PromptAuthorChallenge     = "author_challenge"
+)
+
+// PromptNames lists every prompt the AI service renders
+var PromptNames = []string{PromptReview, PromptQuestions, PromptHint, PromptInterviewTurn, PromptInterviewEvaluation, PromptReviewRepair, PromptEdgeCases, PromptAuthorChallenge}
+
+// versionPattern matches the {{/* version: N */}} header of a prompt template
+var versionPattern = regexp.MustCompile(`\{\{-?\s*/\*\s*version:\s*([^\s*]+)\s*\*/\s*-?\}\}`)
+
+// PromptData is what prompt templates can refer to. Fields a prompt does not use are empty.
+type PromptData struct {
+	Challenge     *models.Challenge
+	Header        string // challenge title, plus package details for package challenges
+	Description   string // the README, truncated
+	Code          string
+	NumberedCode  string // Code with 1-based line numbers
+	SolutionFile  string
+	TestResults   string   // summary of the test, vet and race run (review)
+	Context       string   // free-text context sent by the client (review) or the author's notes (author_challenge)
+	UserProgress  string   // (questions)
+	HintLevel     int      // 1-4 (hint)
+	HintType      string   // what kind of hint the level asks for (hint)
+	Transcript    string   // the interview so far (interview prompts)
+	FirstTurn     bool     // the interviewer has not spoken yet (interview_turn)
+	Response      string   // the response that failed validation (review_repair)
+	Problems      []string // what was wrong with it (review_repair)
+	API           string   // the template's exported declarations without bodies (edge_cases)
+	ExistingTests string   // the challenge's own tests, truncated (edge_cases)
+	Example       string   // files of an existing challenge to follow (author_challenge)
+}
+
+// PromptInfo describes a loaded prompt template or override for the admin console
+type PromptInfo struct {
+	Name    string `json:"name"`
+	Scope   string `json:"scope"`   // "" for the base prompt, else e.g. "challenge-15" or "packages/gin"
+	Version string `json:"version"` // from the version header, or a content hash
+	Source  string `json:"source"`  // "embedded" or the file it was read from
+}
+
+// promptFile is the source of one template with its version
+type promptFile struct {
+	info PromptInfo
+	text string
+}
+
+// PromptStore renders the AI prompts from text/template files. Defaults are embedded in
+// the binary; a file with the same name in the prompts directory replaces one. Files in
+// challenge-N/, packages/<pkg>/ and packages/<pkg>/<challenge>/ subdirectories are parsed
+// on top of the base prompt, so they can redefine its blocks (such as "rubric") for that
+// challenge or package only.
+type PromptStore struct {
+	mu       sync.RWMutex
+	dir      string
+	base     map[string]*promptFile
+	overlays map[string]map[string]*promptFile // scope -> name -> override
+	composed map[string]*template.Template     // cache of parsed base+override chains
+}
+
+// NewPromptStore creates a prompt store reading overrides from AI_PROMPTS_DIR, or from the
+// prompts directory of the repository, and loads it
+func NewPromptStore() *PromptStore {
+	dir := strings.TrimSpace(os.Getenv("AI_PROMPTS_DIR"))
+	if dir == "" {
+		if root, err := resolveRepositoryRoot(); err == nil {
+			dir = filepath.Join(root, "prompts")
+		}
+	}
+
+	store := &PromptStore{dir: dir}
+	if err := store.Load(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return store
+}
+
+// Dir returns the override directory
+func (ps *PromptStore) Dir() string {
+	return ps.dir
+}
+
+// Load (re)reads the embedded prompts and the override directory. Overrides that do not
+// parse are skipped and reported in the returned error; the rest stay in effect.
+func (ps *PromptStore) Load() error {
+	base := make(map[string]*promptFile)
+	for _, name := range PromptNames {
+		data, err := defaultPrompts.ReadFile("prompts/" + name + ".tmpl")
+		if err != nil {
+			return fmt.Errorf("embedded prompt %s missing: %v", name, err)
+		}
+		base[name] = newPromptFile(name, "", "embedded", string(data))
+	}
+
+	overlays := make(map[string]map[string]*promptFile)
+	var problems []string
+	if ps.dir != "" {
+		err := filepath.WalkDir(ps.dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(file, ".tmpl") {
+				return nil
+			}
+			rel, err := filepath.Rel(ps.dir, file)
+			if err != nil {
+				return nil
+			}
+			scope, name := path.Split(filepath.ToSlash(rel))
+			scope = strings.TrimSuffix(scope, "/")
+			name = strings.TrimSuffix(name, ".tmpl")
+			if _, known := base[name]; !known {
+				problems = append(problems, fmt.Sprintf("%s: unknown prompt %q", rel, name))
+				return nil
+			}
+			if scope != "" && !validPromptScope(scope) {
+				problems = append(problems, fmt.Sprintf("%s: directory must be challenge-N, packages/<pkg> or packages/<pkg>/<challenge>", rel))
+				return nil
+			}
+
+			data, err := os.ReadFile(file)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", rel, err))
+				return nil
+			}
+			prompt := newPromptFile(name, scope, rel, string(data))
+			if scope == "" {
+				base[name] = prompt
+				return nil
+			}
+			if overlays[scope] == nil {
+				overlays[scope] = make(map[string]*promptFile)
+			}
+			overlays[scope][name] = prompt
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	// A replaced base prompt that does not parse falls back to the embedded one
+	for name, prompt := range base {
+		if err := checkPromptChain([]*promptFile{prompt}); err != nil {
+			problems = append(problems, err.Error())
+			data, _ := defaultPrompts.ReadFile("prompts/" + name + ".tmpl")
+			base[name] = newPromptFile(name, "", "embedded", string(data))
+		}
+	}
+	for scope, prompts := range overlays {
+		for name, prompt := range prompts {
+			if err := checkPromptChain(append(overlayChain(base, overlays, name, parentScope(scope)), prompt)); err != nil {
+				problems = append(problems, err.Error())
+				delete(prompts, name)
+			}
+		}
+	}
+
+	ps.mu.Lock()
+	ps.base = base
+	ps.overlays = overlays
+	ps.composed = make(map[string]*template.Template)
+	ps.mu.Unlock()
+
+	if len(problems) > 0 {
+		return fmt.Errorf("prompt templates: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Render executes the named prompt for a challenge and returns the prompt text with the
+// version of every template that went into it, e.g. "review@2+challenge-15@1"
+func (ps *PromptStore) Render(name string, data PromptData) (string, string, error) {
+	ps.mu.RLock()
+	chain := overlayChain(ps.base, ps.overlays, name, challengeScope(data.Challenge))
+	ps.mu.RUnlock()
+	if len(chain) == 0 {
+		return "", "", fmt.Errorf("unknown prompt %q", name)
+	}
+
+	versions := make([]string, len(chain))
+	for i, prompt := range chain {
+		versions[i] = prompt.info.label()
+	}
+	key := strings.Join(versions, "+")
+
+	ps.mu.RLock()
+	tmpl := ps.composed[key]
+	ps.mu.RUnlock()
+	if tmpl == nil {
+		var err error
+		if tmpl, err = parsePromptChain(chain); err != nil {
+			return "", "", err
+		}
+		ps.mu.Lock()
+		ps.composed[key] = tmpl
+		ps.mu.Unlock()
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", "", fmt.Errorf("rendering prompt %s: %v", key, err)
+	}
+	return strings.TrimSpace(b.String()), key, nil
+}
+
+// Prompts lists the base prompts and every override, base prompts first
+func (ps *PromptStore) Prompts() []PromptInfo {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	result := []PromptInfo{}
+	for _, name := range PromptNames {
+		result = append(result, ps.base[name].info)
+	}
+	var overrides []PromptInfo
+	for _, prompts := range ps.overlays {
+		for _, prompt := range prompts {
+			overrides = append(overrides, prompt.info)
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Scope != overrides[j].Scope {
+			return overrides[i].Scope < overrides[j].Scope
+		}
+		return overrides[i].Name < overrides[j].Name
+	})
+	return append(result, overrides...)
+}
+
+func newPromptFile(name, scope, source, text string) *promptFile {
+	version := ""
+	if m := versionPattern.FindStringSubmatch(text); m != nil {
+		version = m[1]
+	} else {
+		sum := sha256.Sum256([]byte(text))
+		version = "sha-" + hex.EncodeToString(sum[:4])
+	}
+	return &promptFile{
+		info: PromptInfo{Name: name, Scope: scope, Version: version, Source: source},
+		text: text,
+	}
+}
+
+// label identifies a template in recorded prompt versions
+func (info PromptInfo) label() string {
+	if info.Scope == "" {
+		return info.Name + "@" + info.Version
+	}
+	return info.Scope + "@" + info.Version
+}
+
+// parsePromptChain parses the base prompt and then each override on top of it
+func parsePromptChain(chain []*promptFile) (*template.Template, error) {
+	tmpl := template.New(chain[0].info.Name).Option("missingkey=error")
+	for _, prompt := range chain {
+		var err error
+		if tmpl, err = tmpl.Parse(prompt.text); err != nil {
+			return nil, fmt.Errorf("%s: %v", prompt.info.Source, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// checkPromptChain parses a chain and executes it with empty data, which catches
+// references to fields PromptData does not have
+func checkPromptChain(chain []*promptFile) error {
+	tmpl, err := parsePromptChain(chain)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(io.Discard, PromptData{Challenge: &models.Challenge{}}); err != nil {
+		return fmt.Errorf("%s: %v", chain[len(chain)-1].info.Source, err)
+	}
+	return nil
+}
+
+// overlayChain returns the base prompt followed by the overrides that apply to scope,
+// least specific first
+func overlayChain(base map[string]*promptFile, overlays map[string]map[string]*promptFile, name, scope string) []*promptFile {
+	prompt, ok := base[name]
+	if !ok {
+		return nil
+	}
+	chain := []*promptFile{prompt}
+
+	var scopes []string
+	for s := scope; s != ""; s = parentScope(s) {
+		scopes = append([]string{s}, scopes...)
+	}
+	for _, s := range scopes {
+		if override, ok := overlays[s][name]; ok {
+			chain = append(chain, override)
+		}
+	}
+	return chain
+}
+
+// challengeScope is the override directory for a challenge: challenge-N for classic
+// challenges, packages/<pkg>/<challenge> for package challenges
+func challengeScope(challenge *models.Challenge) string {
+	switch {
+	case challenge == nil:
+		return ""
+	case challenge.Package != nil:
+		return "packages/" + challenge.Package.Name + "/" + challenge.Package.ChallengeID
+	case challenge.ID > 0:
+		return fmt.Sprintf("challenge-%d", challenge.ID)
+	}
+	return ""
+}
+
+// parentScope returns the next less specific scope: packages/gin for a gin challenge
+func parentScope(scope string) string {
+	parts := strings.Split(scope, "/")
+	if parts[0] == "packages" && len(parts) == 3 {
+		return strings.Join(parts[:2], "/")
+	}
+	return ""
+}
+
+var challengeScopePattern = regexp.MustCompile(`^challenge-\d+$`)
+
+func validPromptScope(scope string) bool {
+	parts := strings.Split(scope, "/")
+	if len(parts) == 1 {
+		return challengeScopePattern.MatchString(parts[0])
+	}
+	return parts[0] == "packages" && len(parts) <= 3 && isPlainName(parts[1]) && (len(parts) == 2 || isPlainName(parts[2]))
+}
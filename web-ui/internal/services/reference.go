@@ -0,0 +1,278 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// maxReferenceCandidates limits how many submissions are tried when a challenge has no
+// reference solution of its own
+const maxReferenceCandidates = 5
+
+// ReferenceSolution is a solution known to pass a challenge's tests
+type ReferenceSolution struct {
+	Code   string `json:"code"`
+	Source string `json:"source"` // e.g. "reference/solution-template.go" or "submissions/alice"
+}
+
+// ReferenceComparison is a user's code next to the challenge's reference solution
+type ReferenceComparison struct {
+	Source         string      `json:"source"`
+	Code           string      `json:"code"`
+	Diff           *CodeDiff   `json:"diff"` // the user's code on the left, the reference on the right
+	Benchmarks     []Benchmark `json:"benchmarks"`
+	BenchmarkError string      `json:"benchmarkError,omitempty"`
+}
+
+// ReferenceService finds a solution that passes a challenge's tests: the one in the
+// challenge's reference directory, or else the first submission that passes when run.
+// Results, including finding none, are cached per challenge and version of the test file.
+//
+// The solution in the reference directory is also shown to users, but only once they
+// have a passing submission of the challenge (see Unlocked).
+type ReferenceService struct {
+	root     string
+	executor *ExecutionService
+
+	mu         sync.Mutex
+	cache      map[string]*ReferenceSolution
+	misses     map[string]error            // searches that found no passing submission
+	lookups    map[string]*referenceLookup // searches in progress
+	benchmarks map[string][]Benchmark      // reference benchmark results by reference and test file
+	passes     map[string]map[string]bool  // challenge scope -> lowercased usernames with a passing submission
+}
+
+// NewReferenceService creates a reference service for the repository's challenges
+func NewReferenceService(executor *ExecutionService) *ReferenceService {
+	root, err := resolveRepositoryRoot()
+	if err != nil {
+		root = ".."
+	}
+	return &ReferenceService{
+		root:       root,
+		executor:   executor,
+		cache:      make(map[string]*ReferenceSolution),
+		misses:     make(map[string]error),
+		lookups:    make(map[string]*referenceLookup),
+		benchmarks: make(map[string][]Benchmark),
+		passes:     make(map[string]map[string]bool),
+	}
+}
+
+// ChallengeDir returns the directory of a classic or package challenge
+func (rs *ReferenceService) ChallengeDir(challenge *models.Challenge) string {
+	if challenge.Package != nil {
+		return filepath.Join(rs.root, "packages", challenge.Package.Name, challenge.Package.ChallengeID)
+	}
+	return filepath.Join(rs.root, fmt.Sprintf("challenge-%d", challenge.ID))
+}
+
+// solutionFileName is the name solutions of the challenge are saved under
+func solutionFileName(challenge *models.Challenge) string {
+	if challenge.Package != nil {
+		return "solution.go"
+	}
+	return SolutionFile
+}
+
+// referenceLookup is a search for a reference solution that other callers asking for the
+// same challenge and test file wait on instead of repeating
+type referenceLookup struct {
+	done      chan struct{}
+	reference *ReferenceSolution
+	err       error
+}
+
+// Get returns a reference solution for the challenge. The lock only guards the caches:
+// running candidate submissions can take minutes, so it happens outside it, once per
+// challenge and test file however many callers ask at the same time. When no candidate
+// passes, that is cached too until the test file changes.
+func (rs *ReferenceService) Get(challenge *models.Challenge) (*ReferenceSolution, error) {
+	sum := sha256.Sum256([]byte(challenge.TestFile))
+	key := challengeScope(challenge) + "@" + hex.EncodeToString(sum[:8])
+
+	rs.mu.Lock()
+	if reference, ok := rs.cache[key]; ok {
+		rs.mu.Unlock()
+		return reference, nil
+	}
+	rs.mu.Unlock()
+
+	// A reference file can be added at any time and is cheap to check, so it is looked
+	// for even when a search found nothing before
+	dir := rs.ChallengeDir(challenge)
+	name := solutionFileName(challenge)
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "reference", name)); err == nil {
+		reference := &ReferenceSolution{Code: string(data), Source: filepath.ToSlash(filepath.Join("reference", name))}
+		rs.mu.Lock()
+		rs.cache[key] = reference
+		delete(rs.misses, key)
+		rs.mu.Unlock()
+		return reference, nil
+	}
+
+	rs.mu.Lock()
+	if err, ok := rs.misses[key]; ok {
+		rs.mu.Unlock()
+		return nil, err
+	}
+	if lookup, ok := rs.lookups[key]; ok {
+		rs.mu.Unlock()
+		<-lookup.done
+		return lookup.reference, lookup.err
+	}
+	lookup := &referenceLookup{done: make(chan struct{})}
+	rs.lookups[key] = lookup
+	rs.mu.Unlock()
+
+	lookup.reference, lookup.err = rs.search(challenge, dir, name)
+
+	rs.mu.Lock()
+	if lookup.err == nil {
+		rs.cache[key] = lookup.reference
+	} else {
+		rs.misses[key] = lookup.err
+	}
+	delete(rs.lookups, key)
+	rs.mu.Unlock()
+	close(lookup.done)
+
+	return lookup.reference, lookup.err
+}
+
+// search runs the first few submissions of the challenge and returns one that passes
+func (rs *ReferenceService) search(challenge *models.Challenge, dir, name string) (*ReferenceSolution, error) {
+	if rs.executor != nil {
+		entries, _ := ioutil.ReadDir(filepath.Join(dir, "submissions"))
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		tried := 0
+		for _, entry := range entries {
+			if !entry.IsDir() || tried >= maxReferenceCandidates {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(dir, "submissions", entry.Name(), name))
+			if err != nil {
+				continue
+			}
+			tried++
+			if rs.executor.AnalyzeCode(string(data), challenge).Passed {
+				return &ReferenceSolution{Code: string(data), Source: "submissions/" + entry.Name()}, nil
+			}
+		}
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("challenge directory not found: %v", err)
+	}
+	return nil, fmt.Errorf("no reference solution for %s: add reference/%s or a submission that passes the tests", challenge.Title, name)
+}
+
+// Shipped returns the solution in the challenge's reference directory. Unlike Get it never
+// falls back to a submission, since the result is shown to users.
+func (rs *ReferenceService) Shipped(challenge *models.Challenge) (*ReferenceSolution, bool) {
+	name := solutionFileName(challenge)
+	data, err := ioutil.ReadFile(filepath.Join(rs.ChallengeDir(challenge), "reference", name))
+	if err != nil {
+		return nil, false
+	}
+	return &ReferenceSolution{Code: string(data), Source: filepath.ToSlash(filepath.Join("reference", name))}, true
+}
+
+// RecordPass notes that a solution the server ran passed all tests. session is the
+// server-issued ID of the browser session that submitted it.
+func (rs *ReferenceService) RecordPass(session string, challenge *models.Challenge) {
+	if session == "" {
+		return
+	}
+	scope := challengeScope(challenge)
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.passes[scope] == nil {
+		rs.passes[scope] = make(map[string]bool)
+	}
+	rs.passes[scope][session] = true
+}
+
+// Unlocked reports whether the challenge's reference solution may be shown. Neither
+// argument comes from the client: session is the server-issued browser session, unlocked
+// by a passing submission since the server started, and owner the GitHub user the
+// checkout belongs to, unlocked by a row with all tests passed on the scoreboard.
+func (rs *ReferenceService) Unlocked(session, owner string, challenge *models.Challenge) bool {
+	if session != "" {
+		rs.mu.Lock()
+		passed := rs.passes[challengeScope(challenge)][session]
+		rs.mu.Unlock()
+		if passed {
+			return true
+		}
+	}
+	if owner == "" {
+		return false
+	}
+
+	dir := rs.ChallengeDir(challenge)
+	content, err := ioutil.ReadFile(filepath.Join(dir, "SCOREBOARD.md"))
+	if err != nil {
+		return false
+	}
+	rows := parseScoreboardRows(string(content))
+	markStaleRows(rows, testFileIn(dir))
+	for _, row := range rows {
+		if strings.EqualFold(row.Username, owner) && row.Solved() {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare diffs code against the shipped reference solution and benchmarks the reference.
+// Callers check Unlocked first.
+func (rs *ReferenceService) Compare(code string, challenge *models.Challenge) (*ReferenceComparison, error) {
+	reference, ok := rs.Shipped(challenge)
+	if !ok {
+		return nil, fmt.Errorf("%s has no reference solution", challenge.Title)
+	}
+
+	comparison := &ReferenceComparison{
+		Source:     reference.Source,
+		Code:       reference.Code,
+		Diff:       DiffLines(code, reference.Code),
+		Benchmarks: []Benchmark{},
+	}
+
+	testSum := sha256.Sum256([]byte(challenge.TestFile))
+	codeSum := sha256.Sum256([]byte(reference.Code))
+	key := challengeScope(challenge) + "@" + hex.EncodeToString(testSum[:8]) + hex.EncodeToString(codeSum[:8])
+
+	rs.mu.Lock()
+	benchmarks, cached := rs.benchmarks[key]
+	rs.mu.Unlock()
+	if cached {
+		comparison.Benchmarks = benchmarks
+		return comparison, nil
+	}
+	if rs.executor == nil {
+		return comparison, nil
+	}
+
+	benchmarks, err := rs.executor.RunBenchmarks(reference.Code, challenge)
+	if err != nil {
+		comparison.BenchmarkError = err.Error()
+		return comparison, nil
+	}
+	rs.mu.Lock()
+	rs.benchmarks[key] = benchmarks
+	rs.mu.Unlock()
+	comparison.Benchmarks = benchmarks
+	return comparison, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"web-ui/internal/models"
+)
+
+func newTestReferenceService(t *testing.T) (*ReferenceService, *models.Challenge) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "challenge-1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rs := NewReferenceService(nil)
+	rs.root = root
+	return rs, &models.Challenge{ID: 1, Title: "Sum", TestFile: "package main\n"}
+}
+
+func TestReferenceGetCachesMisses(t *testing.T) {
+	rs, challenge := newTestReferenceService(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := rs.Get(challenge); err == nil {
+				t.Error("Get found a reference in an empty challenge")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(rs.misses) != 1 || len(rs.lookups) != 0 {
+		t.Fatalf("misses = %d, lookups in progress = %d; want 1 and 0", len(rs.misses), len(rs.lookups))
+	}
+
+	// A reference added later is still found
+	dir := filepath.Join(rs.root, "challenge-1", "reference")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SolutionFile), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reference, err := rs.Get(challenge)
+	if err != nil || reference.Source != "reference/"+SolutionFile {
+		t.Fatalf("Get = %+v, %v", reference, err)
+	}
+	if len(rs.misses) != 0 {
+		t.Fatal("miss was not cleared")
+	}
+
+	// A new version of the tests is searched again
+	changed := *challenge
+	changed.TestFile += "// more tests\n"
+	if _, err := rs.Get(&changed); err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.cache) != 2 {
+		t.Fatalf("cache has %d entries, want one per test file version", len(rs.cache))
+	}
+}
+
+func TestReferenceUnlocked(t *testing.T) {
+	rs, challenge := newTestReferenceService(t)
+	scoreboard := "| Username | Passed Tests | Total Tests |\n|---|---|---|\n| alice | 1 | 1 |\n| bob | 0 | 1 |\n"
+	if err := os.WriteFile(filepath.Join(rs.root, "challenge-1", "SCOREBOARD.md"), []byte(scoreboard), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rs.RecordPass("session-a", challenge)
+
+	tests := []struct {
+		name    string
+		session string
+		owner   string
+		want    bool
+	}{
+		{"passing session", "session-a", "", true},
+		{"other session", "session-b", "", false},
+		{"no identity", "", "", false},
+		{"owner with a passing row", "", "alice", true},
+		{"owner other case", "", "ALICE", true},
+		{"owner with a failing row", "", "bob", false},
+		{"owner without a row", "session-b", "carol", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rs.Unlocked(tt.session, tt.owner, challenge); got != tt.want {
+				t.Fatalf("Unlocked(%q, %q) = %v, want %v", tt.session, tt.owner, got, tt.want)
+			}
+		})
+	}
+
+	other := &models.Challenge{ID: 2, TestFile: challenge.TestFile}
+	if rs.Unlocked("session-a", "", other) {
+		t.Fatal("a pass unlocked another challenge")
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSchema is the subset of JSON Schema the providers' structured output modes share
+type JSONSchema struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+
+	order []string // property names in struct order
+}
+
+// SchemaFor generates the schema of a Go value from its json tags. A schema tag adds
+// constraints: `schema:"enum=low|high"`, `schema:"min=0,max=100"`, `schema:"optional"`
+// (not required) or `schema:"-"` (set by the server, not part of the model's output).
+func SchemaFor(v interface{}) *JSONSchema {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+func schemaForType(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		closed := false
+		schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, AdditionalProperties: &closed}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			tag := field.Tag.Get("schema")
+			if !field.IsExported() || name == "-" || tag == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			property := schemaForType(field.Type)
+			optional := false
+			for _, option := range strings.Split(tag, ",") {
+				key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+				switch key {
+				case "optional":
+					optional = true
+				case "enum":
+					property.Enum = strings.Split(value, "|")
+				case "min":
+					if n, err := strconv.ParseFloat(value, 64); err == nil {
+						property.Minimum = &n
+					}
+				case "max":
+					if n, err := strconv.ParseFloat(value, 64); err == nil {
+						property.Maximum = &n
+					}
+				}
+			}
+
+			schema.Properties[name] = property
+			schema.order = append(schema.order, name)
+			if !optional {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		// Keys are free-form; the values are not checked
+		return &JSONSchema{Type: "object"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	}
+	return &JSONSchema{Type: "string"}
+}
+
+// Strict returns a copy in which every property is required, as OpenAI's strict
+// json_schema mode demands. Optional fields then come back as empty values.
+func (s *JSONSchema) Strict() *JSONSchema {
+	copied := *s
+	if s.Items != nil {
+		copied.Items = s.Items.Strict()
+	}
+	if s.Properties != nil {
+		copied.Properties = make(map[string]*JSONSchema, len(s.Properties))
+		for name, property := range s.Properties {
+			copied.Properties[name] = property.Strict()
+		}
+		copied.Required = append([]string(nil), s.propertyNames()...)
+	}
+	return &copied
+}
+
+// Gemini converts the schema to Gemini's OpenAPI-style responseSchema: upper-case type
+// names, no additionalProperties, and an explicit property order so the model writes
+// fields in the order the struct declares them.
+func (s *JSONSchema) Gemini() map[string]interface{} {
+	out := map[string]interface{}{"type": strings.ToUpper(s.Type)}
+	if len(s.Enum) > 0 {
+		out["enum"] = s.Enum
+	}
+	if s.Minimum != nil {
+		out["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		out["maximum"] = *s.Maximum
+	}
+	if s.Items != nil {
+		out["items"] = s.Items.Gemini()
+	}
+	if s.Properties != nil {
+		properties := make(map[string]interface{}, len(s.Properties))
+		for name, property := range s.Properties {
+			properties[name] = property.Gemini()
+		}
+		out["properties"] = properties
+		out["propertyOrdering"] = s.propertyNames()
+		if len(s.Required) > 0 {
+			out["required"] = s.Required
+		}
+	}
+	return out
+}
+
+func (s *JSONSchema) propertyNames() []string {
+	if len(s.order) == len(s.Properties) {
+		return s.order
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Filter drops the object fields the schema does not define from a decoded JSON value,
+// e.g. the fields of a Go value that the server fills in
+func (s *JSONSchema) Filter(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s.Properties == nil {
+			return v
+		}
+		filtered := make(map[string]interface{}, len(v))
+		for name, field := range v {
+			if property, ok := s.Properties[name]; ok {
+				filtered[name] = property.Filter(field)
+			}
+		}
+		return filtered
+	case []interface{}:
+		if s.Items == nil {
+			return v
+		}
+		for i := range v {
+			v[i] = s.Items.Filter(v[i])
+		}
+	}
+	return value
+}
+
+// Validate checks a decoded JSON value (as produced by json.Unmarshal into an
+// interface{}) against the schema and describes every violation, e.g.
+// `issues[0].severity: "urgent" is not one of low, medium, high, critical`
+func (s *JSONSchema) Validate(value interface{}) []string {
+	var problems []string
+	s.validate(value, "", &problems)
+	return problems
+}
+
+func (s *JSONSchema) validate(value interface{}, path string, problems *[]string) {
+	report := func(format string, args ...interface{}) {
+		where := path
+		if where == "" {
+			where = "response"
+		}
+		*problems = append(*problems, where+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			report("expected an object, got %s", jsonKind(value))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				report("missing required field %q", name)
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					report("unexpected field %q", name)
+				}
+				continue
+			}
+			property.validate(object[name], joinPath(path, name), problems)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			report("expected an array, got %s", jsonKind(value))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			report("expected a string, got %s", jsonKind(value))
+			return
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, text) {
+			report("%q is not one of %s", text, strings.Join(s.Enum, ", "))
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			report("expected a number, got %s", jsonKind(value))
+			return
+		}
+		if s.Type == "integer" && n != float64(int64(n)) {
+			report("expected an integer, got %v", n)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			report("%v is below the minimum of %v", n, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			report("%v is above the maximum of %v", n, *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("expected a boolean, got %s", jsonKind(value))
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonKind names the JSON type of a decoded value for validation messages
+func jsonKind(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64, json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
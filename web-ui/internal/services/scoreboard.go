@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"web-ui/internal/models"
@@ -13,6 +14,7 @@ import (
 // ScoreboardService handles scoreboard-related operations
 type ScoreboardService struct {
 	scoreboards models.ScoreboardMap
+	mutex       sync.RWMutex
 }
 
 // NewScoreboardService creates a new scoreboard service
@@ -22,26 +24,51 @@ func NewScoreboardService() *ScoreboardService {
 	}
 }
 
-// LoadScoreboards loads all scoreboards from the filesystem
+// LoadScoreboards loads all scoreboards from the filesystem, replacing any previously loaded set
 func (ss *ScoreboardService) LoadScoreboards(challenges models.ChallengeMap) error {
-	for id := range challenges {
+	scoreboards := make(models.ScoreboardMap)
+	for id, challenge := range challenges {
 		challengeDir := filepath.Join("..", "challenge-"+strconv.Itoa(id))
-		ss.loadScoreboardForChallenge(id, challengeDir)
+		if entries, ok := ss.loadScoreboardForChallenge(id, challengeDir, challenge.TestFile); ok {
+			scoreboards[id] = entries
+		}
 	}
+
+	ss.mutex.Lock()
+	ss.scoreboards = scoreboards
+	ss.mutex.Unlock()
 	return nil
 }
 
-// loadScoreboardForChallenge loads the scoreboard for a specific challenge
-func (ss *ScoreboardService) loadScoreboardForChallenge(id int, dir string) {
+// loadScoreboardForChallenge loads the scoreboard for a specific challenge, flagging entries
+// not graded against testFile
+func (ss *ScoreboardService) loadScoreboardForChallenge(id int, dir, testFile string) ([]models.ScoreboardEntry, bool) {
 	scoreboardPath := filepath.Join(dir, "SCOREBOARD.md")
 	scoreboardContent, err := ioutil.ReadFile(scoreboardPath)
 	if err != nil {
-		return
+		return nil, false
 	}
 
 	// Parse scoreboard markdown table
 	entries := ss.parseScoreboardMarkdown(string(scoreboardContent), id)
-	ss.scoreboards[id] = entries
+
+	rows := parseScoreboardRows(string(scoreboardContent))
+	markStaleRows(rows, testFile)
+	results := make(map[string]ScoreboardRow, len(rows))
+	for _, row := range rows {
+		results[row.Username] = row
+	}
+
+	current := entries[:0]
+	for _, entry := range entries {
+		row := results[entry.Username]
+		entry.SuiteVersion, entry.Stale = row.Suite, row.Stale
+		if entry.Stale && ExcludeStaleResults() {
+			continue // Hidden until the challenge is re-graded
+		}
+		current = append(current, entry)
+	}
+	return current, true
 }
 
 // parseScoreboardMarkdown parses the scoreboard markdown table
@@ -87,7 +114,7 @@ func (ss *ScoreboardService) parseScoreboardMarkdown(content string, challengeID
 		var username string
 
 		if format == 1 {
-			// Format is: | Username | Passed Tests | Total Tests |
+			// Format is: | Username | Passed Tests | Total Tests | Suite |
 			username = strings.TrimSpace(parts[1])
 		} else {
 			// Format is: | Rank | Username | Solution | Date Submitted |
@@ -124,23 +151,37 @@ func (ss *ScoreboardService) isNumeric(s string) bool {
 
 // GetScoreboard returns the scoreboard for a specific challenge
 func (ss *ScoreboardService) GetScoreboard(challengeID int) ([]models.ScoreboardEntry, bool) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
 	scoreboard, exists := ss.scoreboards[challengeID]
 	return scoreboard, exists
 }
 
 // GetAllScoreboards returns all scoreboards
 func (ss *ScoreboardService) GetAllScoreboards() models.ScoreboardMap {
-	return ss.scoreboards
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	// Copy so callers can range over it while submissions are added
+	scoreboards := make(models.ScoreboardMap, len(ss.scoreboards))
+	for id, entries := range ss.scoreboards {
+		scoreboards[id] = entries
+	}
+	return scoreboards
 }
 
 // AddSubmission adds a submission to the scoreboard
 func (ss *ScoreboardService) AddSubmission(submission models.Submission) {
 	entry := models.ScoreboardEntry{
-		Username:    submission.Username,
-		ChallengeID: submission.ChallengeID,
-		SubmittedAt: submission.SubmittedAt,
+		Username:     submission.Username,
+		ChallengeID:  submission.ChallengeID,
+		SubmittedAt:  submission.SubmittedAt,
+		SuiteVersion: submission.SuiteVersion,
 	}
 
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
 	// Add to the scoreboard for this challenge
 	if ss.scoreboards[submission.ChallengeID] == nil {
 		ss.scoreboards[submission.ChallengeID] = []models.ScoreboardEntry{}
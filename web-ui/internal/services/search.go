@@ -0,0 +1,465 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of searchable documents
+const (
+	SearchClassic          = "classic"
+	SearchPackage          = "package"
+	SearchPackageChallenge = "package_challenge"
+)
+
+const (
+	// defaultSearchLimit and maxSearchLimit bound the results returned per query
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// minPrefixLength is the shortest query term that also matches longer words
+	// ("cancel" finds "cancellation")
+	minPrefixLength = 3
+	// prefixPenalty scales down the score of prefix matches
+	prefixPenalty = 0.5
+	// snippetRadius is roughly how many characters are shown around the first match
+	snippetRadius = 90
+)
+
+// searchFields are the indexed parts of a document and their weight in the ranking
+var searchFields = []struct {
+	name   string
+	weight float64
+}{
+	{"title", 6},
+	{"tags", 4},
+	{"description", 2},
+	{"learning", 1},
+	{"hints", 1},
+}
+
+// searchStopWords are too common to be worth indexing
+var searchStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"do": true, "for": true, "from": true, "how": true, "if": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true,
+	"we": true, "what": true, "where": true, "which": true, "with": true, "you": true, "your": true,
+}
+
+// SearchResult is one document matching a query
+type SearchResult struct {
+	Kind       string   `json:"kind"` // classic, package or package_challenge
+	ID         string   `json:"id"`   // challenge number, package name or package challenge ID
+	Package    string   `json:"package,omitempty"`
+	Title      string   `json:"title"`
+	URL        string   `json:"url"`
+	Difficulty string   `json:"difficulty,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Score      float64  `json:"score"`
+	Field      string   `json:"field"`   // the field the snippet comes from
+	Snippet    string   `json:"snippet"` // HTML-escaped, matches wrapped in <mark>
+}
+
+// SearchResponse holds the ranked results of a query and facet counts over all
+// documents that match it, before the kind, difficulty and tag filters are applied
+type SearchResponse struct {
+	Query   string                    `json:"query"`
+	Total   int                       `json:"total"` // matches after filtering
+	Results []SearchResult            `json:"results"`
+	Facets  map[string]map[string]int `json:"facets"`
+}
+
+// SearchQuery is a query with optional facet filters
+type SearchQuery struct {
+	Text       string
+	Kind       string
+	Difficulty string
+	Tag        string
+	Limit      int
+}
+
+// searchDocument is an indexed challenge or package
+type searchDocument struct {
+	kind       string
+	id         string
+	pkg        string
+	title      string
+	url        string
+	difficulty string
+	tags       []string
+	fields     map[string]string // raw text per field, for snippets
+	length     int               // number of indexed terms
+}
+
+// posting records how often a term occurs in each field of a document
+type posting struct {
+	doc    int
+	counts map[string]int
+}
+
+// searchIndex is an inverted index over documents
+type searchIndex struct {
+	docs     []*searchDocument
+	postings map[string][]posting
+	terms    []string // sorted, for prefix lookups
+	avgLen   float64
+}
+
+// SearchService answers full-text queries over classic challenges, packages and
+// package challenges: READMEs, learning materials, hints, tags and descriptions.
+// The index lives in memory and is rebuilt with Rebuild when content is reloaded.
+type SearchService struct {
+	challenges *ChallengeService
+	packages   *PackageService
+
+	mu    sync.RWMutex
+	index *searchIndex
+}
+
+// NewSearchService creates a search service; call Rebuild once content is loaded
+func NewSearchService(challenges *ChallengeService, packages *PackageService) *SearchService {
+	return &SearchService{challenges: challenges, packages: packages, index: &searchIndex{postings: map[string][]posting{}}}
+}
+
+// Rebuild indexes the currently loaded challenges and packages
+func (ss *SearchService) Rebuild() {
+	var docs []*searchDocument
+
+	for _, challenge := range ss.challenges.FilterChallenges(ChallengeFilter{}) {
+		docs = append(docs, &searchDocument{
+			kind:       SearchClassic,
+			id:         fmt.Sprint(challenge.ID),
+			title:      challenge.Title,
+			url:        fmt.Sprintf("/challenge/%d", challenge.ID),
+			difficulty: challenge.Difficulty,
+			tags:       challenge.Tags,
+			fields: map[string]string{
+				"title":       challenge.Title,
+				"tags":        strings.Join(challenge.Tags, " "),
+				"description": challenge.ShortDescription + "\n\n" + challenge.Description,
+				"learning":    challenge.LearningMaterials,
+				"hints":       challenge.Hints,
+			},
+		})
+	}
+
+	packages := ss.packages.GetPackages()
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pkg := packages[name]
+		docs = append(docs, &searchDocument{
+			kind:       SearchPackage,
+			id:         name,
+			pkg:        name,
+			title:      pkg.DisplayName,
+			url:        "/packages/" + name,
+			difficulty: pkg.Difficulty,
+			tags:       pkg.Tags,
+			fields: map[string]string{
+				"title":       pkg.DisplayName,
+				"tags":        strings.Join(append(append([]string{}, pkg.Tags...), pkg.Category), " "),
+				"description": pkg.Description + "\n\n" + strings.Join(pkg.RealWorldUsage, "\n"),
+			},
+		})
+
+		// Only challenges in the learning path are shown on the site
+		for _, challengeID := range pkg.LearningPath {
+			challenge := ss.packages.GetChallenge(name, challengeID)
+			if challenge == nil {
+				continue
+			}
+			title := challenge.Title
+			if info := pkg.ChallengeDetails[challengeID]; info != nil && info.Title != "" {
+				title = info.Title
+			}
+			docs = append(docs, &searchDocument{
+				kind:       SearchPackageChallenge,
+				id:         challengeID,
+				pkg:        name,
+				title:      title,
+				url:        "/packages/" + name + "/" + challengeID,
+				difficulty: challenge.Difficulty,
+				tags:       challenge.Tags,
+				fields: map[string]string{
+					"title":       title,
+					"tags":        strings.Join(challenge.Tags, " "),
+					"description": challenge.ShortDescription + "\n\n" + challenge.Description,
+					"learning":    challenge.LearningMaterials,
+					"hints":       challenge.Hints,
+				},
+			})
+		}
+	}
+
+	index := buildSearchIndex(docs)
+	ss.mu.Lock()
+	ss.index = index
+	ss.mu.Unlock()
+	log.Printf("Indexed %d documents (%d terms) for search", len(index.docs), len(index.terms))
+}
+
+// buildSearchIndex tokenizes every field of every document
+func buildSearchIndex(docs []*searchDocument) *searchIndex {
+	index := &searchIndex{docs: docs, postings: make(map[string][]posting)}
+	total := 0
+	for i, doc := range docs {
+		counts := make(map[string]map[string]int)
+		for _, field := range searchFields {
+			for _, term := range searchTerms(doc.fields[field.name]) {
+				if counts[term] == nil {
+					counts[term] = make(map[string]int)
+				}
+				counts[term][field.name]++
+				doc.length++
+			}
+		}
+		for term, fields := range counts {
+			index.postings[term] = append(index.postings[term], posting{doc: i, counts: fields})
+		}
+		total += doc.length
+	}
+	for term := range index.postings {
+		index.terms = append(index.terms, term)
+	}
+	sort.Strings(index.terms)
+	if len(docs) > 0 {
+		index.avgLen = float64(total) / float64(len(docs))
+	}
+	return index
+}
+
+// searchTerms splits text into lowercase words, dropping stop words and single characters
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) > 1 && !searchStopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Search ranks the documents matching every term of the query (or, when no document
+// does, any term) with BM25 over weighted fields
+func (ss *SearchService) Search(query SearchQuery) SearchResponse {
+	ss.mu.RLock()
+	index := ss.index
+	ss.mu.RUnlock()
+
+	response := SearchResponse{
+		Query:   query.Text,
+		Results: []SearchResult{},
+		Facets:  map[string]map[string]int{"kind": {}, "difficulty": {}, "tag": {}},
+	}
+	terms := uniqueStrings(searchTerms(query.Text))
+	if len(terms) == 0 {
+		return response
+	}
+
+	scores := index.score(terms, true)
+	if len(scores) == 0 {
+		scores = index.score(terms, false)
+	}
+
+	results := []SearchResult{}
+	for i, score := range scores {
+		doc := index.docs[i]
+		response.Facets["kind"][doc.kind]++
+		if doc.difficulty != "" {
+			response.Facets["difficulty"][doc.difficulty]++
+		}
+		for _, tag := range doc.tags {
+			response.Facets["tag"][tag]++
+		}
+
+		if (query.Kind != "" && doc.kind != query.Kind) ||
+			(query.Difficulty != "" && !strings.EqualFold(doc.difficulty, query.Difficulty)) ||
+			(query.Tag != "" && !containsFold(doc.tags, query.Tag)) {
+			continue
+		}
+		field, snippet := doc.snippet(terms)
+		results = append(results, SearchResult{
+			Kind:       doc.kind,
+			ID:         doc.id,
+			Package:    doc.pkg,
+			Title:      doc.title,
+			URL:        doc.url,
+			Difficulty: doc.difficulty,
+			Tags:       doc.tags,
+			Score:      math.Round(score*1000) / 1000,
+			Field:      field,
+			Snippet:    snippet,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].URL < results[j].URL
+	})
+	response.Total = len(results)
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	response.Results = results
+	return response
+}
+
+// score computes BM25 scores for the documents matching all terms, or any term when
+// requireAll is false
+func (index *searchIndex) score(terms []string, requireAll bool) map[int]float64 {
+	const k1, b = 1.2, 0.75
+	scores := make(map[int]float64)
+	matched := make(map[int]int)
+	n := float64(len(index.docs))
+
+	for _, term := range terms {
+		// Every indexed word the term matches, exactly or as a prefix
+		expansions := map[string]float64{}
+		if _, ok := index.postings[term]; ok {
+			expansions[term] = 1
+		}
+		if len(term) >= minPrefixLength {
+			for i := sort.SearchStrings(index.terms, term); i < len(index.terms) && strings.HasPrefix(index.terms[i], term); i++ {
+				if index.terms[i] != term {
+					expansions[index.terms[i]] = prefixPenalty
+				}
+			}
+		}
+
+		termScores := make(map[int]float64)
+		for word, factor := range expansions {
+			postings := index.postings[word]
+			idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			for _, p := range postings {
+				tf := 0.0
+				for _, field := range searchFields {
+					tf += field.weight * float64(p.counts[field.name])
+				}
+				norm := 1 - b + b*float64(index.docs[p.doc].length)/math.Max(index.avgLen, 1)
+				termScores[p.doc] = math.Max(termScores[p.doc], factor*idf*tf*(k1+1)/(tf+k1*norm))
+			}
+		}
+		for doc, score := range termScores {
+			scores[doc] += score
+			matched[doc]++
+		}
+	}
+
+	if requireAll {
+		for doc := range scores {
+			if matched[doc] < len(terms) {
+				delete(scores, doc)
+			}
+		}
+	}
+	return scores
+}
+
+// snippet returns an excerpt around the first match in the field that matches the
+// most query terms, preferring higher-weighted fields on ties
+func (doc *searchDocument) snippet(terms []string) (string, string) {
+	alternatives := make([]string, len(terms))
+	for i, term := range terms {
+		alternatives[i] = regexp.QuoteMeta(term)
+	}
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)[\p{L}\p{N}]*`)
+
+	best, bestMatched := "", 0
+	for _, field := range searchFields {
+		matched := 0
+		text := strings.ToLower(doc.fields[field.name])
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				matched++
+			}
+		}
+		if matched > bestMatched && pattern.MatchString(doc.fields[field.name]) {
+			best, bestMatched = field.name, matched
+		}
+	}
+
+	if best != "" {
+		text := strings.Join(strings.Fields(doc.fields[best]), " ")
+		location := pattern.FindStringIndex(text)
+
+		start, end := location[0]-snippetRadius/2, location[1]+snippetRadius
+		prefix, suffix := "…", "…"
+		if start <= 0 {
+			start, prefix = 0, ""
+		} else if space := strings.IndexByte(text[start:location[0]], ' '); space != -1 {
+			start += space + 1
+		}
+		if end >= len(text) {
+			end, suffix = len(text), ""
+		} else if space := strings.LastIndexByte(text[location[1]:end], ' '); space != -1 {
+			end = location[1] + space
+		}
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start++
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+		excerpt := text[start:end]
+
+		var out strings.Builder
+		out.WriteString(prefix)
+		last := 0
+		for _, match := range pattern.FindAllStringIndex(excerpt, -1) {
+			out.WriteString(html.EscapeString(excerpt[last:match[0]]))
+			out.WriteString("<mark>" + html.EscapeString(excerpt[match[0]:match[1]]) + "</mark>")
+			last = match[1]
+		}
+		out.WriteString(html.EscapeString(excerpt[last:]))
+		out.WriteString(suffix)
+		return best, out.String()
+	}
+	return "title", html.EscapeString(doc.title)
+}
+
+// uniqueStrings drops repeated values, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// suiteVersionLength is how many hex digits of the test file's SHA-256 make a suite
+// version; the scoreboard workflows use `sha256sum | cut -c1-12` to match
+const suiteVersionLength = 12
+
+var (
+	suiteVersionPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+	testFuncPattern     = regexp.MustCompile(`(?m)^func Test\w*\(\s*\w+\s+\*testing\.T\s*\)`)
+	subtestPattern      = regexp.MustCompile(`\.Run\(`)
+)
+
+var (
+	excludeStaleOnce sync.Once
+	excludeStale     bool
+)
+
+// SuiteVersion identifies a version of a challenge's test suite by the content of its
+// test file. It is "" when there is no test file.
+func SuiteVersion(testFile string) string {
+	if testFile == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(testFile))
+	return hex.EncodeToString(sum[:])[:suiteVersionLength]
+}
+
+// testFileIn returns the content of the test file in a challenge directory
+func testFileIn(dir string) string {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "solution-template_test.go"))
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
+
+// suiteTestCount returns the number of tests the scoreboard workflows count for a test
+// file, which is its number of Test functions. ok is false when the file runs subtests:
+// the workflows count every subtest, and only running the suite tells how many there are.
+func suiteTestCount(testFile string) (count int, ok bool) {
+	if subtestPattern.MatchString(testFile) {
+		return 0, false
+	}
+	count = len(testFuncPattern.FindAllString(testFile, -1))
+	return count, count > 0
+}
+
+// ExcludeStaleResults reports whether results graded against an older test suite are
+// left out of leaderboards and progress until the challenge is re-graded
+// (EXCLUDE_STALE_RESULTS=true). By default they count and are flagged. The setting is
+// read once.
+func ExcludeStaleResults() bool {
+	excludeStaleOnce.Do(func() {
+		excludeStale = envBool("EXCLUDE_STALE_RESULTS", false)
+	})
+	return excludeStale
+}
+
+// markStaleRows flags the rows of a scoreboard that were not graded against testFile, the
+// current test suite. Rows recording a suite version are stale when it differs. Rows from
+// before versions were recorded are stale when their test count differs from that of the
+// rows graded against the current suite, as when a suite grew from 6 to 8 tests, or, when
+// no row has been graded against it yet, from the number of tests in testFile.
+func markStaleRows(rows []ScoreboardRow, testFile string) {
+	current := SuiteVersion(testFile)
+	if current == "" {
+		return
+	}
+	currentTotal := 0
+	for _, row := range rows {
+		if row.Suite == current && row.Total > currentTotal {
+			currentTotal = row.Total
+		}
+	}
+	if currentTotal == 0 {
+		currentTotal, _ = suiteTestCount(testFile)
+	}
+	for i := range rows {
+		switch {
+		case rows[i].Suite != "":
+			rows[i].Stale = rows[i].Suite != current
+		case currentTotal > 0:
+			rows[i].Stale = rows[i].Total != currentTotal
+		}
+	}
+}
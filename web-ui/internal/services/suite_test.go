@@ -0,0 +1,87 @@
+package services
+
+import "testing"
+
+const eightTests = `package main
+
+import "testing"
+
+func TestA(t *testing.T) {}
+func TestB(t *testing.T) {}
+func TestC(t *testing.T) {}
+func TestD(t *testing.T) {}
+func TestE(t *testing.T) {}
+func TestF(t *testing.T) {}
+func TestG(t *testing.T) {}
+func TestH(t *testing.T) {}
+
+func TestMain(m *testing.M) {}
+func helper(t *testing.T)   {}
+`
+
+func TestSuiteTestCount(t *testing.T) {
+	if count, ok := suiteTestCount(eightTests); !ok || count != 8 {
+		t.Fatalf("suiteTestCount = %d, %v; want 8, true", count, ok)
+	}
+	subtests := eightTests + "func TestI(t *testing.T) { t.Run(\"case\", func(t *testing.T) {}) }\n"
+	if _, ok := suiteTestCount(subtests); ok {
+		t.Fatal("counted a suite with subtests")
+	}
+	if _, ok := suiteTestCount("package main\n"); ok {
+		t.Fatal("counted a file without tests")
+	}
+}
+
+func TestMarkStaleRows(t *testing.T) {
+	current := SuiteVersion(eightTests)
+	older := SuiteVersion("package main\n")
+
+	tests := []struct {
+		name     string
+		testFile string
+		rows     []ScoreboardRow
+		want     []bool
+	}{
+		{
+			name:     "versioned rows",
+			testFile: eightTests,
+			rows:     []ScoreboardRow{{Total: 8, Suite: current}, {Total: 8, Suite: older}},
+			want:     []bool{false, true},
+		},
+		{
+			name:     "legacy rows against current rows",
+			testFile: eightTests,
+			rows:     []ScoreboardRow{{Total: 8, Suite: current}, {Total: 6}, {Total: 8}},
+			want:     []bool{false, true, false},
+		},
+		{
+			name:     "legacy rows only",
+			testFile: eightTests,
+			rows:     []ScoreboardRow{{Total: 6}, {Total: 6}, {Total: 8}},
+			want:     []bool{true, true, false},
+		},
+		{
+			name:     "legacy rows only with subtests",
+			testFile: eightTests + "func TestI(t *testing.T) { t.Run(\"case\", nil) }\n",
+			rows:     []ScoreboardRow{{Total: 6}, {Total: 12}},
+			want:     []bool{false, false},
+		},
+		{
+			name:     "no test file",
+			testFile: "",
+			rows:     []ScoreboardRow{{Total: 6, Suite: older}, {Total: 6}},
+			want:     []bool{false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			markStaleRows(tt.rows, tt.testFile)
+			for i, row := range tt.rows {
+				if row.Stale != tt.want[i] {
+					t.Fatalf("row %d (%d tests, suite %q) stale = %v, want %v", i, row.Total, row.Suite, row.Stale, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// TeamsFileName is the teams definition file at the repository root
+const TeamsFileName = "teams.json"
+
+// ErrTeamNotFound is returned when a team ID does not exist
+var ErrTeamNotFound = errors.New("team not found")
+
+var teamIDRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// TeamService manages team definitions and computes team leaderboards and dashboards
+type TeamService struct {
+	teams              map[string]*models.Team
+	mutex              sync.RWMutex
+	workspaceService   *WorkspaceService
+	challengeService   *ChallengeService
+	userService        *UserService
+	packageService     *PackageService
+	leaderboardService *LeaderboardService
+}
+
+// NewTeamService creates a new team service
+func NewTeamService(
+	workspaceService *WorkspaceService,
+	challengeService *ChallengeService,
+	userService *UserService,
+	packageService *PackageService,
+	leaderboardService *LeaderboardService,
+) *TeamService {
+	return &TeamService{
+		teams:              make(map[string]*models.Team),
+		workspaceService:   workspaceService,
+		challengeService:   challengeService,
+		userService:        userService,
+		packageService:     packageService,
+		leaderboardService: leaderboardService,
+	}
+}
+
+// LoadTeams reads teams.json from the repository root. A missing file means no teams.
+func (ts *TeamService) LoadTeams() error {
+	content, err := os.ReadFile(filepath.Join(ts.workspaceService.Root(), TeamsFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", TeamsFileName, err)
+	}
+
+	var file models.TeamsFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", TeamsFileName, err)
+	}
+
+	teams := make(map[string]*models.Team)
+	for _, team := range file.Teams {
+		if err := normalizeTeam(team); err != nil {
+			log.Printf("Warning: skipping team %q: %v", team.ID, err)
+			continue
+		}
+		teams[team.ID] = team
+	}
+
+	ts.mutex.Lock()
+	ts.teams = teams
+	ts.mutex.Unlock()
+
+	log.Printf("Loaded %d teams", len(teams))
+	return nil
+}
+
+// normalizeTeam validates a team and cleans up its member list
+func normalizeTeam(team *models.Team) error {
+	if team == nil {
+		return errors.New("empty team")
+	}
+	team.ID = strings.ToLower(strings.TrimSpace(team.ID))
+	if !teamIDRe.MatchString(team.ID) {
+		return fmt.Errorf("invalid team id %q (use lowercase letters, digits and hyphens)", team.ID)
+	}
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		team.Name = team.ID
+	}
+
+	seen := make(map[string]bool)
+	members := []string{}
+	for _, member := range team.Members {
+		member = strings.TrimSpace(member)
+		if !ValidGitHubUsername(member) {
+			return fmt.Errorf("invalid member username %q", member)
+		}
+		if seen[strings.ToLower(member)] {
+			continue
+		}
+		seen[strings.ToLower(member)] = true
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return strings.ToLower(members[i]) < strings.ToLower(members[j])
+	})
+	team.Members = members
+	return nil
+}
+
+// GetTeams returns all teams sorted by name
+func (ts *TeamService) GetTeams() []*models.Team {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	teams := make([]*models.Team, 0, len(ts.teams))
+	for _, team := range ts.teams {
+		teams = append(teams, team)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].Name < teams[j].Name
+	})
+	return teams
+}
+
+// GetTeam returns a team by ID
+func (ts *TeamService) GetTeam(id string) (*models.Team, bool) {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	team, ok := ts.teams[id]
+	return team, ok
+}
+
+// TeamsForUser returns the teams username belongs to
+func (ts *TeamService) TeamsForUser(username string) []*models.Team {
+	var result []*models.Team
+	for _, team := range ts.GetTeams() {
+		for _, member := range team.Members {
+			if strings.EqualFold(member, username) {
+				result = append(result, team)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// SaveTeam creates or replaces a team and persists teams.json
+func (ts *TeamService) SaveTeam(team *models.Team) error {
+	if err := normalizeTeam(team); err != nil {
+		return err
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	previous, existed := ts.teams[team.ID]
+	ts.teams[team.ID] = team
+	if err := ts.persistLocked(); err != nil {
+		// Roll back so memory matches disk
+		if existed {
+			ts.teams[team.ID] = previous
+		} else {
+			delete(ts.teams, team.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteTeam removes a team and persists teams.json
+func (ts *TeamService) DeleteTeam(id string) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	previous, ok := ts.teams[id]
+	if !ok {
+		return ErrTeamNotFound
+	}
+	delete(ts.teams, id)
+	if err := ts.persistLocked(); err != nil {
+		ts.teams[id] = previous
+		return err
+	}
+	return nil
+}
+
+// persistLocked writes teams.json; callers must hold the write lock
+func (ts *TeamService) persistLocked() error {
+	file := models.TeamsFile{Teams: make([]*models.Team, 0, len(ts.teams))}
+	for _, team := range ts.teams {
+		file.Teams = append(file.Teams, team)
+	}
+	sort.Slice(file.Teams, func(i, j int) bool {
+		return file.Teams[i].ID < file.Teams[j].ID
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = ts.workspaceService.WriteFile(TeamsFileName, append(data, '\n'))
+	return err
+}
+
+// lowerKeys re-keys a per-user map by lowercase username so team members match case-insensitively
+func lowerKeys[V any](m map[string]V) map[string]V {
+	result := make(map[string]V, len(m))
+	for k, v := range m {
+		result[strings.ToLower(k)] = v
+	}
+	return result
+}
+
+// summarize aggregates member completion sets into a team leaderboard entry
+func summarize(team *models.Team, completed map[string][]string, totalChallenges int) models.TeamLeaderboardEntry {
+	entry := models.TeamLeaderboardEntry{
+		TeamID:          team.ID,
+		TeamName:        team.Name,
+		MemberCount:     len(team.Members),
+		TotalChallenges: totalChallenges,
+	}
+
+	unique := make(map[string]bool)
+	for _, member := range team.Members {
+		done := completed[strings.ToLower(member)]
+		if len(done) > 0 {
+			entry.ActiveMembers++
+		}
+		entry.TotalCompleted += len(done)
+		for _, id := range done {
+			unique[id] = true
+		}
+	}
+	entry.UniqueCompleted = len(unique)
+
+	if entry.MemberCount > 0 {
+		entry.AveragePerMember = float64(entry.TotalCompleted) / float64(entry.MemberCount)
+		if totalChallenges > 0 {
+			entry.CompletionRate = float64(entry.TotalCompleted) / float64(entry.MemberCount*totalChallenges) * 100
+		}
+	}
+	return entry
+}
+
+// rankTeams sorts entries by completion rate, then total completions, then name, and assigns ranks
+func rankTeams(entries []models.TeamLeaderboardEntry) []models.TeamLeaderboardEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CompletionRate != entries[j].CompletionRate {
+			return entries[i].CompletionRate > entries[j].CompletionRate
+		}
+		if entries[i].TotalCompleted != entries[j].TotalCompleted {
+			return entries[i].TotalCompleted > entries[j].TotalCompleted
+		}
+		return entries[i].TeamName < entries[j].TeamName
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries
+}
+
+// classicCompletedIDs flattens ClassicCompletions into lowercase username -> challenge keys
+func (ts *TeamService) classicCompletedIDs() map[string][]string {
+	result := make(map[string][]string)
+	for username, done := range lowerKeys(ts.leaderboardService.ClassicCompletions()) {
+		for id := range done {
+			result[username] = append(result[username], fmt.Sprintf("%d", id))
+		}
+	}
+	return result
+}
+
+// packageCompletedIDs flattens PackageStats into lowercase username -> challenge IDs
+func (ts *TeamService) packageCompletedIDs(packageName string, challenges []*models.PackageChallenge) map[string][]string {
+	result := make(map[string][]string)
+	for username, stats := range lowerKeys(ts.leaderboardService.PackageStats(packageName, challenges)) {
+		for id := range stats.ChallengesCompleted {
+			result[username] = append(result[username], id)
+		}
+	}
+	return result
+}
+
+// ClassicLeaderboard ranks teams on classic challenges using the main leaderboard's pass criteria
+func (ts *TeamService) ClassicLeaderboard() []models.TeamLeaderboardEntry {
+	completed := ts.classicCompletedIDs()
+	total := len(ts.challengeService.GetChallenges())
+
+	entries := []models.TeamLeaderboardEntry{}
+	for _, team := range ts.GetTeams() {
+		entries = append(entries, summarize(team, completed, total))
+	}
+	return rankTeams(entries)
+}
+
+// PackageLeaderboard ranks teams on one package learning path
+func (ts *TeamService) PackageLeaderboard(packageName string) ([]models.TeamLeaderboardEntry, error) {
+	_, challenges, err := ts.leaderboardService.PackageChallenges(packageName)
+	if err != nil {
+		return nil, err
+	}
+	completed := ts.packageCompletedIDs(packageName, challenges)
+
+	entries := []models.TeamLeaderboardEntry{}
+	for _, team := range ts.GetTeams() {
+		entries = append(entries, summarize(team, completed, len(challenges)))
+	}
+	return rankTeams(entries), nil
+}
+
+// Dashboard shows per-member progress for a team, including who is stuck
+func (ts *TeamService) Dashboard(id string) (*models.TeamDashboard, error) {
+	team, ok := ts.GetTeam(id)
+	if !ok {
+		return nil, ErrTeamNotFound
+	}
+
+	challenges := ts.challengeService.GetChallenges()
+	classicCompletions := lowerKeys(ts.leaderboardService.ClassicCompletions())
+	classicIDs := ts.classicCompletedIDs()
+
+	dashboard := &models.TeamDashboard{
+		Team:             team,
+		ClassicSummary:   summarize(team, classicIDs, len(challenges)),
+		PackageSummaries: make(map[string]models.TeamLeaderboardEntry),
+		ChallengeSolvers: make(map[int][]string),
+	}
+	for challengeID := range challenges {
+		dashboard.ClassicChallenges = append(dashboard.ClassicChallenges, challengeID)
+	}
+	sort.Ints(dashboard.ClassicChallenges)
+
+	// Package completions are computed once per package, not per member
+	type packageData struct {
+		completed map[string][]string
+		partial   map[string][]string // lowercase username -> challenges with a partial scoreboard row
+	}
+	packages := make(map[string]packageData)
+	for name := range ts.packageService.GetPackages() {
+		_, pkgChallenges, err := ts.leaderboardService.PackageChallenges(name)
+		if err != nil {
+			continue
+		}
+		data := packageData{
+			completed: ts.packageCompletedIDs(name, pkgChallenges),
+			partial:   make(map[string][]string),
+		}
+		for _, challenge := range pkgChallenges {
+			for _, row := range ReadPackageScoreboard(name, challenge.ID) {
+				if row.Passed < row.Total {
+					key := strings.ToLower(row.Username)
+					data.partial[key] = append(data.partial[key], name+"/"+challenge.ID)
+				}
+			}
+		}
+		packages[name] = data
+		dashboard.PackageSummaries[name] = summarize(team, data.completed, len(pkgChallenges))
+	}
+
+	for _, member := range team.Members {
+		key := strings.ToLower(member)
+		solved := classicCompletions[key]
+		if solved == nil {
+			solved = make(map[int]bool)
+		}
+
+		progress := models.TeamMemberProgress{
+			Username:         member,
+			MainRank:         rankIn(classicCompletions, key),
+			ClassicSolved:    solved,
+			ClassicCount:     len(solved),
+			ClassicStuck:     []int{},
+			PackageCompleted: make(map[string]int),
+			PackageStuck:     []string{},
+		}
+
+		// Attempted (submission on disk) but not passing all tests means stuck
+		attempts := ts.userService.GetUserAttempts(member, challenges)
+		for challengeID, attempted := range attempts.AttemptedIDs {
+			if attempted && !solved[challengeID] {
+				progress.ClassicStuck = append(progress.ClassicStuck, challengeID)
+			}
+		}
+		sort.Ints(progress.ClassicStuck)
+
+		packageTotal := 0
+		for name, data := range packages {
+			if n := len(data.completed[key]); n > 0 {
+				progress.PackageCompleted[name] = n
+				packageTotal += n
+			}
+			progress.PackageStuck = append(progress.PackageStuck, data.partial[key]...)
+		}
+		sort.Strings(progress.PackageStuck)
+
+		progress.Stuck = len(progress.ClassicStuck) > 0 || len(progress.PackageStuck) > 0
+		progress.Inactive = progress.ClassicCount == 0 && packageTotal == 0
+
+		for challengeID := range solved {
+			dashboard.ChallengeSolvers[challengeID] = append(dashboard.ChallengeSolvers[challengeID], member)
+		}
+		if progress.Stuck {
+			dashboard.StuckMembers = append(dashboard.StuckMembers, member)
+		}
+		if progress.Inactive {
+			dashboard.InactiveMembers = append(dashboard.InactiveMembers, member)
+		}
+
+		dashboard.Members = append(dashboard.Members, progress)
+	}
+
+	for _, challengeID := range dashboard.ClassicChallenges {
+		if len(dashboard.ChallengeSolvers[challengeID]) == 0 {
+			dashboard.UnsolvedByEveryone = append(dashboard.UnsolvedByEveryone, challengeID)
+		}
+	}
+
+	sort.Slice(dashboard.Members, func(i, j int) bool {
+		if dashboard.Members[i].ClassicCount != dashboard.Members[j].ClassicCount {
+			return dashboard.Members[i].ClassicCount > dashboard.Members[j].ClassicCount
+		}
+		return strings.ToLower(dashboard.Members[i].Username) < strings.ToLower(dashboard.Members[j].Username)
+	})
+
+	return dashboard, nil
+}
@@ -0,0 +1,501 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// TracksFileName is the learning tracks definition file at the repository root
+const TracksFileName = "tracks.json"
+
+const (
+	// defaultRecommendations and maxRecommendations bound how many challenges are suggested
+	defaultRecommendations = 3
+	maxRecommendations     = 20
+)
+
+// ErrTrackNotFound is returned when a track ID does not exist
+var ErrTrackNotFound = errors.New("track not found")
+
+// TrackService serves the curated learning tracks and the prerequisite graph of the
+// classic challenges, which comes from the prerequisites in their metadata.json
+type TrackService struct {
+	tracks        []*models.Track // in file order
+	prerequisites map[int][]int   // challenge -> challenges to solve first; acyclic
+	dependents    map[int]int     // challenge -> number of challenges it is a prerequisite of
+	mutex         sync.RWMutex
+
+	workspaceService   *WorkspaceService
+	challengeService   *ChallengeService
+	leaderboardService *LeaderboardService
+}
+
+// NewTrackService creates a new track service
+func NewTrackService(workspaceService *WorkspaceService, challengeService *ChallengeService, leaderboardService *LeaderboardService) *TrackService {
+	return &TrackService{
+		prerequisites:      make(map[int][]int),
+		dependents:         make(map[int]int),
+		workspaceService:   workspaceService,
+		challengeService:   challengeService,
+		leaderboardService: leaderboardService,
+	}
+}
+
+// LoadTracks reads tracks.json from the repository root and rebuilds the prerequisite
+// graph from the loaded challenges. A missing file means no tracks.
+func (ts *TrackService) LoadTracks() error {
+	challenges := ts.challengeService.GetChallenges()
+	prerequisites := prerequisiteGraph(challenges)
+	dependents := make(map[int]int)
+	for _, required := range prerequisites {
+		for _, id := range required {
+			dependents[id]++
+		}
+	}
+
+	var file models.TracksFile
+	content, err := os.ReadFile(filepath.Join(ts.workspaceService.Root(), TracksFileName))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %v", TracksFileName, err)
+	default:
+		if err := json.Unmarshal(content, &file); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", TracksFileName, err)
+		}
+	}
+
+	var tracks []*models.Track
+	seen := make(map[string]bool)
+	for _, track := range file.Tracks {
+		if err := normalizeTrack(track, challenges); err != nil {
+			log.Printf("Warning: skipping track: %v", err)
+			continue
+		}
+		if seen[track.ID] {
+			log.Printf("Warning: skipping duplicate track %q", track.ID)
+			continue
+		}
+		seen[track.ID] = true
+		tracks = append(tracks, track)
+	}
+
+	ts.mutex.Lock()
+	ts.tracks = tracks
+	ts.prerequisites = prerequisites
+	ts.dependents = dependents
+	ts.mutex.Unlock()
+
+	log.Printf("Loaded %d learning tracks", len(tracks))
+	return nil
+}
+
+// normalizeTrack validates a track against the loaded challenges
+func normalizeTrack(track *models.Track, challenges models.ChallengeMap) error {
+	if track == nil {
+		return errors.New("empty track")
+	}
+	track.ID = strings.TrimSpace(track.ID)
+	if !isPlainName(track.ID) {
+		return fmt.Errorf("invalid track ID %q", track.ID)
+	}
+	if strings.TrimSpace(track.Name) == "" {
+		track.Name = track.ID
+	}
+	if len(track.Challenges) == 0 {
+		return fmt.Errorf("track %q has no challenges", track.ID)
+	}
+	listed := make(map[int]bool)
+	for _, id := range track.Challenges {
+		if _, ok := challenges[id]; !ok {
+			return fmt.Errorf("track %q: unknown challenge %d", track.ID, id)
+		}
+		if listed[id] {
+			return fmt.Errorf("track %q lists challenge %d twice", track.ID, id)
+		}
+		listed[id] = true
+	}
+	return nil
+}
+
+// parsePrerequisite parses a "challenge-N" prerequisite
+func parsePrerequisite(ref string) (int, bool) {
+	ref = strings.TrimSpace(ref)
+	if !strings.HasPrefix(ref, "challenge-") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(ref, "challenge-"))
+	return id, err == nil
+}
+
+// prerequisiteGraph collects the prerequisites of every challenge. References to
+// unknown challenges are dropped, as are edges that would close a cycle, so the
+// result is always a DAG.
+func prerequisiteGraph(challenges models.ChallengeMap) map[int][]int {
+	ids := make([]int, 0, len(challenges))
+	for id := range challenges {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	graph := make(map[int][]int)
+	for _, id := range ids {
+		for _, ref := range challenges[id].Prerequisites {
+			required, ok := parsePrerequisite(ref)
+			if _, exists := challenges[required]; !ok || !exists || required == id {
+				log.Printf("Warning: challenge %d has an invalid prerequisite %q", id, ref)
+				continue
+			}
+			graph[id] = append(graph[id], required)
+		}
+	}
+
+	// Depth-first search; an edge to a challenge still on the stack closes a cycle
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int]int)
+	var visit func(id int)
+	visit = func(id int) {
+		state[id] = visiting
+		kept := graph[id][:0]
+		for _, required := range graph[id] {
+			switch state[required] {
+			case visiting:
+				log.Printf("Warning: ignoring prerequisite challenge-%d of challenge %d, it would create a cycle", required, id)
+				continue
+			case unvisited:
+				visit(required)
+			}
+			kept = append(kept, required)
+		}
+		if len(kept) > 0 {
+			graph[id] = kept
+		} else {
+			delete(graph, id)
+		}
+		state[id] = done
+	}
+	for _, id := range ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return graph
+}
+
+// GetTracks returns all tracks in the order they are defined
+func (ts *TrackService) GetTracks() []*models.Track {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return append([]*models.Track(nil), ts.tracks...)
+}
+
+// Prerequisites returns the prerequisite graph: the challenges to solve before each challenge
+func (ts *TrackService) Prerequisites() map[int][]int {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	graph := make(map[int][]int, len(ts.prerequisites))
+	for id, required := range ts.prerequisites {
+		graph[id] = append([]int(nil), required...)
+	}
+	return graph
+}
+
+// solved returns the classic challenges the user passed all tests of
+func (ts *TrackService) solved(username string) map[int]bool {
+	if username == "" {
+		return map[int]bool{}
+	}
+	if solved := ts.leaderboardService.ClassicCompletions()[username]; solved != nil {
+		return solved
+	}
+	return map[int]bool{}
+}
+
+// trackChallenge describes a challenge and its status given the user's solved challenges;
+// callers must hold the read lock
+func (ts *TrackService) trackChallenge(challenge *models.Challenge, solved map[int]bool) models.TrackChallenge {
+	item := models.TrackChallenge{
+		ID:            challenge.ID,
+		Title:         challenge.Title,
+		Difficulty:    challenge.Difficulty,
+		Prerequisites: ts.prerequisites[challenge.ID],
+		Status:        models.TrackStatusAvailable,
+	}
+	if solved[challenge.ID] {
+		item.Status = models.TrackStatusSolved
+		return item
+	}
+	for _, required := range item.Prerequisites {
+		if !solved[required] {
+			item.Missing = append(item.Missing, required)
+		}
+	}
+	if len(item.Missing) > 0 {
+		item.Status = models.TrackStatusLocked
+	}
+	return item
+}
+
+// Progress returns a user's progress through every track
+func (ts *TrackService) Progress(username string) []*models.TrackProgress {
+	solved := ts.solved(username)
+	challenges := ts.challengeService.GetChallenges()
+
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	progress := make([]*models.TrackProgress, 0, len(ts.tracks))
+	for _, track := range ts.tracks {
+		progress = append(progress, ts.trackProgress(track, username, solved, challenges))
+	}
+	return progress
+}
+
+// TrackProgress returns a user's progress through one track
+func (ts *TrackService) TrackProgress(id, username string) (*models.TrackProgress, error) {
+	solved := ts.solved(username)
+	challenges := ts.challengeService.GetChallenges()
+
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	for _, track := range ts.tracks {
+		if track.ID == id {
+			return ts.trackProgress(track, username, solved, challenges), nil
+		}
+	}
+	return nil, ErrTrackNotFound
+}
+
+// trackProgress computes one track's progress; callers must hold the read lock
+func (ts *TrackService) trackProgress(track *models.Track, username string, solved map[int]bool, challenges models.ChallengeMap) *models.TrackProgress {
+	progress := &models.TrackProgress{Track: track, Username: username, Challenges: []models.TrackChallenge{}}
+	for _, id := range track.Challenges {
+		challenge, ok := challenges[id]
+		if !ok {
+			continue // removed since the tracks were loaded
+		}
+		item := ts.trackChallenge(challenge, solved)
+		progress.Challenges = append(progress.Challenges, item)
+		progress.Total++
+		if item.Status == models.TrackStatusSolved {
+			progress.Solved++
+		} else if item.Status == models.TrackStatusAvailable && progress.Next == nil {
+			next := item
+			progress.Next = &next
+		}
+	}
+	if progress.Total > 0 {
+		progress.Progress = progress.Solved * 100 / progress.Total
+	}
+	return progress
+}
+
+// Recommend suggests the next challenges for a user: unsolved challenges whose
+// prerequisites are all solved, closest to the user's level first. The level is the
+// highest difficulty the user has shown they can handle: two solved challenges of a
+// difficulty (or one of the next) move them up. Ties go to challenges in tracks the
+// user has started, then to those that unlock the most others. With a track ID only
+// that track's challenges, and the prerequisites they are missing, are considered.
+func (ts *TrackService) Recommend(username, trackID string, limit int) ([]models.Recommendation, error) {
+	if limit <= 0 {
+		limit = defaultRecommendations
+	}
+	if limit > maxRecommendations {
+		limit = maxRecommendations
+	}
+	solved := ts.solved(username)
+	challenges := ts.challengeService.GetChallenges()
+
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	// Where each challenge appears in the tracks, and which tracks the user has started
+	trackNames := make(map[string]string)
+	memberOf := make(map[int][]string)
+	position := make(map[int]int)
+	started := make(map[string]bool)
+	for _, track := range ts.tracks {
+		trackNames[track.ID] = track.Name
+		for i, id := range track.Challenges {
+			if _, seen := position[id]; !seen {
+				position[id] = i
+			}
+			memberOf[id] = append(memberOf[id], track.ID)
+			started[track.ID] = started[track.ID] || solved[id]
+		}
+	}
+
+	candidates := make(map[int]bool)
+	if trackID == "" {
+		for id := range challenges {
+			candidates[id] = true
+		}
+	} else {
+		var track *models.Track
+		for _, t := range ts.tracks {
+			if t.ID == trackID {
+				track = t
+			}
+		}
+		if track == nil {
+			return nil, ErrTrackNotFound
+		}
+		var include func(id int)
+		include = func(id int) {
+			if candidates[id] {
+				return
+			}
+			candidates[id] = true
+			for _, required := range ts.prerequisites[id] {
+				if !solved[required] {
+					include(required)
+				}
+			}
+		}
+		for _, id := range track.Challenges {
+			include(id)
+		}
+	}
+
+	level := userLevel(solved, challenges)
+
+	type ranked struct {
+		models.Recommendation
+		distance int
+		started  bool
+		position int
+	}
+	var options []ranked
+	for id := range candidates {
+		challenge, ok := challenges[id]
+		if !ok || ts.trackChallenge(challenge, solved).Status != models.TrackStatusAvailable {
+			continue
+		}
+		option := ranked{
+			Recommendation: models.Recommendation{
+				ChallengeID: id,
+				Title:       challenge.Title,
+				Difficulty:  challenge.Difficulty,
+				Tracks:      memberOf[id],
+				Unlocks:     ts.dependents[id],
+			},
+			distance: difficultyDistance(difficultyIndex(challenge.Difficulty), level),
+			position: len(challenges),
+		}
+		if p, ok := position[id]; ok {
+			option.position = p
+		}
+		// Name the first started track it belongs to, or else the first track
+		trackName := ""
+		for _, track := range memberOf[id] {
+			if started[track] {
+				option.started, trackName = true, trackNames[track]
+				break
+			}
+		}
+		if trackName == "" && len(memberOf[id]) > 0 {
+			trackName = trackNames[memberOf[id][0]]
+		}
+		option.Reason = recommendationReason(option.Recommendation, level, option.started, trackName)
+		options = append(options, option)
+	}
+
+	sort.Slice(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		switch {
+		case a.distance != b.distance:
+			return a.distance < b.distance
+		case a.started != b.started:
+			return a.started
+		case a.Unlocks != b.Unlocks:
+			return a.Unlocks > b.Unlocks
+		case a.position != b.position:
+			return a.position < b.position
+		}
+		return a.ChallengeID < b.ChallengeID
+	})
+
+	recommendations := []models.Recommendation{}
+	for i := 0; i < len(options) && i < limit; i++ {
+		recommendations = append(recommendations, options[i].Recommendation)
+	}
+	return recommendations, nil
+}
+
+// difficultyIndex returns the position of a difficulty in Difficulties
+func difficultyIndex(difficulty string) int {
+	for i, d := range Difficulties {
+		if strings.EqualFold(d, difficulty) {
+			return i
+		}
+	}
+	return 1
+}
+
+// userLevel is the index of the difficulty a user is ready for
+func userLevel(solved map[int]bool, challenges models.ChallengeMap) int {
+	counts := make([]int, len(Difficulties))
+	for id := range solved {
+		if challenge, ok := challenges[id]; ok {
+			counts[difficultyIndex(challenge.Difficulty)]++
+		}
+	}
+	level := 0
+	for i := 1; i < len(Difficulties); i++ {
+		if counts[i-1] >= 2 || counts[i] > 0 {
+			level = i
+		}
+	}
+	return level
+}
+
+// difficultyDistance orders difficulties relative to the user's level: the level
+// itself, then one below, then one above, then anything further away
+func difficultyDistance(difficulty, level int) int {
+	switch difficulty - level {
+	case 0:
+		return 0
+	case -1:
+		return 1
+	case 1:
+		return 2
+	}
+	return 3
+}
+
+// recommendationReason explains a recommendation in one sentence
+func recommendationReason(recommendation models.Recommendation, level int, started bool, trackName string) string {
+	var parts []string
+	switch index := difficultyIndex(recommendation.Difficulty); {
+	case index == level:
+		parts = append(parts, recommendation.Difficulty+" matches your level")
+	case index > level:
+		parts = append(parts, recommendation.Difficulty+" is a step up")
+	default:
+		parts = append(parts, recommendation.Difficulty+" to build confidence")
+	}
+	if trackName != "" {
+		if started {
+			parts = append(parts, "continues the "+trackName+" track")
+		} else {
+			parts = append(parts, "part of the "+trackName+" track")
+		}
+	}
+	if recommendation.Unlocks > 0 {
+		parts = append(parts, fmt.Sprintf("unlocks %d more", recommendation.Unlocks))
+	}
+	return strings.Join(parts, "; ")
+}
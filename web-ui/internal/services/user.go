@@ -115,6 +115,15 @@ func (us *UserService) RefreshUserAttempts(username string, challenges models.Ch
 	return us.LoadUserAttempts(username, challenges)
 }
 
+// ClearAttempts drops every cached user attempt so they are rescanned on next access
+func (us *UserService) ClearAttempts() int {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+	cleared := len(us.userAttempts)
+	us.userAttempts = make(models.UserAttemptsMap)
+	return cleared
+}
+
 // GetUserAttempts returns the cached user attempts or loads them if not cached
 func (us *UserService) GetUserAttempts(username string, challenges models.ChallengeMap) *models.UserAttemptedChallenges {
 	us.mutex.RLock()
@@ -0,0 +1,564 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// Validation check statuses
+const (
+	CheckPassed  = "pass"
+	CheckFailed  = "fail" // counts as an error and makes the report fail
+	CheckWarning = "warn"
+	CheckSkipped = "skip"
+)
+
+// Validation target kinds
+const (
+	TargetChallenge        = "challenge"
+	TargetPackage          = "package"
+	TargetPackageChallenge = "package-challenge"
+)
+
+// Files every classic and package challenge directory must contain
+var requiredChallengeFiles = []string{
+	"README.md",
+	"SCOREBOARD.md",
+	"go.mod",
+	"metadata.json",
+	"run_tests.sh",
+	"solution-template.go",
+	"solution-template_test.go",
+}
+
+// Files a challenge directory should contain; the web UI degrades without them
+var optionalChallengeFiles = []string{"hints.md", "learning.md"}
+
+// Other entries a challenge directory may contain
+var otherChallengeEntries = []string{"go.sum", "submissions", "reference"}
+
+// scoreboardLink is the first line of every classic challenge README
+const scoreboardLink = "[View the Scoreboard](SCOREBOARD.md)"
+
+var (
+	classicDirPattern = regexp.MustCompile(`^challenge-(\d+)$`)
+	metadataSchema    = SchemaFor(models.ChallengeMetadata{})
+	packageSchema     = SchemaFor(models.Package{})
+)
+
+// ValidationCheck is the outcome of one check of a target
+type ValidationCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ValidationTarget is a classic challenge, a package or a package challenge and its checks
+type ValidationTarget struct {
+	Kind   string            `json:"kind"`
+	Name   string            `json:"name"` // e.g. "challenge-12", "gin" or "gin/challenge-1-basic-routing"
+	Path   string            `json:"path"` // relative to the repository root
+	Passed bool              `json:"passed"`
+	Checks []ValidationCheck `json:"checks"`
+}
+
+func (t *ValidationTarget) add(name, status, format string, args ...interface{}) {
+	detail := format
+	if len(args) > 0 {
+		detail = fmt.Sprintf(format, args...)
+	}
+	t.Checks = append(t.Checks, ValidationCheck{Name: name, Status: status, Detail: detail})
+}
+
+// ValidationReport is the machine-readable result of validating the repository content
+type ValidationReport struct {
+	Root        string              `json:"root"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+	Passed      bool                `json:"passed"`
+	Errors      int                 `json:"errors"`   // failed checks
+	Warnings    int                 `json:"warnings"` // checks that passed with a warning
+	Targets     []*ValidationTarget `json:"targets"`
+}
+
+// ValidateOptions selects what Validate checks
+type ValidateOptions struct {
+	Only     []string // target names to validate; empty validates everything
+	SkipExec bool     // skip compiling templates and running tests
+	Parallel int      // targets validated at the same time
+}
+
+// ContentValidator checks that the challenges and packages in the repository are
+// complete and consistent, compiling and testing templates and reference solutions
+type ContentValidator struct {
+	root     string
+	executor *ExecutionService
+}
+
+// NewContentValidator creates a validator for the repository the web UI runs from
+func NewContentValidator(executor *ExecutionService) (*ContentValidator, error) {
+	root, err := resolveRepositoryRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &ContentValidator{root: root, executor: executor}, nil
+}
+
+// validationJob validates one target
+type validationJob struct {
+	target *ValidationTarget
+	run    func(*ValidationTarget)
+}
+
+// Validate checks every classic challenge, package and package challenge, or the
+// ones named in options.Only
+func (cv *ContentValidator) Validate(options ValidateOptions) (*ValidationReport, error) {
+	jobs, err := cv.jobs()
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Only) > 0 {
+		selected := jobs[:0]
+		for _, job := range jobs {
+			if containsString(options.Only, job.target.Name) {
+				selected = append(selected, job)
+			}
+		}
+		jobs = selected
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("nothing to validate")
+	}
+
+	parallel := options.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	queue := make(chan validationJob)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job.run(job.target)
+				if !options.SkipExec && job.target.Kind != TargetPackage {
+					cv.checkExecution(job.target)
+				} else if job.target.Kind != TargetPackage {
+					job.target.add("template-compiles", CheckSkipped, "execution checks disabled")
+				}
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	report := &ValidationReport{Root: cv.root, GeneratedAt: time.Now(), Passed: true}
+	for _, job := range jobs {
+		target := job.target
+		target.Passed = true
+		for _, check := range target.Checks {
+			switch check.Status {
+			case CheckFailed:
+				target.Passed = false
+				report.Errors++
+			case CheckWarning:
+				report.Warnings++
+			}
+		}
+		report.Passed = report.Passed && target.Passed
+		report.Targets = append(report.Targets, target)
+	}
+	return report, nil
+}
+
+// jobs lists the targets in the repository, classic challenges first
+func (cv *ContentValidator) jobs() ([]validationJob, error) {
+	entries, err := ioutil.ReadDir(cv.root)
+	if err != nil {
+		return nil, err
+	}
+	type classic struct {
+		id   int
+		name string
+	}
+	var classics []classic
+	for _, entry := range entries {
+		if match := classicDirPattern.FindStringSubmatch(entry.Name()); entry.IsDir() && match != nil {
+			id, _ := strconv.Atoi(match[1])
+			classics = append(classics, classic{id, entry.Name()})
+		}
+	}
+	sort.Slice(classics, func(i, j int) bool { return classics[i].id < classics[j].id })
+
+	var jobs []validationJob
+	for _, c := range classics {
+		id := c.id
+		jobs = append(jobs, validationJob{
+			target: &ValidationTarget{Kind: TargetChallenge, Name: c.name, Path: c.name},
+			run: func(target *ValidationTarget) {
+				cv.checkChallengeDir(target)
+				cv.checkScoreboardLink(target)
+				cv.checkPrerequisites(target, id)
+			},
+		})
+	}
+
+	packages, err := ioutil.ReadDir(filepath.Join(cv.root, "packages"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, pkg := range packages {
+		if !pkg.IsDir() {
+			continue
+		}
+		name := pkg.Name()
+		jobs = append(jobs, validationJob{
+			target: &ValidationTarget{Kind: TargetPackage, Name: name, Path: filepath.ToSlash(filepath.Join("packages", name))},
+			run:    cv.checkPackage,
+		})
+
+		dirs, err := ioutil.ReadDir(filepath.Join(cv.root, "packages", name))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			if !dir.IsDir() || !strings.HasPrefix(dir.Name(), "challenge-") {
+				continue
+			}
+			jobs = append(jobs, validationJob{
+				target: &ValidationTarget{
+					Kind: TargetPackageChallenge,
+					Name: name + "/" + dir.Name(),
+					Path: filepath.ToSlash(filepath.Join("packages", name, dir.Name())),
+				},
+				run: cv.checkChallengeDir,
+			})
+		}
+	}
+	return jobs, nil
+}
+
+func (cv *ContentValidator) dir(target *ValidationTarget) string {
+	return filepath.Join(cv.root, filepath.FromSlash(target.Path))
+}
+
+// checkChallengeDir checks the files and metadata of a classic or package challenge
+func (cv *ContentValidator) checkChallengeDir(target *ValidationTarget) {
+	dir := cv.dir(target)
+
+	var missing []string
+	for _, name := range requiredChallengeFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		target.add("required-files", CheckFailed, "missing %s", strings.Join(missing, ", "))
+	} else {
+		target.add("required-files", CheckPassed, "")
+	}
+
+	missing = nil
+	for _, name := range optionalChallengeFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		target.add("optional-files", CheckWarning, "missing %s", strings.Join(missing, ", "))
+	} else {
+		target.add("optional-files", CheckPassed, "")
+	}
+
+	cv.checkStrayFiles(target, dir)
+	cv.checkMetadata(target, dir)
+}
+
+// checkStrayFiles reports entries that are not part of a challenge: compiled
+// binaries fail, anything else is a warning
+func (cv *ContentValidator) checkStrayFiles(target *ValidationTarget, dir string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		target.add("stray-files", CheckFailed, "%v", err)
+		return
+	}
+	var binaries, unknown []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if containsString(requiredChallengeFiles, name) || containsString(optionalChallengeFiles, name) ||
+			containsString(otherChallengeEntries, name) || localizedFilePattern.MatchString(name) {
+			continue
+		}
+		if !entry.IsDir() && isBinary(filepath.Join(dir, name)) {
+			binaries = append(binaries, name)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	switch {
+	case len(binaries) > 0:
+		target.add("stray-files", CheckFailed, "compiled binaries must not be committed: %s", strings.Join(binaries, ", "))
+	case len(unknown) > 0:
+		target.add("stray-files", CheckWarning, "unexpected entries: %s", strings.Join(unknown, ", "))
+	default:
+		target.add("stray-files", CheckPassed, "")
+	}
+}
+
+// isBinary reports whether a file is an executable or a compiled test binary
+func isBinary(path string) bool {
+	if strings.HasSuffix(path, ".test") || strings.HasSuffix(path, ".exe") {
+		return true
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header := make([]byte, 4)
+	if n, _ := file.Read(header); n < 4 {
+		return false
+	}
+	for _, magic := range [][]byte{
+		[]byte("\x7fELF"),
+		{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf}, // Mach-O
+		{0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe},
+	} {
+		if bytes.Equal(header, magic) {
+			return true
+		}
+	}
+	return bytes.HasPrefix(header, []byte("MZ"))
+}
+
+// checkMetadata validates metadata.json against the schema of models.ChallengeMetadata.
+// Fields the web UI does not know are only a warning.
+func (cv *ContentValidator) checkMetadata(target *ValidationTarget, dir string) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		target.add("metadata", CheckSkipped, "no metadata.json")
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		target.add("metadata", CheckFailed, "invalid JSON: %v", err)
+		return
+	}
+
+	var problems, unknown []string
+	for _, problem := range metadataSchema.Validate(value) {
+		problem = strings.TrimPrefix(problem, "response: ")
+		if strings.Contains(problem, "unexpected field") {
+			unknown = append(unknown, problem)
+		} else {
+			problems = append(problems, problem)
+		}
+	}
+	switch {
+	case len(problems) > 0:
+		target.add("metadata", CheckFailed, strings.Join(problems, "; "))
+	case len(unknown) > 0:
+		target.add("metadata", CheckWarning, strings.Join(unknown, "; "))
+	default:
+		target.add("metadata", CheckPassed, "")
+	}
+}
+
+// checkScoreboardLink checks that a classic challenge README starts with the scoreboard link
+func (cv *ContentValidator) checkScoreboardLink(target *ValidationTarget) {
+	data, err := ioutil.ReadFile(filepath.Join(cv.dir(target), "README.md"))
+	if err != nil {
+		target.add("scoreboard-link", CheckSkipped, "no README.md")
+		return
+	}
+	firstLine := strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0])
+	if firstLine != scoreboardLink {
+		target.add("scoreboard-link", CheckFailed, "README.md must start with %s", scoreboardLink)
+		return
+	}
+	target.add("scoreboard-link", CheckPassed, "")
+}
+
+// checkPrerequisites checks that the prerequisites of a classic challenge name
+// other classic challenges that exist
+func (cv *ContentValidator) checkPrerequisites(target *ValidationTarget, id int) {
+	metadata, err := readChallengeMetadata(cv.dir(target))
+	if err != nil || metadata == nil {
+		return
+	}
+	var broken []string
+	for _, prerequisite := range metadata.Prerequisites {
+		n, ok := parsePrerequisite(prerequisite)
+		if !ok {
+			continue // free-form prerequisites such as "Basic Go syntax"
+		}
+		if n == id {
+			broken = append(broken, prerequisite+" (itself)")
+		} else if _, err := os.Stat(filepath.Join(cv.root, fmt.Sprintf("challenge-%d", n))); err != nil {
+			broken = append(broken, prerequisite+" (does not exist)")
+		}
+	}
+	if len(broken) > 0 {
+		target.add("prerequisites", CheckFailed, strings.Join(broken, ", "))
+		return
+	}
+	target.add("prerequisites", CheckPassed, "")
+}
+
+// checkPackage checks package.json, the package README and its learning path
+func (cv *ContentValidator) checkPackage(target *ValidationTarget) {
+	dir := cv.dir(target)
+
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); err != nil {
+		target.add("readme", CheckFailed, "missing README.md")
+	} else {
+		target.add("readme", CheckPassed, "")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		target.add("package-json", CheckFailed, "missing package.json")
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		target.add("package-json", CheckFailed, "invalid JSON: %v", err)
+		return
+	}
+	if problems := packageSchema.Validate(value); len(problems) > 0 {
+		target.add("package-json", CheckFailed, strings.Replace(strings.Join(problems, "; "), "response: ", "", -1))
+		return
+	}
+	target.add("package-json", CheckPassed, "")
+
+	var pkg models.Package
+	json.Unmarshal(data, &pkg)
+	if pkg.Name != target.Name {
+		target.add("package-name", CheckFailed, "package.json names %q but the directory is %q", pkg.Name, target.Name)
+	}
+
+	var missing []string
+	for _, id := range pkg.LearningPath {
+		if info, err := os.Stat(filepath.Join(dir, id)); err != nil || !info.IsDir() {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		target.add("learning-path", CheckFailed, "no challenge directory for %s", strings.Join(missing, ", "))
+		return
+	}
+
+	entries, _ := ioutil.ReadDir(dir)
+	var unlisted []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "challenge-") && !containsString(pkg.LearningPath, entry.Name()) {
+			unlisted = append(unlisted, entry.Name())
+		}
+	}
+	if len(unlisted) > 0 {
+		target.add("learning-path", CheckWarning, "not in learning_path: %s", strings.Join(unlisted, ", "))
+		return
+	}
+	target.add("learning-path", CheckPassed, "")
+}
+
+// checkExecution compiles the template, checks that the tests fail against it and
+// that the reference solution, if there is one, passes them
+func (cv *ContentValidator) checkExecution(target *ValidationTarget) {
+	dir := cv.dir(target)
+	template, err := ioutil.ReadFile(filepath.Join(dir, "solution-template.go"))
+	if err != nil {
+		target.add("template-compiles", CheckSkipped, "no solution-template.go")
+		return
+	}
+	tests, err := ioutil.ReadFile(filepath.Join(dir, "solution-template_test.go"))
+	if err != nil {
+		target.add("template-compiles", CheckSkipped, "no solution-template_test.go")
+		return
+	}
+
+	challenge := &models.Challenge{Title: target.Name, Template: string(template), TestFile: string(tests)}
+	if target.Kind == TargetPackageChallenge {
+		parts := strings.SplitN(target.Name, "/", 2)
+		challenge.Package = &models.ChallengePackage{Name: parts[0], ChallengeID: parts[1]}
+	} else {
+		challenge.ID, _ = strconv.Atoi(strings.TrimPrefix(target.Name, "challenge-"))
+	}
+
+	analysis := cv.executor.AnalyzeCode(challenge.Template, challenge)
+	switch {
+	case analysis.Error != "":
+		target.add("template-compiles", CheckFailed, "could not run the tests: %s", firstLine(analysis.Error))
+		return
+	case !analysis.Compiled:
+		target.add("template-compiles", CheckFailed, describeFindings(analysis))
+		return
+	}
+	target.add("template-compiles", CheckPassed, "")
+
+	if analysis.Passed {
+		target.add("template-fails-tests", CheckFailed, "the tests pass against the untouched template")
+	} else {
+		target.add("template-fails-tests", CheckPassed, "")
+	}
+
+	name := solutionFileName(challenge)
+	reference, err := ioutil.ReadFile(filepath.Join(dir, "reference", name))
+	if err != nil {
+		target.add("reference-passes", CheckSkipped, "no reference/%s", name)
+		return
+	}
+	analysis = cv.executor.AnalyzeCode(string(reference), challenge)
+	switch {
+	case analysis.Error != "":
+		target.add("reference-passes", CheckFailed, "could not run the tests: %s", firstLine(analysis.Error))
+	case !analysis.Compiled:
+		target.add("reference-passes", CheckFailed, describeFindings(analysis))
+	case !analysis.Passed:
+		var failed []string
+		for _, test := range analysis.Tests {
+			if test.Status == "fail" {
+				failed = append(failed, test.Name)
+			}
+		}
+		target.add("reference-passes", CheckFailed, "failing tests: %s", strings.Join(failed, ", "))
+	default:
+		target.add("reference-passes", CheckPassed, "")
+	}
+}
+
+// describeFindings summarizes why code did not compile
+func describeFindings(analysis *CodeAnalysis) string {
+	var messages []string
+	for _, finding := range analysis.Findings {
+		if finding.Source == SourceCompiler {
+			messages = append(messages, finding.Message)
+		}
+		if len(messages) == 3 {
+			break
+		}
+	}
+	if len(messages) == 0 {
+		return "does not compile"
+	}
+	return "does not compile: " + strings.Join(messages, "; ")
+}
+
+func firstLine(text string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0])
+}
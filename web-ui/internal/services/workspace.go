@@ -0,0 +1,295 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/utils"
+)
+
+// Workspace errors returned to handlers so they can pick a status code
+var (
+	ErrInvalidPathComponent = errors.New("invalid path component")
+	ErrUnknownChallenge     = errors.New("unknown challenge")
+	ErrOutsideWorkspace     = errors.New("path escapes repository root")
+	ErrNotWorkspaceOwner    = errors.New("username does not match the workspace owner")
+)
+
+var (
+	// GitHub usernames: alphanumerics and single hyphens, no leading/trailing hyphen, max 39 chars
+	githubUsernameRe = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9]|-[a-zA-Z0-9]){0,38}$`)
+	packageNameRe    = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	packageChallRe   = regexp.MustCompile(`^challenge-\d+(-[a-z0-9]+)*$`)
+)
+
+// WorkspaceService owns every write into the repository checkout.
+// The repository root is resolved once and all paths are validated against it.
+type WorkspaceService struct {
+	root  string
+	owner string
+}
+
+// NewWorkspaceService resolves the repository root and the workspace owner
+func NewWorkspaceService() (*WorkspaceService, error) {
+	root, err := resolveRepositoryRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	// The owner is the GitHub user this checkout belongs to. WORKSPACE_USER
+	// overrides detection for deployments without a git remote.
+	owner := strings.TrimSpace(os.Getenv("WORKSPACE_USER"))
+	if owner == "" {
+		owner = utils.GetGitUsername().Username
+	}
+	if owner != "" && !ValidGitHubUsername(owner) {
+		owner = ""
+	}
+
+	return &WorkspaceService{root: root, owner: owner}, nil
+}
+
+// resolveRepositoryRoot finds the directory holding the challenge-N and packages directories
+func resolveRepositoryRoot() (string, error) {
+	candidates := []string{}
+	if env := os.Getenv("REPOSITORY_ROOT"); env != "" {
+		candidates = append(candidates, env)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		// web-ui is normally started from its own directory, so try the parent first
+		candidates = append(candidates, filepath.Join(wd, ".."), wd)
+	}
+
+	for _, candidate := range candidates {
+		abs, err := filepath.Abs(candidate)
+		if err != nil {
+			continue
+		}
+		abs, err = filepath.EvalSymlinks(abs)
+		if err != nil {
+			continue
+		}
+		if isRepositoryRoot(abs) {
+			return abs, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not locate repository root from %v", candidates)
+}
+
+// isRepositoryRoot reports whether dir looks like the go-interview-practice checkout
+func isRepositoryRoot(dir string) bool {
+	if info, err := os.Stat(filepath.Join(dir, "packages")); err != nil || !info.IsDir() {
+		return false
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "challenge-*"))
+	return err == nil && len(matches) > 0
+}
+
+// Root returns the absolute repository root
+func (ws *WorkspaceService) Root() string {
+	return ws.root
+}
+
+// Owner returns the GitHub username allowed to write submissions, or "" if none was detected
+func (ws *WorkspaceService) Owner() string {
+	return ws.owner
+}
+
+// ValidGitHubUsername reports whether name follows GitHub's username grammar
+func ValidGitHubUsername(name string) bool {
+	return githubUsernameRe.MatchString(name)
+}
+
+// Authorize checks that username may write into its submission folders
+func (ws *WorkspaceService) Authorize(username string) error {
+	if !ValidGitHubUsername(username) {
+		return fmt.Errorf("%w: username %q", ErrInvalidPathComponent, username)
+	}
+	if ws.owner == "" || !strings.EqualFold(ws.owner, username) {
+		return ErrNotWorkspaceOwner
+	}
+	return nil
+}
+
+// SaveClassicSolution writes a classic challenge solution for username
+func (ws *WorkspaceService) SaveClassicSolution(username string, challengeID int, code string) (SaveSubmissionResponse, error) {
+	if err := ws.Authorize(username); err != nil {
+		return SaveSubmissionResponse{}, err
+	}
+	if challengeID <= 0 {
+		return SaveSubmissionResponse{}, fmt.Errorf("%w: challenge %d", ErrInvalidPathComponent, challengeID)
+	}
+
+	challengeDir := "challenge-" + strconv.Itoa(challengeID)
+	if err := ws.requireDir(challengeDir); err != nil {
+		return SaveSubmissionResponse{}, err
+	}
+
+	rel := filepath.Join(challengeDir, "submissions", username, "solution-template.go")
+	abs, err := ws.WriteFile(rel, []byte(code))
+	if err != nil {
+		return SaveSubmissionResponse{}, err
+	}
+
+	return SaveSubmissionResponse{
+		Success:  true,
+		Message:  "Solution saved to filesystem",
+		FilePath: abs,
+		GitCommands: []string{
+			"cd " + ws.root,
+			fmt.Sprintf("git add %s", filepath.ToSlash(rel)),
+			fmt.Sprintf("git commit -m \"Add solution for Challenge %d\"", challengeID),
+			"git push origin main",
+		},
+	}, nil
+}
+
+// SavePackageSolution writes a package challenge solution for username
+func (ws *WorkspaceService) SavePackageSolution(username, packageName, challengeID, code string) (SaveSubmissionResponse, error) {
+	if err := ws.Authorize(username); err != nil {
+		return SaveSubmissionResponse{}, err
+	}
+	if !packageNameRe.MatchString(packageName) {
+		return SaveSubmissionResponse{}, fmt.Errorf("%w: package %q", ErrInvalidPathComponent, packageName)
+	}
+	if !packageChallRe.MatchString(challengeID) {
+		return SaveSubmissionResponse{}, fmt.Errorf("%w: challenge %q", ErrInvalidPathComponent, challengeID)
+	}
+
+	challengeDir := filepath.Join("packages", packageName, challengeID)
+	if err := ws.requireDir(challengeDir); err != nil {
+		return SaveSubmissionResponse{}, err
+	}
+
+	rel := filepath.Join(challengeDir, "submissions", username, "solution.go")
+	abs, err := ws.WriteFile(rel, []byte(code))
+	if err != nil {
+		return SaveSubmissionResponse{}, err
+	}
+
+	return SaveSubmissionResponse{
+		Success:  true,
+		Message:  "Solution saved to filesystem",
+		FilePath: abs,
+		GitCommands: []string{
+			"cd " + ws.root,
+			fmt.Sprintf("git add %s", filepath.ToSlash(rel)),
+			fmt.Sprintf("git commit -m \"Add solution for %s %s by %s\"", packageName, challengeID, username),
+			"git push origin main",
+		},
+	}, nil
+}
+
+// resolve joins rel onto the root and rejects anything that ends up outside it
+func (ws *WorkspaceService) resolve(rel string) (string, error) {
+	if filepath.IsAbs(rel) {
+		return "", ErrOutsideWorkspace
+	}
+	abs := filepath.Join(ws.root, rel)
+	if !ws.contains(abs) {
+		return "", ErrOutsideWorkspace
+	}
+	return abs, nil
+}
+
+// contains reports whether abs is the root or lies beneath it
+func (ws *WorkspaceService) contains(abs string) bool {
+	r, err := filepath.Rel(ws.root, abs)
+	if err != nil {
+		return false
+	}
+	return r != ".." && !strings.HasPrefix(r, ".."+string(filepath.Separator))
+}
+
+// containsReal reports whether the deepest existing ancestor of abs, with symlinks
+// resolved, lies within the root
+func (ws *WorkspaceService) containsReal(abs string) bool {
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return ws.contains(real)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
+}
+
+// requireDir checks that rel is an existing challenge directory inside the root
+func (ws *WorkspaceService) requireDir(rel string) error {
+	abs, err := ws.resolve(rel)
+	if err != nil {
+		return err
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownChallenge, filepath.ToSlash(rel))
+	}
+	if !ws.contains(real) {
+		return ErrOutsideWorkspace
+	}
+	info, err := os.Stat(filepath.Join(real, "solution-template.go"))
+	if err != nil || info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrUnknownChallenge, filepath.ToSlash(rel))
+	}
+	return nil
+}
+
+// WriteFile atomically replaces rel (relative to the root) with data via a temp file and rename
+func (ws *WorkspaceService) WriteFile(rel string, data []byte) (string, error) {
+	abs, err := ws.resolve(rel)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(abs)
+	// Check before creating anything, so a symlinked folder cannot make us create directories elsewhere
+	if !ws.containsReal(dir) {
+		return "", ErrOutsideWorkspace
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create submission directory: %v", err)
+	}
+
+	// A symlinked submissions folder could still point elsewhere; check the real location
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve submission directory: %v", err)
+	}
+	if !ws.contains(realDir) {
+		return "", ErrOutsideWorkspace
+	}
+	target := filepath.Join(realDir, filepath.Base(abs))
+
+	tmp, err := os.CreateTemp(realDir, ".tmp-"+filepath.Base(abs)+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once the rename succeeded
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write solution: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to flush solution: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close solution: %v", err)
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		return "", fmt.Errorf("failed to set permissions: %v", err)
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return "", fmt.Errorf("failed to move solution into place: %v", err)
+	}
+
+	return target, nil
+}
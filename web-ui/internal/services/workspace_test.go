@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestWorkspace lays out a minimal checkout with challenge-1 and packages/gin/challenge-1-basic-routing
+func newTestWorkspace(t *testing.T) *WorkspaceService {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{
+		"challenge-1",
+		filepath.Join("packages", "gin", "challenge-1-basic-routing"),
+	} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "solution-template.go"), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &WorkspaceService{root: root, owner: "alice"}
+}
+
+func TestWorkspaceResolve(t *testing.T) {
+	ws := newTestWorkspace(t)
+
+	tests := []struct {
+		name string
+		rel  string
+		err  error
+	}{
+		{"plain", "challenge-1/submissions/alice/solution-template.go", nil},
+		{"inner dot-dot", "challenge-1/../challenge-1/solution-template.go", nil},
+		{"parent", "..", ErrOutsideWorkspace},
+		{"dot-dot prefix", "../outside/solution.go", ErrOutsideWorkspace},
+		{"dot-dot inside", "challenge-1/../../outside/solution.go", ErrOutsideWorkspace},
+		{"absolute", "/etc/passwd", ErrOutsideWorkspace},
+		{"absolute inside root", filepath.Join(ws.Root(), "challenge-1"), ErrOutsideWorkspace},
+		{"dot-dot named file", "challenge-1/..solution.go", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			abs, err := ws.resolve(tt.rel)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("resolve(%q) error = %v, want %v", tt.rel, err, tt.err)
+			}
+			if err == nil && !ws.contains(abs) {
+				t.Fatalf("resolve(%q) = %q, outside %q", tt.rel, abs, ws.Root())
+			}
+		})
+	}
+}
+
+func TestWorkspaceAuthorize(t *testing.T) {
+	ws := newTestWorkspace(t)
+
+	tests := []struct {
+		name     string
+		username string
+		err      error
+	}{
+		{"owner", "alice", nil},
+		{"owner other case", "Alice", nil},
+		{"other user", "bob", ErrNotWorkspaceOwner},
+		{"empty", "", ErrInvalidPathComponent},
+		{"dot-dot", "..", ErrInvalidPathComponent},
+		{"slash", "alice/../bob", ErrInvalidPathComponent},
+		{"leading hyphen", "-alice", ErrInvalidPathComponent},
+		{"trailing hyphen", "alice-", ErrInvalidPathComponent},
+		{"double hyphen", "al--ice", ErrInvalidPathComponent},
+		{"too long", "a123456789012345678901234567890123456789", ErrInvalidPathComponent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ws.Authorize(tt.username); !errors.Is(err, tt.err) {
+				t.Fatalf("Authorize(%q) = %v, want %v", tt.username, err, tt.err)
+			}
+		})
+	}
+
+	noOwner := &WorkspaceService{root: ws.Root()}
+	if err := noOwner.Authorize("alice"); !errors.Is(err, ErrNotWorkspaceOwner) {
+		t.Fatalf("Authorize without an owner = %v, want %v", err, ErrNotWorkspaceOwner)
+	}
+}
+
+func TestWorkspaceSaveSolution(t *testing.T) {
+	ws := newTestWorkspace(t)
+
+	tests := []struct {
+		name string
+		save func() error
+		err  error
+	}{
+		{"classic", func() error {
+			_, err := ws.SaveClassicSolution("alice", 1, "package main")
+			return err
+		}, nil},
+		{"package", func() error {
+			_, err := ws.SavePackageSolution("alice", "gin", "challenge-1-basic-routing", "package main")
+			return err
+		}, nil},
+		{"other user", func() error {
+			_, err := ws.SaveClassicSolution("bob", 1, "package main")
+			return err
+		}, ErrNotWorkspaceOwner},
+		{"unknown challenge", func() error {
+			_, err := ws.SaveClassicSolution("alice", 2, "package main")
+			return err
+		}, ErrUnknownChallenge},
+		{"invalid challenge", func() error {
+			_, err := ws.SaveClassicSolution("alice", -1, "package main")
+			return err
+		}, ErrInvalidPathComponent},
+		{"package dot-dot", func() error {
+			_, err := ws.SavePackageSolution("alice", "..", "challenge-1-basic-routing", "package main")
+			return err
+		}, ErrInvalidPathComponent},
+		{"package challenge dot-dot", func() error {
+			_, err := ws.SavePackageSolution("alice", "gin", "../../challenge-1", "package main")
+			return err
+		}, ErrInvalidPathComponent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.save(); !errors.Is(err, tt.err) {
+				t.Fatalf("save error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	content, err := os.ReadFile(filepath.Join(ws.Root(), "challenge-1", "submissions", "alice", "solution-template.go"))
+	if err != nil || string(content) != "package main" {
+		t.Fatalf("saved solution = %q, %v", content, err)
+	}
+}
+
+func TestWorkspaceWriteFileSymlinkEscape(t *testing.T) {
+	ws := newTestWorkspace(t)
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// challenge-1/submissions points outside the checkout
+	if err := os.Symlink(outside, filepath.Join(ws.Root(), "challenge-1", "submissions")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		rel  string
+	}{
+		{"new user folder", "challenge-1/submissions/alice/solution-template.go"},
+		{"file in folder", "challenge-1/submissions/solution-template.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ws.WriteFile(tt.rel, []byte("package main")); !errors.Is(err, ErrOutsideWorkspace) {
+				t.Fatalf("WriteFile(%q) = %v, want %v", tt.rel, err, ErrOutsideWorkspace)
+			}
+		})
+	}
+
+	if _, err := ws.SaveClassicSolution("alice", 1, "package main"); !errors.Is(err, ErrOutsideWorkspace) {
+		t.Fatalf("SaveClassicSolution = %v, want %v", err, ErrOutsideWorkspace)
+	}
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("wrote %d entries outside the workspace", len(entries))
+	}
+}
+
+func TestWorkspaceWriteFileSymlinkedChallenge(t *testing.T) {
+	ws := newTestWorkspace(t)
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "solution-template.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A whole challenge directory linked in from elsewhere
+	if err := os.Symlink(outside, filepath.Join(ws.Root(), "challenge-2")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if _, err := ws.SaveClassicSolution("alice", 2, "package main"); !errors.Is(err, ErrOutsideWorkspace) {
+		t.Fatalf("SaveClassicSolution = %v, want %v", err, ErrOutsideWorkspace)
+	}
+}
+
+func TestWorkspaceWriteFileReplaces(t *testing.T) {
+	ws := newTestWorkspace(t)
+	rel := filepath.Join("challenge-1", "submissions", "alice", "solution-template.go")
+
+	for _, content := range []string{"first", "second"} {
+		if _, err := ws.WriteFile(rel, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := filepath.Join(ws.Root(), "challenge-1", "submissions", "alice")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("left %d files behind, want 1", len(entries))
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "solution-template.go"))
+	if string(content) != "second" {
+		t.Fatalf("content = %q, want %q", content, "second")
+	}
+}
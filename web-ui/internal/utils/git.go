@@ -4,6 +4,7 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // GitUserInfo contains extracted git user information
@@ -87,3 +88,38 @@ func IsGitRepository() bool {
 	err := cmd.Run()
 	return err == nil
 }
+
+// GetFileCommitDates returns, for each file matched by pathspecs, the date of the
+// commit that added it. Keys are slash-separated paths relative to repoRoot.
+func GetFileCommitDates(repoRoot string, pathspecs ...string) map[string]time.Time {
+	dates := make(map[string]time.Time)
+	if len(pathspecs) == 0 {
+		return dates
+	}
+
+	args := append([]string{"-C", repoRoot, "log", "--diff-filter=A", "--format=@%cI", "--name-only", "--"}, pathspecs...)
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return dates
+	}
+
+	// Output is newest first, so the last date seen for a path is when it was added
+	var current time.Time
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "@") {
+			if t, err := time.Parse(time.RFC3339, line[1:]); err == nil {
+				current = t
+			}
+			continue
+		}
+		if !current.IsZero() {
+			dates[line] = current
+		}
+	}
+
+	return dates
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"go/scanner"
+	"go/token"
+	"html"
+	"strings"
+)
+
+// Predeclared identifiers, highlighted like highlight.js does for Go
+var (
+	goTypes = map[string]bool{
+		"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+		"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+		"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+		"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	}
+	goBuiltins = map[string]bool{
+		"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true,
+		"delete": true, "imag": true, "len": true, "make": true, "max": true, "min": true, "new": true,
+		"panic": true, "print": true, "println": true, "real": true, "recover": true,
+	}
+	goLiterals = map[string]bool{"true": true, "false": true, "nil": true, "iota": true}
+)
+
+// HighlightGo returns Go source as HTML with highlight.js token classes, so code blocks
+// are highlighted without running highlight.js in the browser. Source that does not
+// scan as Go is still escaped; only the tokens that scan are colored.
+func HighlightGo(source string) string {
+	src := []byte(source)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var out strings.Builder
+	written := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		offset := file.Offset(pos)
+		if tok == token.SEMICOLON && lit == "\n" || offset < written {
+			continue // automatically inserted semicolon
+		}
+
+		class := ""
+		text := lit
+		switch {
+		case tok.IsKeyword():
+			class, text = "hljs-keyword", tok.String()
+		case tok == token.STRING || tok == token.CHAR:
+			class = "hljs-string"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "hljs-number"
+		case tok == token.COMMENT:
+			class = "hljs-comment"
+		case tok == token.IDENT && goTypes[lit]:
+			class = "hljs-type"
+		case tok == token.IDENT && goBuiltins[lit]:
+			class = "hljs-built_in"
+		case tok == token.IDENT && goLiterals[lit]:
+			class = "hljs-literal"
+		}
+		if class == "" || offset+len(text) > len(src) {
+			continue
+		}
+
+		out.WriteString(html.EscapeString(source[written:offset]))
+		out.WriteString(`<span class="` + class + `">`)
+		out.WriteString(html.EscapeString(source[offset : offset+len(text)]))
+		out.WriteString("</span>")
+		written = offset + len(text)
+	}
+	out.WriteString(html.EscapeString(source[written:]))
+	return out.String()
+}
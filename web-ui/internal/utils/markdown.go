@@ -0,0 +1,644 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownOptions changes how RenderMarkdown treats its input
+type MarkdownOptions struct {
+	HardBreaks bool // render every newline in a paragraph as <br>, as chat-style AI output expects
+}
+
+// RenderMarkdown renders CommonMark with the GitHub extensions the challenges use: tables,
+// task lists, strikethrough and bare URLs. Headings get anchors, Go code blocks are
+// highlighted, and raw HTML is sanitized and balanced, so the result is safe to insert into a page.
+func RenderMarkdown(source string) string {
+	return RenderMarkdownWithOptions(source, MarkdownOptions{})
+}
+
+// RenderMarkdownWithOptions is RenderMarkdown with options
+func RenderMarkdownWithOptions(source string, options MarkdownOptions) string {
+	r := &markdownRenderer{options: options, anchors: make(map[string]int)}
+	source = strings.Replace(strings.Replace(source, "\r\n", "\n", -1), "\r", "\n", -1)
+	source = strings.Replace(source, "\x00", "�", -1)
+
+	var out strings.Builder
+	r.renderBlocks(strings.Split(source, "\n"), &out, false)
+	return balanceTags(out.String())
+}
+
+type markdownRenderer struct {
+	options MarkdownOptions
+	anchors map[string]int // heading slugs already used, for unique anchors
+}
+
+var (
+	atxHeadingPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	thematicBreakPattern = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern         = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+	blockquotePattern    = regexp.MustCompile(`^ {0,3}> ?`)
+	listItemPattern      = regexp.MustCompile(`^( {0,3})([-+*]|\d{1,9}[.)])([ \t]+|$)`)
+	setextPattern        = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	tableDelimiterCell   = regexp.MustCompile(`^:?-+:?$`)
+	htmlBlockPattern     = regexp.MustCompile(`^ {0,3}(?:<!--|</?([a-zA-Z][a-zA-Z0-9-]*)(?:[\s/>]|$))`)
+	taskItemPattern      = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+|$)`)
+)
+
+// htmlBlockTags start an HTML block that runs to the next blank line
+var htmlBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "center": true, "details": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "figure": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"li": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true, "summary": true,
+	"table": true, "tbody": true, "td": true, "tfoot": true, "th": true, "thead": true, "tr": true, "ul": true,
+	"img": true, "picture": true, "script": true, "style": true, "iframe": true,
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indentation returns the width of a line's leading whitespace, with tabs to multiples of 4
+func indentation(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// stripIndent removes up to width columns of leading whitespace
+func stripIndent(line string, width int) string {
+	column := 0
+	for i, c := range line {
+		if column >= width {
+			return line[i:]
+		}
+		switch c {
+		case ' ':
+			column++
+		case '\t':
+			next := column + 4 - column%4
+			if next > width {
+				return strings.Repeat(" ", next-width) + line[i+1:]
+			}
+			column = next
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+// startsBlock reports whether a line starts a block that interrupts a paragraph
+func startsBlock(line string) bool {
+	if atxHeadingPattern.MatchString(line) || thematicBreakPattern.MatchString(line) ||
+		fencePattern.MatchString(line) || blockquotePattern.MatchString(line) {
+		return true
+	}
+	if m := htmlBlockPattern.FindStringSubmatch(line); m != nil && (m[1] == "" || htmlBlockTags[strings.ToLower(m[1])]) {
+		return true
+	}
+	if m := listItemPattern.FindStringSubmatch(line); m != nil {
+		// An empty item, or an ordered list not starting at 1, cannot interrupt a paragraph
+		rest := line[len(m[0]):]
+		if isBlank(rest) {
+			return false
+		}
+		if marker := m[2]; marker[0] >= '0' && marker[0] <= '9' {
+			return marker[:len(marker)-1] == "1"
+		}
+		return true
+	}
+	return false
+}
+
+// renderBlocks renders a sequence of lines as blocks. In a tight list item paragraphs
+// are not wrapped in <p>.
+func (r *markdownRenderer) renderBlocks(lines []string, out *strings.Builder, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+
+		case fencePattern.MatchString(line):
+			i = r.fencedCode(lines, i, out)
+
+		case indentation(line) >= 4:
+			i = r.indentedCode(lines, i, out)
+
+		case atxHeadingPattern.MatchString(line):
+			m := atxHeadingPattern.FindStringSubmatch(line)
+			r.heading(len(m[1]), m[2], out)
+			i++
+
+		case thematicBreakPattern.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+
+		case blockquotePattern.MatchString(line):
+			i = r.blockquote(lines, i, out)
+
+		case listItemPattern.MatchString(line):
+			i = r.list(lines, i, out)
+
+		case r.isHTMLBlock(line):
+			i = r.htmlBlock(lines, i, out)
+
+		case r.isTable(lines, i):
+			i = r.table(lines, i, out)
+
+		default:
+			i = r.paragraph(lines, i, out, tight)
+		}
+	}
+}
+
+func (r *markdownRenderer) fencedCode(lines []string, start int, out *strings.Builder) int {
+	m := fencePattern.FindStringSubmatch(lines[start])
+	indent, fence, info := len(m[1]), m[2], m[3]
+	if fence[0] == '`' && strings.Contains(info, "`") {
+		// Not a fence after all; CommonMark forbids backticks in the info string
+		return r.paragraph(lines, start, out, false)
+	}
+
+	var code []string
+	i := start + 1
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if indentation(lines[i]) < 4 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		code = append(code, stripIndent(lines[i], indent))
+	}
+
+	language := ""
+	if fields := strings.Fields(html.UnescapeString(info)); len(fields) > 0 {
+		language = strings.ToLower(fields[0])
+	}
+	r.codeBlock(strings.Join(code, "\n"), language, out)
+	return i
+}
+
+func (r *markdownRenderer) indentedCode(lines []string, start int, out *strings.Builder) int {
+	var code []string
+	i := start
+	for ; i < len(lines) && (isBlank(lines[i]) || indentation(lines[i]) >= 4); i++ {
+		code = append(code, stripIndent(lines[i], 4))
+	}
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+		i--
+	}
+	r.codeBlock(strings.Join(code, "\n"), "", out)
+	return i
+}
+
+func (r *markdownRenderer) codeBlock(code, language string, out *strings.Builder) {
+	if code != "" {
+		code += "\n"
+	}
+	switch language {
+	case "go", "golang":
+		out.WriteString(`<pre><code class="hljs language-go" data-highlighted="yes">`)
+		out.WriteString(HighlightGo(code))
+	case "":
+		out.WriteString("<pre><code>")
+		out.WriteString(html.EscapeString(code))
+	default:
+		fmt.Fprintf(out, `<pre><code class="language-%s">`, html.EscapeString(language))
+		out.WriteString(html.EscapeString(code))
+	}
+	out.WriteString("</code></pre>\n")
+}
+
+func (r *markdownRenderer) heading(level int, text string, out *strings.Builder) {
+	content := r.inline(strings.TrimSpace(text))
+	slug := r.anchor(content)
+	fmt.Fprintf(out, `<h%d id="%s">%s<a class="heading-anchor" href="#%s" aria-hidden="true">#</a></h%d>`+"\n",
+		level, slug, content, slug, level)
+}
+
+var (
+	tagPattern      = regexp.MustCompile(`<[^>]*>`)
+	slugDropPattern = regexp.MustCompile(`[^\p{L}\p{N}\s_-]`)
+)
+
+// anchor returns a unique GitHub-style slug for a rendered heading
+func (r *markdownRenderer) anchor(content string) string {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(content, ""))
+	slug := strings.ToLower(strings.TrimSpace(text))
+	slug = slugDropPattern.ReplaceAllString(slug, "")
+	slug = strings.Join(strings.Fields(slug), "-")
+	if slug == "" {
+		slug = "section"
+	}
+
+	n := r.anchors[slug]
+	r.anchors[slug] = n + 1
+	if n > 0 {
+		slug += "-" + strconv.Itoa(n)
+	}
+	return html.EscapeString(slug)
+}
+
+func (r *markdownRenderer) blockquote(lines []string, start int, out *strings.Builder) int {
+	var inner []string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := blockquotePattern.FindString(line); m != "" {
+			inner = append(inner, line[len(m):])
+			continue
+		}
+		// A lazy continuation line extends the quoted paragraph
+		if isBlank(line) || startsBlock(line) || len(inner) == 0 || isBlank(inner[len(inner)-1]) {
+			break
+		}
+		inner = append(inner, line)
+	}
+
+	out.WriteString("<blockquote>\n")
+	r.renderBlocks(inner, out, false)
+	out.WriteString("</blockquote>\n")
+	return i
+}
+
+// listItem is one item of a list with its lines, the marker and first-line indentation removed
+type listItem struct {
+	lines []string
+}
+
+// continuesList reports whether lines[i] is an item of the list with the given marker type
+func continuesList(lines []string, i int, ordered bool, delimiter string) bool {
+	if i >= len(lines) || thematicBreakPattern.MatchString(lines[i]) {
+		return false
+	}
+	m := listItemPattern.FindStringSubmatch(lines[i])
+	if m == nil {
+		return false
+	}
+	itemOrdered := m[2][0] >= '0' && m[2][0] <= '9'
+	return itemOrdered == ordered && m[2][len(m[2])-1:] == delimiter
+}
+
+func (r *markdownRenderer) list(lines []string, start int, out *strings.Builder) int {
+	first := listItemPattern.FindStringSubmatch(lines[start])
+	marker := first[2]
+	ordered := marker[0] >= '0' && marker[0] <= '9'
+	delimiter := marker[len(marker)-1:]
+
+	var items []listItem
+	loose := false
+	i := start
+	for i < len(lines) {
+		m := listItemPattern.FindStringSubmatch(lines[i])
+		if !continuesList(lines, i, ordered, delimiter) {
+			break
+		}
+
+		// Content starts after the marker and 1-4 spaces; with more, the content is
+		// indented code and only one space belongs to the marker
+		markerWidth := len(m[1]) + len(m[2])
+		spacing := indentation(strings.Repeat(" ", markerWidth)+m[3]) - markerWidth
+		rest := lines[i][len(m[0]):]
+		if spacing > 4 || isBlank(rest) {
+			spacing = 1
+		}
+		contentIndent := markerWidth + spacing
+		firstLine := stripIndent(strings.Repeat(" ", markerWidth)+m[3]+rest, contentIndent)
+		if isBlank(rest) {
+			firstLine = ""
+		}
+
+		item := listItem{lines: []string{firstLine}}
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				// A blank line ends an item that started empty
+				if len(item.lines) == 1 && item.lines[0] == "" {
+					break
+				}
+				item.lines = append(item.lines, "")
+				i++
+				continue
+			}
+			if indentation(line) >= contentIndent {
+				item.lines = append(item.lines, stripIndent(line, contentIndent))
+				i++
+				continue
+			}
+			// A lazy continuation line extends the item's paragraph
+			previous := item.lines[len(item.lines)-1]
+			if !isBlank(previous) && !startsBlock(line) && !listItemPattern.MatchString(line) && !isFenceOpen(item.lines) {
+				item.lines = append(item.lines, line)
+				i++
+				continue
+			}
+			break
+		}
+
+		// Trailing blank lines separate items, which makes the list loose
+		trailing := 0
+		for len(item.lines) > 1 && isBlank(item.lines[len(item.lines)-1]) {
+			item.lines = item.lines[:len(item.lines)-1]
+			trailing++
+		}
+		if hasInnerBlankLine(item.lines) {
+			loose = true
+		}
+		items = append(items, item)
+		if trailing > 0 {
+			if !continuesList(lines, i, ordered, delimiter) {
+				break
+			}
+			loose = true
+		}
+	}
+
+	if ordered {
+		number, _ := strconv.Atoi(marker[:len(marker)-1])
+		if number != 1 {
+			fmt.Fprintf(out, "<ol start=\"%d\">\n", number)
+		} else {
+			out.WriteString("<ol>\n")
+		}
+	} else {
+		out.WriteString("<ul>\n")
+	}
+
+	for _, item := range items {
+		content := item.lines
+		task := ""
+		if len(content) > 0 {
+			if m := taskItemPattern.FindStringSubmatch(content[0]); m != nil {
+				checked := ""
+				if m[1] != " " {
+					checked = " checked"
+				}
+				task = fmt.Sprintf(`<input type="checkbox" disabled%s> `, checked)
+				content = append([]string{content[0][len(m[0]):]}, content[1:]...)
+			}
+		}
+
+		if task != "" {
+			out.WriteString(`<li class="task-list-item">`)
+			out.WriteString(task)
+		} else {
+			out.WriteString("<li>")
+		}
+		var body strings.Builder
+		r.renderBlocks(content, &body, !loose)
+		out.WriteString(strings.TrimSuffix(body.String(), "\n"))
+		out.WriteString("</li>\n")
+	}
+
+	if ordered {
+		out.WriteString("</ol>\n")
+	} else {
+		out.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// hasInnerBlankLine reports whether a blank line separates two blocks directly inside an item.
+// Blank lines inside fenced code or nested lists do not count.
+func hasInnerBlankLine(lines []string) bool {
+	inFence := ""
+	for i, line := range lines {
+		if m := fencePattern.FindStringSubmatch(line); m != nil && indentation(line) == 0 {
+			if inFence == "" {
+				inFence = m[2][:1]
+			} else if strings.HasPrefix(strings.TrimSpace(line), inFence) {
+				inFence = ""
+			}
+			continue
+		}
+		if inFence != "" || !isBlank(line) || i == 0 || i == len(lines)-1 {
+			continue
+		}
+		if indentation(lines[i+1]) == 0 && !listItemPattern.MatchString(lines[i-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFenceOpen reports whether the lines end inside an unclosed fenced code block
+func isFenceOpen(lines []string) bool {
+	fence := ""
+	for _, line := range lines {
+		m := fencePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if fence == "" {
+			fence = m[2]
+		} else if strings.HasPrefix(strings.TrimSpace(line), fence) {
+			fence = ""
+		}
+	}
+	return fence != ""
+}
+
+func (r *markdownRenderer) isHTMLBlock(line string) bool {
+	m := htmlBlockPattern.FindStringSubmatch(line)
+	return m != nil && (m[1] == "" || htmlBlockTags[strings.ToLower(m[1])])
+}
+
+func (r *markdownRenderer) htmlBlock(lines []string, start int, out *strings.Builder) int {
+	i := start
+	for i < len(lines) && !isBlank(lines[i]) {
+		i++
+	}
+	out.WriteString(SanitizeHTML(strings.Join(lines[start:i], "\n")))
+	out.WriteString("\n")
+	return i
+}
+
+// splitTableRow splits "| a | b \| c |" into its trimmed cells
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func (r *markdownRenderer) isTable(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !strings.Contains(lines[i+1], "-") {
+		return false
+	}
+	delimiters := splitTableRow(lines[i+1])
+	for _, cell := range delimiters {
+		if !tableDelimiterCell.MatchString(cell) {
+			return false
+		}
+	}
+	return len(splitTableRow(lines[i])) == len(delimiters)
+}
+
+func (r *markdownRenderer) table(lines []string, start int, out *strings.Builder) int {
+	header := splitTableRow(lines[start])
+	var aligns []string
+	for _, cell := range splitTableRow(lines[start+1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "center")
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "right")
+		case strings.HasPrefix(cell, ":"):
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+
+	writeRow := func(cells []string, tag string) {
+		out.WriteString("<tr>\n")
+		for c := range aligns {
+			cell := ""
+			if c < len(cells) {
+				cell = cells[c]
+			}
+			if aligns[c] != "" {
+				fmt.Fprintf(out, `<%s align="%s">`, tag, aligns[c])
+			} else {
+				fmt.Fprintf(out, "<%s>", tag)
+			}
+			out.WriteString(r.inline(cell))
+			fmt.Fprintf(out, "</%s>\n", tag)
+		}
+		out.WriteString("</tr>\n")
+	}
+
+	out.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	out.WriteString("</thead>\n")
+
+	i := start + 2
+	if i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]) {
+		out.WriteString("<tbody>\n")
+		for ; i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]); i++ {
+			writeRow(splitTableRow(lines[i]), "td")
+		}
+		out.WriteString("</tbody>\n")
+	}
+	out.WriteString("</table>\n")
+	return i
+}
+
+func (r *markdownRenderer) paragraph(lines []string, start int, out *strings.Builder, tight bool) int {
+	text := []string{strings.TrimLeft(lines[start], " \t")}
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		if m := setextPattern.FindStringSubmatch(line); m != nil {
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			r.heading(level, strings.Join(text, "\n"), out)
+			return i + 1
+		}
+		if startsBlock(line) || r.isTable(lines, i) {
+			break
+		}
+		text = append(text, strings.TrimLeft(line, " \t"))
+	}
+
+	content := r.inline(strings.TrimRight(strings.Join(text, "\n"), " \t"))
+	if tight {
+		out.WriteString(content)
+		out.WriteString("\n")
+		return i
+	}
+	out.WriteString("<p>")
+	out.WriteString(content)
+	out.WriteString("</p>\n")
+	return i
+}
+
+// Hint is one section of a hints.md file, rendered
+type Hint struct {
+	Title   string `json:"title"`
+	Content string `json:"content"` // HTML
+}
+
+var hintHeadingPattern = regexp.MustCompile(`(?i)^##\s+Hint\s+\d+:?\s*(.*)$`)
+
+// ParseHints splits hints.md into its "## Hint N: title" sections and renders each one
+func ParseHints(markdown string) []Hint {
+	hints := []Hint{}
+	var title string
+	var body []string
+	inHint := false
+	flush := func() {
+		if inHint {
+			hints = append(hints, Hint{Title: title, Content: RenderMarkdown(strings.Join(body, "\n"))})
+		}
+	}
+
+	inFence := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if m := hintHeadingPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil && !inFence {
+			flush()
+			title, body, inHint = m[1], nil, true
+			continue
+		}
+		if inHint {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return hints
+}
+
+var scoreboardLinkPattern = regexp.MustCompile(`(?im)^.*\(\s*scoreboard\.md\s*\).*$\n?`)
+
+// StripScoreboardLinks removes the "[View the Scoreboard](SCOREBOARD.md)" line of a
+// challenge README; the web UI shows the scoreboard in its own tab
+func StripScoreboardLinks(markdown string) string {
+	return strings.TrimLeft(scoreboardLinkPattern.ReplaceAllString(markdown, ""), "\n")
+}
@@ -0,0 +1,386 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	entityPattern      = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	autolinkPattern    = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*)>`)
+	emailLinkPattern   = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	bareURLPattern     = regexp.MustCompile(`^https?://[^\s<]*[^\s<?!.,:*_~'")\]]`)
+	inlineTagPattern   = regexp.MustCompile(`^(?:<!--[\s\S]*?-->|</?[a-zA-Z][a-zA-Z0-9-]*(?:\s+[a-zA-Z_:][-a-zA-Z0-9_:.]*(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+))?)*\s*/?>)`)
+	linkTitlePattern   = regexp.MustCompile(`^(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'|\(((?:[^()\\]|\\.)*)\))`)
+	punctuationEscapes = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// inlineNode is a piece of rendered inline content, or a run of emphasis delimiters
+// that has not been matched yet
+type inlineNode struct {
+	html string
+
+	delimiter byte // '*', '_' or '~'; zero for content
+	count     int  // delimiters left in the run
+	original  int  // length of the run before matching
+	canOpen   bool
+	canClose  bool
+}
+
+func (n *inlineNode) render() string {
+	if n.delimiter != 0 {
+		return strings.Repeat(string(n.delimiter), n.count)
+	}
+	return n.html
+}
+
+// inline renders the inline content of a paragraph, heading or table cell
+func (r *markdownRenderer) inline(text string) string {
+	return renderInlineNodes(r.inlineNodes(text, false))
+}
+
+func renderInlineNodes(nodes []*inlineNode) string {
+	processEmphasis(&nodes)
+	var out strings.Builder
+	for _, node := range nodes {
+		out.WriteString(node.render())
+	}
+	return out.String()
+}
+
+func (r *markdownRenderer) inlineNodes(text string, inLink bool) []*inlineNode {
+	var nodes []*inlineNode
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			nodes = append(nodes, &inlineNode{html: plain.String()})
+			plain.Reset()
+		}
+	}
+	add := func(content string) {
+		flush()
+		nodes = append(nodes, &inlineNode{html: content})
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(punctuationEscapes, text[i+1]) >= 0:
+			plain.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			add("<br>\n")
+			i += 2
+
+		case c == '\n':
+			// Two trailing spaces make a hard break
+			content := strings.TrimRight(plain.String(), " ")
+			hard := plain.Len()-len(content) >= 2 || r.options.HardBreaks
+			plain.Reset()
+			plain.WriteString(content)
+			if hard {
+				add("<br>\n")
+			} else {
+				plain.WriteByte('\n')
+			}
+			i++
+			for i < len(text) && text[i] == ' ' {
+				i++
+			}
+
+		case c == '`':
+			if code, n := codeSpan(text[i:]); n > 0 {
+				add("<code>" + html.EscapeString(code) + "</code>")
+				i += n
+			} else {
+				run := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+				plain.WriteString(text[i : i+run])
+				i += run
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			run := len(text[i:]) - len(strings.TrimLeft(text[i:], string(c)))
+			if c == '~' && run != 2 {
+				plain.WriteString(text[i : i+run])
+				i += run
+				continue
+			}
+			before, _ := utf8.DecodeLastRuneInString(text[:i])
+			if i == 0 {
+				before = ' '
+			}
+			after, _ := utf8.DecodeRuneInString(text[i+run:])
+			if i+run >= len(text) {
+				after = ' '
+			}
+			left := !unicode.IsSpace(after) && (!isPunctuation(after) || unicode.IsSpace(before) || isPunctuation(before))
+			right := !unicode.IsSpace(before) && (!isPunctuation(before) || unicode.IsSpace(after) || isPunctuation(after))
+			node := &inlineNode{delimiter: c, count: run, original: run, canOpen: left, canClose: right}
+			if c == '_' {
+				node.canOpen = left && (!right || isPunctuation(before))
+				node.canClose = right && (!left || isPunctuation(after))
+			}
+			flush()
+			nodes = append(nodes, node)
+			i += run
+
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if label, url, title, n := linkAt(text[i+1:]); n > 0 {
+				alt := html.UnescapeString(tagPattern.ReplaceAllString(r.inline(label), ""))
+				image := `<img src="` + html.EscapeString(safeURL(url)) + `" alt="` + html.EscapeString(alt) + `"`
+				if title != "" {
+					image += ` title="` + html.EscapeString(title) + `"`
+				}
+				add(image + ">")
+				i += 1 + n
+			} else {
+				plain.WriteByte('!')
+				i++
+			}
+
+		case c == '[' && !inLink:
+			if label, url, title, n := linkAt(text[i:]); n > 0 {
+				link := `<a href="` + html.EscapeString(safeURL(url)) + `"`
+				if title != "" {
+					link += ` title="` + html.EscapeString(title) + `"`
+				}
+				if isExternal(url) {
+					link += ` target="_blank" rel="noopener noreferrer"`
+				}
+				add(link + ">" + renderInlineNodes(r.inlineNodes(label, true)) + "</a>")
+				i += n
+			} else {
+				plain.WriteByte('[')
+				i++
+			}
+
+		case c == '<':
+			if m := autolinkPattern.FindStringSubmatch(text[i:]); m != nil {
+				add(`<a href="` + html.EscapeString(safeURL(m[1])) + `" target="_blank" rel="noopener noreferrer">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := emailLinkPattern.FindStringSubmatch(text[i:]); m != nil {
+				add(`<a href="mailto:` + html.EscapeString(m[1]) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := inlineTagPattern.FindString(text[i:]); m != "" {
+				m = withDroppedContent(text[i:], m)
+				add(SanitizeHTML(m))
+				i += len(m)
+			} else {
+				plain.WriteString("&lt;")
+				i++
+			}
+
+		case c == 'h' && !inLink && bareURLPattern.MatchString(text[i:]) && (i == 0 || !isWordByte(text[i-1])):
+			url := trimURLParens(bareURLPattern.FindString(text[i:]))
+			add(`<a href="` + html.EscapeString(url) + `" target="_blank" rel="noopener noreferrer">` + html.EscapeString(url) + "</a>")
+			i += len(url)
+
+		case c == '&':
+			if entity := entityPattern.FindString(text[i:]); entity != "" && html.UnescapeString(entity) != entity {
+				plain.WriteString(entity)
+				i += len(entity)
+			} else {
+				plain.WriteString("&amp;")
+				i++
+			}
+
+		default:
+			plain.WriteString(html.EscapeString(text[i : i+1]))
+			i++
+		}
+	}
+	flush()
+	return nodes
+}
+
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// trimURLParens drops closing parentheses a bare URL does not open, as in "(see https://x.y)"
+func trimURLParens(url string) string {
+	for strings.HasSuffix(url, ")") && strings.Count(url, ")") > strings.Count(url, "(") {
+		url = url[:len(url)-1]
+	}
+	return url
+}
+
+// codeSpan matches a code span at the start of text and returns its content and length
+func codeSpan(text string) (string, int) {
+	run := len(text) - len(strings.TrimLeft(text, "`"))
+	fence := text[:run]
+	for i := run; i < len(text); {
+		j := strings.Index(text[i:], fence)
+		if j < 0 {
+			return "", 0
+		}
+		j += i
+		end := j + run
+		if end < len(text) && text[end] == '`' {
+			// A longer run of backticks does not close the span
+			i = end + len(text[end:]) - len(strings.TrimLeft(text[end:], "`"))
+			continue
+		}
+		code := strings.Replace(text[run:j], "\n", " ", -1)
+		if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		return code, end
+	}
+	return "", 0
+}
+
+// linkAt matches "[label](url "title")" at the start of text
+func linkAt(text string) (label, url, title string, n int) {
+	depth := 0
+	closing := -1
+	for i := 0; i < len(text) && closing < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '`':
+			if _, span := codeSpan(text[i:]); span > 0 {
+				i += span - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+	if closing < 0 || closing+1 >= len(text) || text[closing+1] != '(' {
+		return "", "", "", 0
+	}
+	label = text[1:closing]
+
+	rest := text[closing+2:]
+	i := len(rest) - len(strings.TrimLeft(rest, " \t\n"))
+	if i < len(rest) && rest[i] == '<' {
+		end := strings.IndexAny(rest[i:], ">\n")
+		if end < 0 || rest[i+end] != '>' {
+			return "", "", "", 0
+		}
+		url = rest[i+1 : i+end]
+		i += end + 1
+	} else {
+		start, parens := i, 0
+		for ; i < len(rest); i++ {
+			c := rest[i]
+			if c == '\\' && i+1 < len(rest) {
+				i++
+				continue
+			}
+			if c == '(' {
+				parens++
+			} else if c == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			} else if c == ' ' || c == '\t' || c == '\n' {
+				break
+			}
+		}
+		url = rest[start:i]
+	}
+
+	spaces := len(rest[i:]) - len(strings.TrimLeft(rest[i:], " \t\n"))
+	if m := linkTitlePattern.FindStringSubmatch(rest[i+spaces:]); m != nil && spaces > 0 {
+		title = m[1] + m[2] + m[3]
+		i += spaces + len(m[0])
+	}
+	i += len(rest[i:]) - len(strings.TrimLeft(rest[i:], " \t\n"))
+	if i >= len(rest) || rest[i] != ')' {
+		return "", "", "", 0
+	}
+	return label, unescapeBackslashes(html.UnescapeString(url)), unescapeBackslashes(html.UnescapeString(title)), closing + 2 + i + 1
+}
+
+func unescapeBackslashes(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && strings.IndexByte(punctuationEscapes, text[i+1]) >= 0 {
+			i++
+		}
+		out.WriteByte(text[i])
+	}
+	return out.String()
+}
+
+// isExternal reports whether a link leaves the site and so opens in a new tab
+func isExternal(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// processEmphasis matches delimiter runs into <em>, <strong> and <del>, following the
+// CommonMark rules: closers are matched left to right with the nearest compatible opener
+func processEmphasis(nodes *[]*inlineNode) {
+	list := *nodes
+	for closer := 0; closer < len(list); closer++ {
+		c := list[closer]
+		if c.delimiter == 0 || !c.canClose || c.count == 0 {
+			continue
+		}
+
+		opener := -1
+		for i := closer - 1; i >= 0; i-- {
+			o := list[i]
+			if o.delimiter != c.delimiter || !o.canOpen || o.count == 0 {
+				continue
+			}
+			if c.delimiter == '~' && o.count != c.count {
+				continue
+			}
+			// The "rule of 3" keeps *foo**bar* from matching inside out
+			if c.delimiter != '~' && (o.canClose || c.canOpen) && (o.original+c.original)%3 == 0 &&
+				!(o.original%3 == 0 && c.original%3 == 0) {
+				continue
+			}
+			opener = i
+			break
+		}
+		if opener < 0 {
+			continue
+		}
+
+		o := list[opener]
+		used, tag := 1, "em"
+		switch {
+		case c.delimiter == '~':
+			used, tag = c.count, "del"
+		case o.count >= 2 && c.count >= 2:
+			used, tag = 2, "strong"
+		}
+
+		var inner strings.Builder
+		for _, node := range list[opener+1 : closer] {
+			inner.WriteString(node.render())
+		}
+		wrapped := &inlineNode{html: "<" + tag + ">" + inner.String() + "</" + tag + ">"}
+		o.count -= used
+		c.count -= used
+
+		replaced := append([]*inlineNode{}, list[:opener+1]...)
+		replaced = append(replaced, wrapped)
+		next := len(replaced)
+		replaced = append(replaced, list[closer:]...)
+		list = replaced
+		closer = next
+		if c.count > 0 {
+			closer-- // the closer may match another opener
+		}
+	}
+	*nodes = list
+}
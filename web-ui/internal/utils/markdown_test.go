@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownSanitizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "javascript link",
+			input:   "[click](javascript:alert(1))",
+			want:    []string{`href="#"`},
+			notWant: []string{"javascript:"},
+		},
+		{
+			name:    "data image",
+			input:   "![x](data:image/svg+xml;base64,PHN2Zz4=)",
+			want:    []string{`src="#"`},
+			notWant: []string{"data:"},
+		},
+		{
+			name:    "javascript autolink",
+			input:   "<javascript:alert(1)>",
+			notWant: []string{`href="javascript:`},
+		},
+		{
+			name:    "inline event handler",
+			input:   `Some <span onmouseover="alert(1)">text</span>`,
+			want:    []string{"<span>text</span>"},
+			notWant: []string{"onmouseover"},
+		},
+		{
+			name:    "html block event handler",
+			input:   `<div align="center" onclick="alert(1)">x</div>`,
+			want:    []string{`<div align="center">x</div>`},
+			notWant: []string{"onclick"},
+		},
+		{
+			name:    "script in a table",
+			input:   "| a | b |\n|---|---|\n| <script>alert(1)</script> | <style>*{}</style> |\n",
+			want:    []string{"<table>", "</table>"},
+			notWant: []string{"<script", "alert(1)", "<style", "*{}"},
+		},
+		{
+			name:    "script in a nested list",
+			input:   "- one\n  - two <script>alert(1)</script>\n    - three <style>body{display:none}</style>\n",
+			want:    []string{"two", "three"},
+			notWant: []string{"<script", "alert(1)", "<style", "display:none"},
+		},
+		{
+			name:    "script block in a nested list",
+			input:   "- one\n  - two\n\n    <script>\n    alert(1)\n    </script>\n",
+			notWant: []string{"<script", "alert(1)"},
+		},
+		{
+			name:    "stray closing tag",
+			input:   "# Title\n\nSome text</div>\n\n</div>\n\nMore text\n",
+			want:    []string{"Some text", "More text"},
+			notWant: []string{"</div>"},
+		},
+		{
+			name:  "stray closing tag in a table",
+			input: "| a |\n|---|\n| x</td></tr></table> |\n",
+			want:  []string{"</table>"},
+		},
+		{
+			name:  "block wrapping markdown",
+			input: "<div align=\"center\">\n\n# Title\n\n</div>\n",
+			want:  []string{`<div align="center">`, "</h1>", "</div>"},
+		},
+		{
+			name:  "unclosed block",
+			input: "<details>\n<summary>Hint</summary>\n\nUse a map.\n",
+			want:  []string{"</details>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderMarkdown(tt.input)
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("missing %q in\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("unexpected %q in\n%s", s, got)
+				}
+			}
+			if balanced := balanceTags(got); balanced != got {
+				t.Errorf("output is not balanced:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Tags allowed in sanitized HTML, with the attributes each may keep
+var allowedTags = map[string][]string{
+	"a": {"href", "title"}, "abbr": {"title"}, "b": nil, "blockquote": nil, "br": nil,
+	"center": nil, "code": nil, "dd": nil, "del": nil, "details": {"open"}, "div": {"align"},
+	"dl": nil, "dt": nil, "em": nil, "h1": {"align"}, "h2": {"align"}, "h3": {"align"},
+	"h4": {"align"}, "h5": {"align"}, "h6": {"align"}, "hr": nil, "i": nil,
+	"img": {"src", "alt", "title", "width", "height", "align"}, "kbd": nil, "li": nil,
+	"ol": {"start"}, "p": {"align"}, "pre": nil, "s": nil, "small": nil, "span": nil,
+	"strong": nil, "sub": nil, "summary": nil, "sup": nil, "table": nil, "tbody": nil,
+	"td": {"align", "colspan", "rowspan"}, "th": {"align", "colspan", "rowspan"}, "thead": nil,
+	"tr": nil, "u": nil, "ul": nil,
+}
+
+// Tags removed together with their content
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "textarea": true, "title": true, "frameset": true,
+}
+
+// Closing tag of each dropped tag, which ends the content removed with it
+var droppedClosingPatterns = func() map[string]*regexp.Regexp {
+	patterns := make(map[string]*regexp.Regexp, len(droppedTags))
+	for name := range droppedTags {
+		patterns[name] = regexp.MustCompile(`(?i)</` + name + `\s*>`)
+	}
+	return patterns
+}()
+
+var (
+	sanitizeTagPattern = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9-]*)((?:\s+[a-zA-Z_:][-a-zA-Z0-9_:.]*(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+))?)*)\s*(/?)>`)
+	attributePattern   = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+)))?`)
+	safeSchemePattern  = regexp.MustCompile(`(?i)^(?:https?|mailto):`)
+	schemePattern      = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	balanceTagPattern  = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)[^>]*?(/?)>`)
+)
+
+// Tags that never take a closing tag
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "input": true, "wbr": true}
+
+// SanitizeHTML keeps the allowed tags and attributes of an HTML fragment and escapes
+// everything else, so that it renders as text. Scripts, styles and frames are dropped
+// with their content, as are comments.
+func SanitizeHTML(fragment string) string {
+	var out strings.Builder
+	for i := 0; i < len(fragment); {
+		c := fragment[i]
+		switch {
+		case strings.HasPrefix(fragment[i:], "<!--"):
+			end := strings.Index(fragment[i+4:], "-->")
+			if end < 0 {
+				return out.String()
+			}
+			i += 4 + end + 3
+
+		case c == '<':
+			m := sanitizeTagPattern.FindStringSubmatch(fragment[i:])
+			if m == nil {
+				out.WriteString("&lt;")
+				i++
+				continue
+			}
+			name := strings.ToLower(m[2])
+			i += len(m[0])
+
+			if droppedTags[name] {
+				if m[1] == "" && m[4] == "" {
+					closing := droppedClosingPatterns[name].FindStringIndex(fragment[i:])
+					if closing == nil {
+						return out.String()
+					}
+					i += closing[1]
+				}
+				continue
+			}
+
+			allowed, ok := allowedTags[name]
+			if !ok {
+				out.WriteString(html.EscapeString(m[0]))
+				continue
+			}
+			if m[1] == "/" {
+				out.WriteString("</" + name + ">")
+				continue
+			}
+
+			out.WriteString("<" + name)
+			for _, attr := range attributePattern.FindAllStringSubmatch(m[3], -1) {
+				key := strings.ToLower(attr[1])
+				if !containsAttribute(allowed, key) {
+					continue
+				}
+				value := html.UnescapeString(attr[2] + attr[3] + attr[4])
+				if key == "href" || key == "src" {
+					value = safeURL(value)
+				}
+				out.WriteString(" " + key + `="` + html.EscapeString(value) + `"`)
+			}
+			if name == "a" {
+				out.WriteString(` rel="noopener noreferrer"`)
+			}
+			out.WriteString(">")
+
+		case c == '&':
+			if entity := entityPattern.FindString(fragment[i:]); entity != "" {
+				out.WriteString(entity)
+				i += len(entity)
+			} else {
+				out.WriteString("&amp;")
+				i++
+			}
+
+		case c == '>':
+			out.WriteString("&gt;")
+			i++
+
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// balanceTags closes the tags a rendered document leaves open and drops closing tags
+// that match nothing, so that a stray </div> in a README cannot close the page layout
+// around it. Closing a tag also closes the tags opened inside it. The document must
+// already be sanitized: text is escaped, so every < starts a tag.
+func balanceTags(document string) string {
+	var out strings.Builder
+	var open []string
+	last := 0
+	for _, m := range balanceTagPattern.FindAllStringSubmatchIndex(document, -1) {
+		out.WriteString(document[last:m[0]])
+		last = m[1]
+		tag := document[m[0]:m[1]]
+		name := strings.ToLower(document[m[4]:m[5]])
+
+		switch {
+		case voidTags[name] || m[7] > m[6]:
+			out.WriteString(tag)
+		case m[3] == m[2]:
+			open = append(open, name)
+			out.WriteString(tag)
+		default:
+			depth := len(open) - 1
+			for depth >= 0 && open[depth] != name {
+				depth--
+			}
+			if depth < 0 {
+				continue
+			}
+			for len(open) > depth {
+				out.WriteString("</" + open[len(open)-1] + ">")
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	out.WriteString(document[last:])
+	for len(open) > 0 {
+		out.WriteString("</" + open[len(open)-1] + ">")
+		open = open[:len(open)-1]
+	}
+	return out.String()
+}
+
+// withDroppedContent extends tag, found at the start of text, to the end of its element
+// when it opens a script, style or other tag that is dropped with its content
+func withDroppedContent(text, tag string) string {
+	m := sanitizeTagPattern.FindStringSubmatch(tag)
+	if m == nil || m[1] != "" || m[4] != "" || !droppedTags[strings.ToLower(m[2])] {
+		return tag
+	}
+	closing := droppedClosingPatterns[strings.ToLower(m[2])].FindStringIndex(text[len(tag):])
+	if closing == nil {
+		return text
+	}
+	return text[:len(tag)+closing[1]]
+}
+
+func containsAttribute(allowed []string, name string) bool {
+	for _, a := range allowed {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// safeURL returns url if it is relative or uses http, https or mailto, and "#" otherwise,
+// which defuses javascript: and data: links
+func safeURL(url string) string {
+	trimmed := strings.TrimSpace(url)
+	// Browsers ignore control characters and whitespace inside the scheme
+	compact := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, trimmed)
+	if schemePattern.MatchString(compact) && !safeSchemePattern.MatchString(compact) {
+		return "#"
+	}
+	return trimmed
+}
@@ -0,0 +1,65 @@
+package utils
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"allowed tag", `<b>bold</b>`, `<b>bold</b>`},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, `<a href="#" rel="noopener noreferrer">x</a>`},
+		{"javascript href mixed case", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a href="#" rel="noopener noreferrer">x</a>`},
+		{"javascript href split by control characters", "<a href=\"java\tscript:alert(1)\">x</a>", `<a href="#" rel="noopener noreferrer">x</a>`},
+		{"javascript href entity encoded", `<a href="&#106;avascript:alert(1)">x</a>`, `<a href="#" rel="noopener noreferrer">x</a>`},
+		{"data src", `<img src="data:text/html;base64,PHNjcmlwdD4=">`, `<img src="#">`},
+		{"vbscript src", `<img src=vbscript:msgbox(1)>`, `<img src="#">`},
+		{"https href", `<a href="https://go.dev">go</a>`, `<a href="https://go.dev" rel="noopener noreferrer">go</a>`},
+		{"relative src", `<img src="images/diagram.png" alt="diagram">`, `<img src="images/diagram.png" alt="diagram">`},
+		{"onclick", `<div onclick="alert(1)" align="center">x</div>`, `<div align="center">x</div>`},
+		{"onerror unquoted", `<img src=x.png onerror=alert(1)>`, `<img src="x.png">`},
+		{"style attribute", `<p style="position:fixed">x</p>`, `<p>x</p>`},
+		{"script with content", `a<script>alert(1)</script>b`, `ab`},
+		{"style with content", `a<style>body{display:none}</style>b`, `ab`},
+		{"unclosed script", `a<script>alert(1)`, `a`},
+		{"script in another case", "a<SCRIPT>alert(1)</Script >b", `ab`},
+		{"iframe", `<iframe src="https://example.com"></iframe>`, ``},
+		{"unknown tag escaped", `<form action="/x">`, `&lt;form action=&#34;/x&#34;&gt;`},
+		{"comment", `a<!-- <script>alert(1)</script> -->b`, `ab`},
+		{"attribute breaking out", `<a title='"><script>alert(1)</script>'>x</a>`, `<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;" rel="noopener noreferrer">x</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeHTML(tt.input); got != tt.want {
+				t.Fatalf("SanitizeHTML(%q)\n got %q\nwant %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBalanceTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"balanced", `<div><p>x</p></div>`, `<div><p>x</p></div>`},
+		{"stray closing tag", `<p>x</p></div><p>y</p>`, `<p>x</p><p>y</p>`},
+		{"unclosed tag", `<div><b>x`, `<div><b>x</b></div>`},
+		{"closing an outer tag", `<div><b>x</div>y`, `<div><b>x</b></div>y`},
+		{"misnested", `<b><i>x</b></i>`, `<b><i>x</i></b>`},
+		{"void tags", `<p>a<br>b<hr><img src="x.png"><br /></p>`, `<p>a<br>b<hr><img src="x.png"><br /></p>`},
+		{"case insensitive", `<DIV>x</div>`, `<DIV>x</div>`},
+		{"escaped text", `&lt;/div&gt;`, `&lt;/div&gt;`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := balanceTags(tt.input); got != tt.want {
+				t.Fatalf("balanceTags(%q)\n got %q\nwant %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
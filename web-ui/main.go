@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 
+	"web-ui/internal/handlers"
 	"web-ui/internal/server"
 	"web-ui/internal/services"
 )
@@ -20,13 +21,28 @@ func main() {
 	// Load environment variables from .env file
 	loadEnvFile()
 
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
 	// Initialize services
 	challengeService := services.NewChallengeService()
 	scoreboardService := services.NewScoreboardService()
 	userService := services.NewUserService()
 	executionService := services.NewExecutionService()
 	packageService := services.NewPackageService()
-	aiService := services.NewAIService()
+	referenceService := services.NewReferenceService(executionService)
+	aiService := services.NewAIService(services.NewAIUsageService(), executionService, referenceService)
+
+	workspaceService, err := services.NewWorkspaceService()
+	if err != nil {
+		log.Fatalf("Failed to resolve workspace: %v", err)
+	}
+	if owner := workspaceService.Owner(); owner != "" {
+		log.Printf("Workspace %s owned by %s", workspaceService.Root(), owner)
+	} else {
+		log.Printf("Workspace %s has no detected owner; saving solutions is disabled", workspaceService.Root())
+	}
 
 	// Load data
 	log.Println("Loading challenges...")
@@ -44,16 +60,75 @@ func main() {
 		log.Fatalf("Failed to load packages: %v", err)
 	}
 
-	// Initialize server
-	srv := server.NewServer(
-		content,
+	searchService := services.NewSearchService(challengeService, packageService)
+	log.Println("Building search index...")
+	searchService.Rebuild()
+
+	leaderboardService := services.NewLeaderboardService(challengeService, packageService)
+	profileService := services.NewProfileService(
+		challengeService,
+		userService,
+		packageService,
+		leaderboardService,
+		workspaceService.Root(),
+	)
+
+	teamService := services.NewTeamService(
+		workspaceService,
 		challengeService,
-		scoreboardService,
 		userService,
-		executionService,
 		packageService,
-		aiService,
+		leaderboardService,
 	)
+	log.Println("Loading teams...")
+	if err := teamService.LoadTeams(); err != nil {
+		log.Fatalf("Failed to load teams: %v", err)
+	}
+
+	cohortService := services.NewCohortService(
+		workspaceService,
+		challengeService,
+		userService,
+		packageService,
+		leaderboardService,
+	)
+	log.Println("Loading cohorts...")
+	if err := cohortService.LoadCohorts(); err != nil {
+		log.Fatalf("Failed to load cohorts: %v", err)
+	}
+
+	trackService := services.NewTrackService(workspaceService, challengeService, leaderboardService)
+	log.Println("Loading learning tracks...")
+	if err := trackService.LoadTracks(); err != nil {
+		log.Fatalf("Failed to load learning tracks: %v", err)
+	}
+
+	interviewService := services.NewInterviewService(workspaceService, challengeService, aiService)
+	authoringService := services.NewAuthoringService(workspaceService, aiService, executionService)
+	log.Println("Loading interview sessions...")
+	if err := interviewService.LoadInterviews(); err != nil {
+		log.Fatalf("Failed to load interview sessions: %v", err)
+	}
+
+	// Initialize server
+	srv := server.NewServer(content, handlers.Services{
+		ChallengeService:   challengeService,
+		ScoreboardService:  scoreboardService,
+		UserService:        userService,
+		ExecutionService:   executionService,
+		PackageService:     packageService,
+		AIService:          aiService,
+		WorkspaceService:   workspaceService,
+		LeaderboardService: leaderboardService,
+		ProfileService:     profileService,
+		TeamService:        teamService,
+		CohortService:      cohortService,
+		InterviewService:   interviewService,
+		AuthoringService:   authoringService,
+		SearchService:      searchService,
+		TrackService:       trackService,
+		ReferenceService:   referenceService,
+	})
 
 	// Setup routes
 	mux := srv.SetupRoutes()
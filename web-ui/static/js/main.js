@@ -360,9 +360,10 @@ class LearningHighlighter {
     }
 }
 
-// Initialize syntax highlighting for code blocks
-function initSyntaxHighlighting() {
-    document.querySelectorAll('pre code').forEach((el) => {
+// Initialize syntax highlighting for code blocks. Go blocks in rendered Markdown
+// come highlighted from the server and are marked with data-highlighted.
+function initSyntaxHighlighting(root = document) {
+    root.querySelectorAll('pre code:not([data-highlighted])').forEach((el) => {
         // Fix for Go language blocks
         if (el.className === 'language-go') {
             el.className = 'language-golang'; // Convert 'go' to 'golang' for better highlighting
@@ -371,70 +372,82 @@ function initSyntaxHighlighting() {
     });
 }
 
-// Initialize Markdown parsing
-function renderMarkdown(markdownText, targetElement) {
-    if (!markdownText || !targetElement) return;
-    
-    // Set options for the markdown parser
-    marked.setOptions({
-        gfm: true,      // GitHub flavored markdown
-        breaks: true,   // Line breaks are rendered
-        highlight: function(code, lang) {
-            // Normalize language name - convert 'go' to 'golang' if needed
-            let language = lang;
-            if (lang === 'go') {
-                language = 'golang';
-            } else {
-                language = hljs.getLanguage(lang) ? lang : 'plaintext';
-            }
-            return hljs.highlight(code, { language }).value;
-        },
-        langPrefix: 'language-'  // Add proper language prefix for CSS
-    });
-    
-    // Parse and render markdown
-    targetElement.innerHTML = marked.parse(markdownText);
-    
-    // Additionally, ensure Go code blocks are properly highlighted
-    targetElement.querySelectorAll('pre code.language-go').forEach((el) => {
-        el.className = 'language-golang';
-        hljs.highlightElement(el);
-    });
+// Render Markdown on the server (POST /api/markdown), which also sanitizes it.
+// Resolves to the HTML; breaks renders every newline as a line break.
+function fetchMarkdown(markdownText, breaks = false) {
+    return fetch('/api/markdown', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ markdown: markdownText, breaks })
+    })
+        .then(response => {
+            if (!response.ok) throw new Error(`HTTP ${response.status}`);
+            return response.json();
+        })
+        .then(data => data.html);
+}
+
+// Render Markdown into targetElement
+function renderMarkdown(markdownText, targetElement, breaks = false) {
+    if (!markdownText || !targetElement) return Promise.resolve();
+
+    return fetchMarkdown(markdownText, breaks)
+        .then(html => {
+            targetElement.innerHTML = html;
+            initSyntaxHighlighting(targetElement);
+        })
+        .catch(error => {
+            console.error('Error rendering markdown:', error);
+            targetElement.innerHTML = `<pre class="markdown-fallback">${escapeHtml(markdownText)}</pre>`;
+        });
 }
 
-// Initialize Markdown parsing with cleanup (for challenge descriptions)
+// Render a challenge README into targetElement, without its scoreboard link
 function renderMarkdownAndCleanup(markdownText, targetElement) {
-    if (!markdownText || !targetElement) return;
-    
-    // Set options for the markdown parser
-    marked.setOptions({
-        gfm: true,      // GitHub flavored markdown
-        breaks: true,   // Line breaks are rendered
-        highlight: function(code, lang) {
-            // Normalize language name - convert 'go' to 'golang' if needed
-            let language = lang;
-            if (lang === 'go') {
-                language = 'golang';
-            } else {
-                language = hljs.getLanguage(lang) ? lang : 'plaintext';
-            }
-            return hljs.highlight(code, { language }).value;
-        },
-        langPrefix: 'language-'  // Add proper language prefix for CSS
-    });
-    
-    // Parse and render markdown
-    targetElement.innerHTML = marked.parse(markdownText);
-    
-    // Clean up any script tags for security
-    const scripts = targetElement.querySelectorAll('script');
-    scripts.forEach(script => script.remove());
-    
-    // Additionally, ensure Go code blocks are properly highlighted
-    targetElement.querySelectorAll('pre code.language-go').forEach((el) => {
-        el.className = 'language-golang';
-        hljs.highlightElement(el);
-    });
+    if (!markdownText) return Promise.resolve();
+    const cleanedMarkdown = markdownText.replace(/^.*\(\s*scoreboard\.md\s*\).*$/gmi, '').trim();
+    return renderMarkdown(cleanedMarkdown, targetElement);
+}
+
+// For HTML built as a string: markdownPlaceholder returns an element that shows the
+// escaped text until renderPendingMarkdown replaces every placeholder under root with
+// its rendered Markdown, in one request.
+let markdownPlaceholderCount = 0;
+const pendingMarkdown = new Map();
+
+function markdownPlaceholder(markdownText, breaks = false) {
+    const id = `md-${++markdownPlaceholderCount}`;
+    const text = String(markdownText || '');
+    pendingMarkdown.set(id, { markdown: text, breaks });
+    return `<div class="markdown-pending" data-markdown-id="${id}" style="white-space: pre-wrap;">${escapeHtml(text)}</div>`;
+}
+
+function renderPendingMarkdown(root = document) {
+    const elements = Array.from(root.querySelectorAll('.markdown-pending[data-markdown-id]'))
+        .filter(el => pendingMarkdown.has(el.dataset.markdownId));
+    if (elements.length === 0) return Promise.resolve();
+
+    const documents = elements.map(el => pendingMarkdown.get(el.dataset.markdownId));
+    elements.forEach(el => pendingMarkdown.delete(el.dataset.markdownId));
+
+    return fetch('/api/markdown', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ documents })
+    })
+        .then(response => {
+            if (!response.ok) throw new Error(`HTTP ${response.status}`);
+            return response.json();
+        })
+        .then(data => {
+            elements.forEach((el, i) => {
+                el.innerHTML = data.html[i];
+                el.classList.remove('markdown-pending');
+                el.removeAttribute('style');
+                initSyntaxHighlighting(el);
+            });
+        })
+        .catch(error => console.error('Error rendering markdown:', error));
 }
 
 // Escape HTML for safe output
@@ -518,6 +531,40 @@ function handleFormSubmit(formElement, successCallback, errorCallback) {
     });
 }
 
+// Language menu: lists the languages challenge content is translated into, with their
+// coverage, and remembers the choice in the lang cookie the server reads
+function initLanguageMenu() {
+    const menu = document.getElementById('language-menu');
+    const options = document.getElementById('language-options');
+    if (!menu || !options) return;
+
+    fetch('/api/languages')
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success || !data.languages || data.languages.length === 0) return;
+
+            const languages = [{ language: data.default, percent: 100 }].concat(data.languages);
+            options.innerHTML = languages.map(l => `
+                <li><a class="dropdown-item d-flex justify-content-between ${l.language === data.current ? 'active' : ''}" href="#" data-language="${escapeHtml(l.language)}">
+                    <span>${escapeHtml(l.language.toUpperCase())}</span>
+                    <small class="ms-3 opacity-75">${l.percent}%</small>
+                </a></li>`).join('');
+            document.getElementById('language-current').textContent = data.current.toUpperCase();
+            menu.classList.remove('d-none');
+
+            options.querySelectorAll('[data-language]').forEach(item => {
+                item.addEventListener('click', event => {
+                    event.preventDefault();
+                    const expiryDate = new Date();
+                    expiryDate.setFullYear(expiryDate.getFullYear() + 1);
+                    document.cookie = `lang=${item.dataset.language}; expires=${expiryDate.toUTCString()}; path=/`;
+                    window.location.reload();
+                });
+            });
+        })
+        .catch(error => console.error('Error loading languages:', error));
+}
+
 // Custom template function for truncating text in templates
 function truncateDescription(text, maxLength = 100) {
     if (!text) return '';
@@ -536,6 +583,9 @@ document.addEventListener('DOMContentLoaded', function() {
     
     // Initialize syntax highlighting
     initSyntaxHighlighting();
+
+    // Content language menu in the navbar
+    initLanguageMenu();
     
     // Apply renderMarkdown to all markdown content containers
     document.querySelectorAll('.markdown-content').forEach(function(el) {
@@ -544,14 +594,6 @@ document.addEventListener('DOMContentLoaded', function() {
         if (content) {
             renderMarkdown(content, el);
         }
-        // Otherwise just ensure proper syntax highlighting for existing content
-        else {
-            // Make sure Go code blocks have the right class for syntax highlighting
-            el.querySelectorAll('pre code.language-go').forEach(function(codeEl) {
-                codeEl.className = 'language-golang';
-                hljs.highlightElement(codeEl);
-            });
-        }
     });
     
     // Handle username persistence
@@ -654,19 +696,13 @@ function initializeHints(hintsText) {
                         <h6 class="alert-heading">
                             <i class="bi bi-lightbulb me-2"></i>Hint ${hintNumber}
                         </h6>
-                        <div class="markdown-content">${marked.parse(hint)}</div>
+                        <div class="markdown-content">${markdownPlaceholder(hint)}</div>
                     </div>
                     <span class="badge bg-warning text-dark ms-2">${hintNumber}</span>
                 </div>
             `;
             container.appendChild(hintDiv);
-            
-            // Apply syntax highlighting to code blocks in the hint
-            setTimeout(() => {
-                hintDiv.querySelectorAll('pre code').forEach((el) => {
-                    hljs.highlightElement(el);
-                });
-            }, 100);
+            renderPendingMarkdown(hintDiv);
         };
         
         // Helper function to update progress display
@@ -677,4 +713,97 @@ function initializeHints(hintsText) {
     } catch (error) {
         console.error('Error initializing hints:', error);
     }
-} 
\ No newline at end of file
+} 
+// Reference solutions: the "Compare" tab appears once the user has a passing submission.
+// The server decides from its session cookie, so no username is sent.
+// options: {challengeId, packageName, getCode}
+function initReferenceCompare(options) {
+    const tabItem = document.getElementById('compare-tab-item');
+    const content = document.getElementById('compare-content');
+    const button = document.getElementById('compare-button');
+    if (!tabItem || !content || !button) return null;
+
+    const params = () => {
+        const query = new URLSearchParams({ challengeId: options.challengeId });
+        if (options.packageName) query.set('package', options.packageName);
+        return query;
+    };
+
+    const refresh = () => fetch(`/api/reference?${params()}`)
+        .then(response => response.json())
+        .then(data => {
+            tabItem.classList.toggle('d-none', !data.unlocked);
+            return data.unlocked;
+        })
+        .catch(() => false);
+
+    button.addEventListener('click', function() {
+        button.disabled = true;
+        content.innerHTML = `<div class="text-center py-4 text-muted">
+            <div class="spinner-border spinner-border-sm me-2" role="status"></div>Running the reference benchmarks...
+        </div>`;
+
+        fetch('/api/reference', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                package: options.packageName || '',
+                challengeId: String(options.challengeId),
+                code: options.getCode()
+            })
+        })
+        .then(async response => {
+            if (!response.ok) throw new Error(await response.text());
+            return response.json();
+        })
+        .then(data => renderReferenceComparison(content, data.comparison))
+        .catch(error => {
+            content.innerHTML = `<div class="alert alert-warning">${escapeHtml(error.message)}</div>`;
+        })
+        .finally(() => { button.disabled = false; });
+    });
+
+    refresh();
+    return { refresh };
+}
+
+// Render a side-by-side diff of the user's code and the reference, and its benchmarks
+function renderReferenceComparison(container, comparison) {
+    const cell = (line, text, kind) => `
+        <td class="diff-num">${line || ''}</td>
+        <td class="diff-code diff-${kind}">${line ? escapeHtml(text) : ''}</td>`;
+
+    const rows = comparison.diff.rows.map(row => {
+        const left = row.kind === 'added' ? 'empty' : (row.kind === 'equal' ? 'equal' : 'removed');
+        const right = row.kind === 'removed' ? 'empty' : (row.kind === 'equal' ? 'equal' : 'added');
+        return `<tr>${cell(row.leftLine, row.left, left)}${cell(row.rightLine, row.right, right)}</tr>`;
+    }).join('');
+
+    let benchmarks;
+    if (comparison.benchmarkError) {
+        benchmarks = `<div class="alert alert-warning small">${escapeHtml(comparison.benchmarkError)}</div>`;
+    } else if (comparison.benchmarks.length === 0) {
+        benchmarks = '<p class="text-muted small">This challenge has no benchmarks.</p>';
+    } else {
+        benchmarks = `<table class="table table-sm small">
+            <thead><tr><th>Benchmark</th><th class="text-end">ns/op</th><th class="text-end">B/op</th><th class="text-end">allocs/op</th></tr></thead>
+            <tbody>${comparison.benchmarks.map(b => `<tr>
+                <td><code>${escapeHtml(b.name)}</code></td>
+                <td class="text-end">${b.nsPerOp.toLocaleString()}</td>
+                <td class="text-end">${b.bytesPerOp.toLocaleString()}</td>
+                <td class="text-end">${b.allocsPerOp.toLocaleString()}</td>
+            </tr>`).join('')}</tbody>
+        </table>`;
+    }
+
+    container.innerHTML = `
+        <div class="d-flex justify-content-between align-items-center mb-2">
+            <small class="text-muted">Your solution (left) and <code>${escapeHtml(comparison.source)}</code> (right)</small>
+            <small><span class="text-danger">-${comparison.diff.removed}</span> <span class="text-success">+${comparison.diff.added}</span></small>
+        </div>
+        <div class="reference-diff mb-3">
+            <table class="table table-sm mb-0"><tbody>${rows}</tbody></table>
+        </div>
+        <h6>Reference benchmarks</h6>
+        ${benchmarks}`;
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"web-ui/internal/services"
+)
+
+// runValidate implements `web-ui validate`: it checks every challenge and package,
+// writes a JSON report and returns the exit code (0 valid, 1 invalid, 2 usage or I/O error)
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: web-ui validate [flags]")
+		fmt.Fprintln(flags.Output(), "Checks classic and package challenges and prints a JSON report.")
+		flags.PrintDefaults()
+	}
+	only := flags.String("only", "", "comma-separated targets to check, e.g. challenge-12,gin,gin/challenge-1-basic-routing")
+	skipExec := flags.Bool("skip-exec", false, "skip compiling templates and running tests")
+	parallel := flags.Int("parallel", (runtime.NumCPU()+1)/2, "targets checked at the same time")
+	output := flags.String("o", "", "write the report to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	validator, err := services.NewContentValidator(services.NewExecutionService())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+		return 2
+	}
+	options := services.ValidateOptions{SkipExec: *skipExec, Parallel: *parallel}
+	for _, name := range strings.Split(*only, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			options.Only = append(options.Only, name)
+		}
+	}
+
+	report, err := validator.Validate(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+		return 2
+	}
+
+	data, _ := json.MarshalIndent(report, "", "  ")
+	data = append(data, '\n')
+	if *output != "" {
+		err = os.WriteFile(*output, data, 0644)
+	} else {
+		_, err = os.Stdout.Write(data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+		return 2
+	}
+
+	fmt.Fprintf(os.Stderr, "%d targets, %d errors, %d warnings\n", len(report.Targets), report.Errors, report.Warnings)
+	if !report.Passed {
+		return 1
+	}
+	return 0
+}